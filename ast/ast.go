@@ -11,6 +11,26 @@ import (
 type Node interface {
 	TokenLiteral() string
 	String() string
+	Pos() token.Position // position of the first character of the node
+	End() token.Position // position just after the last character of the node
+}
+
+// tokenEnd returns the position just after the token. Tokens do not span lines.
+func tokenEnd(tok token.Token) token.Position {
+	length := len(tok.Literal)
+	if tok.Type == token.STRING {
+		length += 2 // the quotes
+	}
+	return token.Position{Line: tok.Pos.Line, Column: tok.Pos.Column + length}
+}
+
+// closerEnd returns the position just after a one-character closing delimiter
+// at pos, or fallback if the delimiter is missing.
+func closerEnd(pos token.Position, fallback token.Position) token.Position {
+	if !pos.IsValid() {
+		return fallback
+	}
+	return token.Position{Line: pos.Line, Column: pos.Column + 1}
 }
 
 type Statement interface {
@@ -35,6 +55,20 @@ func (p *File) TokenLiteral() string {
 	}
 }
 
+func (p *File) Pos() token.Position {
+	if len(p.Statements) > 0 {
+		return p.Statements[0].Pos()
+	}
+	return token.Position{}
+}
+
+func (p *File) End() token.Position {
+	if len(p.Statements) > 0 {
+		return p.Statements[len(p.Statements)-1].End()
+	}
+	return token.Position{}
+}
+
 func (p *File) String() string {
 	var out bytes.Buffer
 	for _, s := range p.Statements {
@@ -44,9 +78,11 @@ func (p *File) String() string {
 }
 
 type AssignStatement struct {
-	Token token.Token // the type token
-	Name  *Identifier
-	Value Expression
+	Token    token.Token // the type token
+	Name     *Identifier
+	Parent   *Identifier // the object named after EXTENDS, nil if there is none
+	Position Expression  // the expression after AT, nil if there is none
+	Value    Expression
 }
 
 func (ls *AssignStatement) statementNode() {
@@ -56,10 +92,33 @@ func (ls *AssignStatement) TokenLiteral() string {
 	return ls.Token.Literal
 }
 
+func (ls *AssignStatement) Pos() token.Position { return ls.Token.Pos }
+
+func (ls *AssignStatement) End() token.Position {
+	switch {
+	case ls.Value != nil:
+		return ls.Value.End()
+	case ls.Position != nil:
+		return ls.Position.End()
+	case ls.Parent != nil:
+		return ls.Parent.End()
+	case ls.Name != nil:
+		return ls.Name.End()
+	default:
+		return tokenEnd(ls.Token)
+	}
+}
+
 func (ls *AssignStatement) String() string {
 	var out bytes.Buffer
 	out.WriteString(ls.TokenLiteral() + " ")
 	out.WriteString(ls.Name.String())
+	if ls.Parent != nil {
+		out.WriteString(" EXTENDS " + ls.Parent.String())
+	}
+	if ls.Position != nil {
+		out.WriteString(" AT " + ls.Position.String())
+	}
 	out.WriteString(" = ")
 	if ls.Value != nil {
 		out.WriteString(ls.Value.String())
@@ -80,6 +139,19 @@ func (ms *ModifyStatement) TokenLiteral() string {
 	return ms.Token.Literal
 }
 
+func (ms *ModifyStatement) Pos() token.Position { return ms.Token.Pos }
+
+func (ms *ModifyStatement) End() token.Position {
+	switch {
+	case ms.Value != nil:
+		return ms.Value.End()
+	case ms.Name != nil:
+		return ms.Name.End()
+	default:
+		return tokenEnd(ms.Token)
+	}
+}
+
 func (ms *ModifyStatement) String() string {
 	var out bytes.Buffer
 	out.WriteString(fmt.Sprintf("%s %s ", ms.Token.Literal, ms.Name.String()))
@@ -89,6 +161,35 @@ func (ms *ModifyStatement) String() string {
 	return out.String()
 }
 
+type IncludeStatement struct {
+	Token token.Token // the token.INCLUDE token
+	Path  *StringLiteral
+}
+
+func (is *IncludeStatement) statementNode()       {}
+func (is *IncludeStatement) TokenLiteral() string { return is.Token.Literal }
+func (is *IncludeStatement) Pos() token.Position  { return is.Token.Pos }
+func (is *IncludeStatement) End() token.Position {
+	if is.Path != nil {
+		return is.Path.End()
+	}
+	return tokenEnd(is.Token)
+}
+func (is *IncludeStatement) String() string {
+	return fmt.Sprintf("%s %s", is.Token.Literal, is.Path.String())
+}
+
+type StringLiteral struct {
+	Token token.Token // the token.STRING token
+	Value string
+}
+
+func (sl *StringLiteral) expressionNode()      {}
+func (sl *StringLiteral) TokenLiteral() string { return sl.Token.Literal }
+func (sl *StringLiteral) Pos() token.Position  { return sl.Token.Pos }
+func (sl *StringLiteral) End() token.Position  { return tokenEnd(sl.Token) }
+func (sl *StringLiteral) String() string       { return fmt.Sprintf("%q", sl.Value) }
+
 type Identifier struct {
 	Token token.Token // the token.IDENT token
 	Value string
@@ -102,6 +203,10 @@ func (i *Identifier) TokenLiteral() string {
 	return i.Token.Literal
 }
 
+func (i *Identifier) Pos() token.Position { return i.Token.Pos }
+
+func (i *Identifier) End() token.Position { return tokenEnd(i.Token) }
+
 func (i *Identifier) String() string { return i.Value }
 
 type ExpressionStatement struct {
@@ -111,6 +216,13 @@ type ExpressionStatement struct {
 
 func (es *ExpressionStatement) statementNode()       {}
 func (es *ExpressionStatement) TokenLiteral() string { return es.Token.Literal }
+func (es *ExpressionStatement) Pos() token.Position  { return es.Token.Pos }
+func (es *ExpressionStatement) End() token.Position {
+	if es.Expression != nil {
+		return es.Expression.End()
+	}
+	return tokenEnd(es.Token)
+}
 func (es *ExpressionStatement) String() string {
 	if es.Expression != nil {
 		return es.Expression.String()
@@ -125,6 +237,8 @@ type FloatLiteral struct {
 
 func (fl *FloatLiteral) expressionNode()      {}
 func (fl *FloatLiteral) TokenLiteral() string { return fl.Token.Literal }
+func (fl *FloatLiteral) Pos() token.Position  { return fl.Token.Pos }
+func (fl *FloatLiteral) End() token.Position  { return tokenEnd(fl.Token) }
 func (fl *FloatLiteral) String() string       { return fl.Token.Literal }
 
 type PrefixExpression struct {
@@ -135,6 +249,13 @@ type PrefixExpression struct {
 
 func (pe *PrefixExpression) expressionNode()      {}
 func (pe *PrefixExpression) TokenLiteral() string { return pe.Token.Literal }
+func (pe *PrefixExpression) Pos() token.Position  { return pe.Token.Pos }
+func (pe *PrefixExpression) End() token.Position {
+	if pe.Right != nil {
+		return pe.Right.End()
+	}
+	return tokenEnd(pe.Token)
+}
 func (pe *PrefixExpression) String() string {
 	var out bytes.Buffer
 	out.WriteString("(")
@@ -153,6 +274,13 @@ type InfixExpression struct {
 
 func (oe *InfixExpression) expressionNode()      {}
 func (oe *InfixExpression) TokenLiteral() string { return oe.Token.Literal }
+func (oe *InfixExpression) Pos() token.Position  { return oe.Left.Pos() }
+func (oe *InfixExpression) End() token.Position {
+	if oe.Right != nil {
+		return oe.Right.End()
+	}
+	return tokenEnd(oe.Token)
+}
 func (oe *InfixExpression) String() string {
 	var out bytes.Buffer
 	out.WriteString("(")
@@ -163,13 +291,72 @@ func (oe *InfixExpression) String() string {
 	return out.String()
 }
 
+type IndexExpression struct {
+	Token    token.Token // The [ token
+	Left     Expression
+	Index    Expression
+	Rbracket token.Position // position of the closing ]
+}
+
+func (ie *IndexExpression) expressionNode()      {}
+func (ie *IndexExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *IndexExpression) Pos() token.Position  { return ie.Left.Pos() }
+func (ie *IndexExpression) End() token.Position {
+	if ie.Index != nil {
+		return closerEnd(ie.Rbracket, ie.Index.End())
+	}
+	return closerEnd(ie.Rbracket, tokenEnd(ie.Token))
+}
+func (ie *IndexExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("(")
+	out.WriteString(ie.Left.String())
+	out.WriteString("[")
+	out.WriteString(ie.Index.String())
+	out.WriteString("])")
+	return out.String()
+}
+
+type MemberExpression struct {
+	Token    token.Token // The . token
+	Object   Expression
+	Property *Identifier
+}
+
+func (me *MemberExpression) expressionNode()      {}
+func (me *MemberExpression) TokenLiteral() string { return me.Token.Literal }
+func (me *MemberExpression) Pos() token.Position  { return me.Object.Pos() }
+func (me *MemberExpression) End() token.Position {
+	if me.Property != nil {
+		return me.Property.End()
+	}
+	return tokenEnd(me.Token)
+}
+func (me *MemberExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("(")
+	out.WriteString(me.Object.String())
+	out.WriteString(".")
+	out.WriteString(me.Property.String())
+	out.WriteString(")")
+	return out.String()
+}
+
 type ArrayExpression struct {
 	Token    token.Token // The token.ARRAY token
 	Elements []Expression
+	Rbracket token.Position // position of the closing ]
 }
 
 func (ae *ArrayExpression) expressionNode()      {}
 func (ae *ArrayExpression) TokenLiteral() string { return ae.Token.Literal }
+func (ae *ArrayExpression) Pos() token.Position  { return ae.Token.Pos }
+func (ae *ArrayExpression) End() token.Position {
+	if len(ae.Elements) > 0 {
+		return closerEnd(ae.Rbracket, ae.Elements[len(ae.Elements)-1].End())
+	}
+	return closerEnd(ae.Rbracket, tokenEnd(ae.Token))
+}
 func (ae *ArrayExpression) String() string {
 	var out bytes.Buffer
 	args := []string{}
@@ -183,17 +370,26 @@ func (ae *ArrayExpression) String() string {
 }
 
 type PropertiesExpression struct {
-	Token      token.Token // The token.PROPERTIES token
+	Token      token.Token   // The token.PROPERTIES token
+	Keys       []*Identifier // The keys in the order they appear in the source
 	Properties map[string]Expression
+	Rbrace     token.Position // position of the closing }
 }
 
 func (pe *PropertiesExpression) expressionNode()      {}
 func (pe *PropertiesExpression) TokenLiteral() string { return pe.Token.Literal }
+func (pe *PropertiesExpression) Pos() token.Position  { return pe.Token.Pos }
+func (pe *PropertiesExpression) End() token.Position {
+	if len(pe.Keys) > 0 {
+		return closerEnd(pe.Rbrace, pe.Properties[pe.Keys[len(pe.Keys)-1].Value].End())
+	}
+	return closerEnd(pe.Rbrace, tokenEnd(pe.Token))
+}
 func (pe *PropertiesExpression) String() string {
 	var out bytes.Buffer
 	out.WriteString("{\n")
-	for k, v := range pe.Properties {
-		out.WriteString(fmt.Sprintf("%s: %s,\n", k, v.String()))
+	for _, key := range pe.Keys {
+		out.WriteString(fmt.Sprintf("%s: %s,\n", key.Value, pe.Properties[key.Value].String()))
 	}
 	out.WriteString("}")
 	return out.String()
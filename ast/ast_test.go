@@ -59,6 +59,10 @@ NUMBER negative = (-1.0)
 	}
 }
 
+func key(name string) *Identifier {
+	return &Identifier{Token: token.Token{Type: token.IDENT, Literal: name}, Value: name}
+}
+
 func TestPropertiesStatement(t *testing.T) {
 	file := &File{
 		Statements: []Statement{
@@ -70,6 +74,7 @@ func TestPropertiesStatement(t *testing.T) {
 				},
 				Value: &PropertiesExpression{
 					Token: token.Token{Type: token.PROPERTIES, Literal: "{"},
+					Keys:  []*Identifier{key("specularIntensity"), key("color"), key("ambientIntensity"), key("diffuseIntensity")},
 					Properties: map[string]Expression{
 						"ambientIntensity": &FloatLiteral{Token: token.Token{Type: token.FLOAT, Literal: "0.1"}, Value: 0.1},
 						"color": &Identifier{
@@ -84,32 +89,18 @@ func TestPropertiesStatement(t *testing.T) {
 		},
 	}
 
-	expectedLines := []string{
-		"MATERIAL shiny = {",
-		"ambientIntensity: 0.1,",
-		"color: white,",
-		"diffuseIntensity: 0.7,",
-		"specularIntensity: 1.0,",
-		"}",
-	}
-
-	// Split the output into lines and trim spaces.
-	output := file.String()
-	outputLines := strings.Split(output, "\n")
-	for i := range outputLines {
-		outputLines[i] = strings.TrimSpace(outputLines[i])
-	}
-
-	// Create a map for quick lookup of lines.
-	outputMap := make(map[string]bool)
-	for _, line := range outputLines {
-		outputMap[line] = true
-	}
+	// Properties are printed in the order of their keys.
+	expectedContent := `MATERIAL shiny = {
+specularIntensity: 1.0,
+color: white,
+ambientIntensity: 0.1,
+diffuseIntensity: 0.7,
+}
+`
 
-	// Check that each expected line is present.
-	for _, expectedLine := range expectedLines {
-		if !outputMap[expectedLine] {
-			t.Errorf("Expected line '%s' not found in output: %q", expectedLine, output)
+	for i := 0; i < 10; i++ {
+		if got := file.String(); got != expectedContent {
+			t.Fatalf("file.String() wrong. got=%q", got)
 		}
 	}
 }
@@ -125,6 +116,7 @@ func TestModifyStatement(t *testing.T) {
 				},
 				Value: &PropertiesExpression{
 					Token: token.Token{Type: token.PROPERTIES, Literal: "{"},
+					Keys:  []*Identifier{key("position")},
 					Properties: map[string]Expression{
 						"position": &ArrayExpression{
 							Token: token.Token{Type: token.LBRACKET, Literal: "["},
@@ -150,3 +142,49 @@ position: [0.0, 0.0, 0.0],
 		t.Errorf("file.String() wrong. got=%q", got)
 	}
 }
+
+func TestInspect(t *testing.T) {
+	file := &File{
+		Statements: []Statement{
+			&AssignStatement{
+				Token:  token.Token{Type: token.SPHERE, Literal: "SPHERE"},
+				Name:   &Identifier{Token: token.Token{Type: token.IDENT, Literal: "big"}, Value: "big"},
+				Parent: &Identifier{Token: token.Token{Type: token.IDENT, Literal: "small"}, Value: "small"},
+				Value: &InfixExpression{
+					Token:    token.Token{Type: token.MULTIPLY, Literal: "*"},
+					Operator: "*",
+					Left: &MemberExpression{
+						Token:    token.Token{Type: token.DOT, Literal: "."},
+						Object:   &Identifier{Token: token.Token{Type: token.IDENT, Literal: "small"}, Value: "small"},
+						Property: &Identifier{Token: token.Token{Type: token.IDENT, Literal: "radius"}, Value: "radius"},
+					},
+					Right: &FloatLiteral{Token: token.Token{Type: token.FLOAT, Literal: "2"}, Value: 2},
+				},
+			},
+		},
+	}
+
+	var identifiers []string
+	Inspect(file, func(node Node) bool {
+		if ident, ok := node.(*Identifier); ok {
+			identifiers = append(identifiers, ident.Value)
+		}
+		return true
+	})
+
+	expected := []string{"big", "small", "small", "radius"}
+	if strings.Join(identifiers, " ") != strings.Join(expected, " ") {
+		t.Errorf("wrong identifiers visited. expected=%v, got=%v", expected, identifiers)
+	}
+
+	var visited int
+	Inspect(file, func(node Node) bool {
+		visited++
+		_, isStatement := node.(*AssignStatement)
+		return !isStatement
+	})
+
+	if visited != 2 {
+		t.Errorf("Inspect did not stop at the statement. visited=%d", visited)
+	}
+}
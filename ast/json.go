@@ -0,0 +1,648 @@
+package ast
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/kacperkrolak/scene-description-language/token"
+)
+
+// Nodes are encoded in JSON as objects with the kind of the node, its span
+// in the source and its fields, e.g. a float literal as
+//
+//	{"kind": "FloatLiteral", "span": {...}, "literal": "1.0", "value": 1}
+//
+// Properties are encoded as an array of key and value pairs in source order.
+// Decoding restores the tokens of the nodes from their kinds and spans,
+// except the positions of infix operators, [ of index expressions and
+// . of member expressions, which are not part of the spans.
+
+// Span is the part of the source covered by a node.
+type Span struct {
+	Start token.Position `json:"start"`
+	End   token.Position `json:"end"` // position just after the last character
+}
+
+func spanOf(node Node) Span {
+	return Span{Start: node.Pos(), End: node.End()}
+}
+
+// header holds the members every encoded node has.
+type header struct {
+	Kind string `json:"kind"`
+	Span Span   `json:"span"`
+}
+
+func headerOf(node Node) header {
+	return header{Kind: reflect.TypeOf(node).Elem().Name(), Span: spanOf(node)}
+}
+
+func (p *File) MarshalJSON() ([]byte, error) {
+	statements := p.Statements
+	if statements == nil {
+		statements = []Statement{}
+	}
+	return json.Marshal(struct {
+		header
+		Statements []Statement `json:"statements"`
+	}{headerOf(p), statements})
+}
+
+func (ls *AssignStatement) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		header
+		Class    string      `json:"class"`
+		Name     *Identifier `json:"name"`
+		Parent   *Identifier `json:"parent,omitempty"`
+		Position Expression  `json:"position,omitempty"`
+		Value    Expression  `json:"value"`
+	}{headerOf(ls), ls.Token.Literal, ls.Name, ls.Parent, ls.Position, ls.Value})
+}
+
+func (ms *ModifyStatement) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		header
+		Name  *Identifier `json:"name"`
+		Value Expression  `json:"value"`
+	}{headerOf(ms), ms.Name, ms.Value})
+}
+
+func (is *IncludeStatement) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		header
+		Path *StringLiteral `json:"path"`
+	}{headerOf(is), is.Path})
+}
+
+func (es *ExpressionStatement) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		header
+		Expression Expression `json:"expression"`
+	}{headerOf(es), es.Expression})
+}
+
+func (sl *StringLiteral) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		header
+		Value string `json:"value"`
+	}{headerOf(sl), sl.Value})
+}
+
+func (i *Identifier) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		header
+		Value string `json:"value"`
+	}{headerOf(i), i.Value})
+}
+
+func (fl *FloatLiteral) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		header
+		Literal string  `json:"literal"`
+		Value   float64 `json:"value"`
+	}{headerOf(fl), fl.Token.Literal, fl.Value})
+}
+
+func (pe *PrefixExpression) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		header
+		Operator string     `json:"operator"`
+		Right    Expression `json:"right"`
+	}{headerOf(pe), pe.Operator, pe.Right})
+}
+
+func (oe *InfixExpression) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		header
+		Left     Expression `json:"left"`
+		Operator string     `json:"operator"`
+		Right    Expression `json:"right"`
+	}{headerOf(oe), oe.Left, oe.Operator, oe.Right})
+}
+
+func (ie *IndexExpression) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		header
+		Left  Expression `json:"left"`
+		Index Expression `json:"index"`
+	}{headerOf(ie), ie.Left, ie.Index})
+}
+
+func (me *MemberExpression) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		header
+		Object   Expression  `json:"object"`
+		Property *Identifier `json:"property"`
+	}{headerOf(me), me.Object, me.Property})
+}
+
+func (ae *ArrayExpression) MarshalJSON() ([]byte, error) {
+	elements := ae.Elements
+	if elements == nil {
+		elements = []Expression{}
+	}
+	return json.Marshal(struct {
+		header
+		Elements []Expression `json:"elements"`
+	}{headerOf(ae), elements})
+}
+
+// property is a key and value of a properties expression in JSON.
+type property struct {
+	Key   *Identifier `json:"key"`
+	Value Expression  `json:"value"`
+}
+
+func (pe *PropertiesExpression) MarshalJSON() ([]byte, error) {
+	properties := make([]property, len(pe.Keys))
+	for i, key := range pe.Keys {
+		properties[i] = property{Key: key, Value: pe.Properties[key.Value]}
+	}
+	return json.Marshal(struct {
+		header
+		Properties []property `json:"properties"`
+	}{headerOf(pe), properties})
+}
+
+// UnmarshalNode decodes a node of any kind from JSON.
+func UnmarshalNode(data []byte) (Node, error) {
+	var h header
+	if err := json.Unmarshal(data, &h); err != nil {
+		return nil, err
+	}
+
+	switch h.Kind {
+	case "File":
+		return unmarshalFile(data)
+	case "AssignStatement":
+		return unmarshalAssignStatement(data, h)
+	case "ModifyStatement":
+		return unmarshalModifyStatement(data, h)
+	case "IncludeStatement":
+		return unmarshalIncludeStatement(data, h)
+	case "ExpressionStatement":
+		return unmarshalExpressionStatement(data, h)
+	case "StringLiteral":
+		return unmarshalStringLiteral(data, h)
+	case "Identifier":
+		return unmarshalIdentifier(data, h)
+	case "FloatLiteral":
+		return unmarshalFloatLiteral(data, h)
+	case "PrefixExpression":
+		return unmarshalPrefixExpression(data, h)
+	case "InfixExpression":
+		return unmarshalInfixExpression(data, h)
+	case "IndexExpression":
+		return unmarshalIndexExpression(data, h)
+	case "MemberExpression":
+		return unmarshalMemberExpression(data, h)
+	case "ArrayExpression":
+		return unmarshalArrayExpression(data, h)
+	case "PropertiesExpression":
+		return unmarshalPropertiesExpression(data, h)
+	case "":
+		return nil, fmt.Errorf("node without a kind")
+	default:
+		return nil, fmt.Errorf("unknown node kind %q", h.Kind)
+	}
+}
+
+func unmarshalStatement(data json.RawMessage, field string) (Statement, error) {
+	node, err := unmarshalField(data, field)
+	if err != nil {
+		return nil, err
+	}
+	statement, ok := node.(Statement)
+	if !ok {
+		return nil, fmt.Errorf("%s: %T is not a statement", field, node)
+	}
+	return statement, nil
+}
+
+func unmarshalExpression(data json.RawMessage, field string) (Expression, error) {
+	node, err := unmarshalField(data, field)
+	if err != nil {
+		return nil, err
+	}
+	expression, ok := node.(Expression)
+	if !ok {
+		return nil, fmt.Errorf("%s: %T is not an expression", field, node)
+	}
+	return expression, nil
+}
+
+// unmarshalOptional decodes an expression which may be left out.
+func unmarshalOptional(data json.RawMessage, field string) (Expression, error) {
+	if isNull(data) {
+		return nil, nil
+	}
+	return unmarshalExpression(data, field)
+}
+
+func unmarshalIdentifierField(data json.RawMessage, field string) (*Identifier, error) {
+	node, err := unmarshalField(data, field)
+	if err != nil {
+		return nil, err
+	}
+	identifier, ok := node.(*Identifier)
+	if !ok {
+		return nil, fmt.Errorf("%s: %T is not an identifier", field, node)
+	}
+	return identifier, nil
+}
+
+func unmarshalField(data json.RawMessage, field string) (Node, error) {
+	if isNull(data) {
+		return nil, fmt.Errorf("missing %s", field)
+	}
+	node, err := UnmarshalNode(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", field, err)
+	}
+	return node, nil
+}
+
+func isNull(data json.RawMessage) bool {
+	return len(data) == 0 || string(data) == "null"
+}
+
+func unmarshalFile(data []byte) (*File, error) {
+	var fields struct {
+		Statements []json.RawMessage `json:"statements"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	file := &File{Statements: []Statement{}}
+	for i, raw := range fields.Statements {
+		statement, err := unmarshalStatement(raw, fmt.Sprintf("statements[%d]", i))
+		if err != nil {
+			return nil, err
+		}
+		file.Statements = append(file.Statements, statement)
+	}
+	return file, nil
+}
+
+func unmarshalAssignStatement(data []byte, h header) (*AssignStatement, error) {
+	var fields struct {
+		Class    string          `json:"class"`
+		Name     json.RawMessage `json:"name"`
+		Parent   json.RawMessage `json:"parent"`
+		Position json.RawMessage `json:"position"`
+		Value    json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	class := token.LookupIdent(fields.Class)
+	if class == token.IDENT {
+		return nil, fmt.Errorf("AssignStatement: unknown class %q", fields.Class)
+	}
+
+	s := &AssignStatement{Token: token.Token{Type: class, Literal: fields.Class, Pos: h.Span.Start}}
+	var err error
+	if s.Name, err = unmarshalIdentifierField(fields.Name, "AssignStatement.name"); err != nil {
+		return nil, err
+	}
+	if !isNull(fields.Parent) {
+		if s.Parent, err = unmarshalIdentifierField(fields.Parent, "AssignStatement.parent"); err != nil {
+			return nil, err
+		}
+	}
+	if s.Position, err = unmarshalOptional(fields.Position, "AssignStatement.position"); err != nil {
+		return nil, err
+	}
+	if s.Value, err = unmarshalExpression(fields.Value, "AssignStatement.value"); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func unmarshalModifyStatement(data []byte, h header) (*ModifyStatement, error) {
+	var fields struct {
+		Name  json.RawMessage `json:"name"`
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	s := &ModifyStatement{Token: token.Token{Type: token.MODIFY, Literal: "MODIFY", Pos: h.Span.Start}}
+	var err error
+	if s.Name, err = unmarshalIdentifierField(fields.Name, "ModifyStatement.name"); err != nil {
+		return nil, err
+	}
+	if s.Value, err = unmarshalExpression(fields.Value, "ModifyStatement.value"); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func unmarshalIncludeStatement(data []byte, h header) (*IncludeStatement, error) {
+	var fields struct {
+		Path json.RawMessage `json:"path"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	node, err := unmarshalField(fields.Path, "IncludeStatement.path")
+	if err != nil {
+		return nil, err
+	}
+	path, ok := node.(*StringLiteral)
+	if !ok {
+		return nil, fmt.Errorf("IncludeStatement.path: %T is not a string", node)
+	}
+
+	return &IncludeStatement{Token: token.Token{Type: token.INCLUDE, Literal: "INCLUDE", Pos: h.Span.Start}, Path: path}, nil
+}
+
+func unmarshalExpressionStatement(data []byte, h header) (*ExpressionStatement, error) {
+	var fields struct {
+		Expression json.RawMessage `json:"expression"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	expression, err := unmarshalExpression(fields.Expression, "ExpressionStatement.expression")
+	if err != nil {
+		return nil, err
+	}
+
+	// The statement starts with the first token of the expression,
+	// unless the expression is in parentheses.
+	tok := firstToken(expression)
+	if h.Span.Start != expression.Pos() {
+		tok = token.Token{Type: token.LPAREN, Literal: "(", Pos: h.Span.Start}
+	}
+	return &ExpressionStatement{Token: tok, Expression: expression}, nil
+}
+
+// firstToken returns the token the expression starts with.
+func firstToken(expression Expression) token.Token {
+	switch e := expression.(type) {
+	case *InfixExpression:
+		return firstToken(e.Left)
+	case *IndexExpression:
+		return firstToken(e.Left)
+	case *MemberExpression:
+		return firstToken(e.Object)
+	case *Identifier:
+		return e.Token
+	case *FloatLiteral:
+		return e.Token
+	case *StringLiteral:
+		return e.Token
+	case *PrefixExpression:
+		return e.Token
+	case *ArrayExpression:
+		return e.Token
+	case *PropertiesExpression:
+		return e.Token
+	default:
+		return token.Token{}
+	}
+}
+
+func unmarshalStringLiteral(data []byte, h header) (*StringLiteral, error) {
+	var fields struct {
+		Value string `json:"value"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return &StringLiteral{Token: token.Token{Type: token.STRING, Literal: fields.Value, Pos: h.Span.Start}, Value: fields.Value}, nil
+}
+
+func unmarshalIdentifier(data []byte, h header) (*Identifier, error) {
+	var fields struct {
+		Value string `json:"value"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	if fields.Value == "" {
+		return nil, fmt.Errorf("Identifier: missing value")
+	}
+	tok := token.Token{Type: token.LookupIdent(fields.Value), Literal: fields.Value, Pos: h.Span.Start}
+	return &Identifier{Token: tok, Value: fields.Value}, nil
+}
+
+func unmarshalFloatLiteral(data []byte, h header) (*FloatLiteral, error) {
+	var fields struct {
+		Literal string   `json:"literal"`
+		Value   *float64 `json:"value"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	// Either member is enough, the literal keeps the number as it was written.
+	switch {
+	case fields.Literal == "" && fields.Value == nil:
+		return nil, fmt.Errorf("FloatLiteral: missing value")
+	case fields.Literal == "":
+		fields.Literal = strconv.FormatFloat(*fields.Value, 'f', -1, 64)
+	case fields.Value == nil:
+		value, err := strconv.ParseFloat(fields.Literal, 64)
+		if err != nil {
+			return nil, fmt.Errorf("FloatLiteral: invalid literal %q", fields.Literal)
+		}
+		fields.Value = &value
+	}
+
+	return &FloatLiteral{Token: token.Token{Type: token.FLOAT, Literal: fields.Literal, Pos: h.Span.Start}, Value: *fields.Value}, nil
+}
+
+var operators = map[string]token.TokenType{
+	"-": token.MINUS,
+	"+": token.PLUS,
+	"*": token.MULTIPLY,
+	"/": token.DIVIDE,
+}
+
+func unmarshalPrefixExpression(data []byte, h header) (*PrefixExpression, error) {
+	var fields struct {
+		Operator string          `json:"operator"`
+		Right    json.RawMessage `json:"right"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	if fields.Operator != "-" {
+		return nil, fmt.Errorf("PrefixExpression: unknown operator %q", fields.Operator)
+	}
+
+	right, err := unmarshalExpression(fields.Right, "PrefixExpression.right")
+	if err != nil {
+		return nil, err
+	}
+	return &PrefixExpression{Token: token.Token{Type: token.MINUS, Literal: fields.Operator, Pos: h.Span.Start}, Operator: fields.Operator, Right: right}, nil
+}
+
+func unmarshalInfixExpression(data []byte, h header) (*InfixExpression, error) {
+	var fields struct {
+		Left     json.RawMessage `json:"left"`
+		Operator string          `json:"operator"`
+		Right    json.RawMessage `json:"right"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	operator, ok := operators[fields.Operator]
+	if !ok {
+		return nil, fmt.Errorf("InfixExpression: unknown operator %q", fields.Operator)
+	}
+
+	e := &InfixExpression{Token: token.Token{Type: operator, Literal: fields.Operator}, Operator: fields.Operator}
+	var err error
+	if e.Left, err = unmarshalExpression(fields.Left, "InfixExpression.left"); err != nil {
+		return nil, err
+	}
+	if e.Right, err = unmarshalExpression(fields.Right, "InfixExpression.right"); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func unmarshalIndexExpression(data []byte, h header) (*IndexExpression, error) {
+	var fields struct {
+		Left  json.RawMessage `json:"left"`
+		Index json.RawMessage `json:"index"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	e := &IndexExpression{Token: token.Token{Type: token.LBRACKET, Literal: "["}, Rbracket: closer(h.Span)}
+	var err error
+	if e.Left, err = unmarshalExpression(fields.Left, "IndexExpression.left"); err != nil {
+		return nil, err
+	}
+	if e.Index, err = unmarshalExpression(fields.Index, "IndexExpression.index"); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func unmarshalMemberExpression(data []byte, h header) (*MemberExpression, error) {
+	var fields struct {
+		Object   json.RawMessage `json:"object"`
+		Property json.RawMessage `json:"property"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	e := &MemberExpression{Token: token.Token{Type: token.DOT, Literal: "."}}
+	var err error
+	if e.Object, err = unmarshalExpression(fields.Object, "MemberExpression.object"); err != nil {
+		return nil, err
+	}
+	if e.Property, err = unmarshalIdentifierField(fields.Property, "MemberExpression.property"); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func unmarshalArrayExpression(data []byte, h header) (*ArrayExpression, error) {
+	var fields struct {
+		Elements []json.RawMessage `json:"elements"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	e := &ArrayExpression{Token: token.Token{Type: token.LBRACKET, Literal: "[", Pos: h.Span.Start}, Rbracket: closer(h.Span)}
+	for i, raw := range fields.Elements {
+		element, err := unmarshalExpression(raw, fmt.Sprintf("ArrayExpression.elements[%d]", i))
+		if err != nil {
+			return nil, err
+		}
+		e.Elements = append(e.Elements, element)
+	}
+	return e, nil
+}
+
+func unmarshalPropertiesExpression(data []byte, h header) (*PropertiesExpression, error) {
+	var fields struct {
+		Properties []struct {
+			Key   json.RawMessage `json:"key"`
+			Value json.RawMessage `json:"value"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	e := &PropertiesExpression{
+		Token:      token.Token{Type: token.LBRACE, Literal: "{", Pos: h.Span.Start},
+		Properties: make(map[string]Expression),
+		Rbrace:     closer(h.Span),
+	}
+	for i, p := range fields.Properties {
+		field := fmt.Sprintf("PropertiesExpression.properties[%d]", i)
+		key, err := unmarshalIdentifierField(p.Key, field+".key")
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := e.Properties[key.Value]; ok {
+			return nil, fmt.Errorf("%s: duplicate property %q", field, key.Value)
+		}
+
+		value, err := unmarshalExpression(p.Value, field+".value")
+		if err != nil {
+			return nil, err
+		}
+		e.Keys = append(e.Keys, key)
+		e.Properties[key.Value] = value
+	}
+	return e, nil
+}
+
+// closer returns the position of the closing delimiter ending the span.
+func closer(span Span) token.Position {
+	if !span.End.IsValid() {
+		return token.Position{}
+	}
+	return token.Position{Line: span.End.Line, Column: span.End.Column - 1}
+}
+
+// unmarshalInto decodes data into the node pointed to by target,
+// failing if data encodes a node of another kind.
+func unmarshalInto(data []byte, target Node) error {
+	node, err := UnmarshalNode(data)
+	if err != nil {
+		return err
+	}
+
+	value := reflect.ValueOf(node)
+	targetValue := reflect.ValueOf(target)
+	if value.Type() != targetValue.Type() {
+		return fmt.Errorf("cannot decode %s into %s", value.Elem().Type().Name(), targetValue.Elem().Type().Name())
+	}
+	targetValue.Elem().Set(value.Elem())
+	return nil
+}
+
+func (p *File) UnmarshalJSON(data []byte) error                  { return unmarshalInto(data, p) }
+func (ls *AssignStatement) UnmarshalJSON(data []byte) error      { return unmarshalInto(data, ls) }
+func (ms *ModifyStatement) UnmarshalJSON(data []byte) error      { return unmarshalInto(data, ms) }
+func (is *IncludeStatement) UnmarshalJSON(data []byte) error     { return unmarshalInto(data, is) }
+func (es *ExpressionStatement) UnmarshalJSON(data []byte) error  { return unmarshalInto(data, es) }
+func (sl *StringLiteral) UnmarshalJSON(data []byte) error        { return unmarshalInto(data, sl) }
+func (i *Identifier) UnmarshalJSON(data []byte) error            { return unmarshalInto(data, i) }
+func (fl *FloatLiteral) UnmarshalJSON(data []byte) error         { return unmarshalInto(data, fl) }
+func (pe *PrefixExpression) UnmarshalJSON(data []byte) error     { return unmarshalInto(data, pe) }
+func (oe *InfixExpression) UnmarshalJSON(data []byte) error      { return unmarshalInto(data, oe) }
+func (ie *IndexExpression) UnmarshalJSON(data []byte) error      { return unmarshalInto(data, ie) }
+func (me *MemberExpression) UnmarshalJSON(data []byte) error     { return unmarshalInto(data, me) }
+func (ae *ArrayExpression) UnmarshalJSON(data []byte) error      { return unmarshalInto(data, ae) }
+func (pe *PropertiesExpression) UnmarshalJSON(data []byte) error { return unmarshalInto(data, pe) }
@@ -0,0 +1,174 @@
+package ast_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kacperkrolak/scene-description-language/ast"
+	"github.com/kacperkrolak/scene-description-language/lexer"
+	"github.com/kacperkrolak/scene-description-language/parser"
+)
+
+func parse(t *testing.T, input string) *ast.File {
+	t.Helper()
+	p := parser.New(lexer.New(input))
+	file := p.ParseFile()
+	if errors := p.Errors(); len(errors) > 0 {
+		t.Fatalf("syntax errors in %q: %v", input, errors)
+	}
+	return file
+}
+
+const scene = `INCLUDE "materials.sdl"
+NUMBER r = -(1 + 2) * 3
+MATERIAL red = {color: [1, 0, 0]}
+SPHERE ball EXTENDS base AT [0, r / 2, 5.0] = {
+    radius: r,
+    material: red,
+    // a comment
+    matrix: [[1, 0], [0, 1]],
+}
+MODIFY CAMERA {fov: ball.radius[0], name: "main"}
+(r + 1).x
+`
+
+func TestJSONRoundTrip(t *testing.T) {
+	file := parse(t, scene)
+
+	data, err := json.Marshal(file)
+	if err != nil {
+		t.Fatalf("error marshalling: %v", err)
+	}
+
+	var decoded ast.File
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("error unmarshalling: %v", err)
+	}
+	if decoded.String() != file.String() {
+		t.Errorf("wrong file.\ngot=%s\nwant=%s", decoded.String(), file.String())
+	}
+
+	again, err := json.Marshal(&decoded)
+	if err != nil {
+		t.Fatalf("error marshalling again: %v", err)
+	}
+	if string(again) != string(data) {
+		t.Errorf("JSON changed after a round trip.\ngot=%s\nwant=%s", again, data)
+	}
+
+	// Only the tokens which are not part of the spans are lost.
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.InfixExpression:
+			n.Token.Pos.Line, n.Token.Pos.Column = 0, 0
+		case *ast.IndexExpression:
+			n.Token.Pos.Line, n.Token.Pos.Column = 0, 0
+		case *ast.MemberExpression:
+			n.Token.Pos.Line, n.Token.Pos.Column = 0, 0
+		}
+		return true
+	})
+	if !reflect.DeepEqual(&decoded, file) {
+		t.Errorf("decoded file differs from the parsed one")
+	}
+}
+
+func TestJSONEncoding(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1.50", `{"kind":"ExpressionStatement","span":{"start":{"line":1,"column":1},"end":{"line":1,"column":5}},` +
+			`"expression":{"kind":"FloatLiteral","span":{"start":{"line":1,"column":1},"end":{"line":1,"column":5}},"literal":"1.50","value":1.5}}`},
+		{`INCLUDE "a.sdl"`, `{"kind":"IncludeStatement","span":{"start":{"line":1,"column":1},"end":{"line":1,"column":16}},` +
+			`"path":{"kind":"StringLiteral","span":{"start":{"line":1,"column":9},"end":{"line":1,"column":16}},"value":"a.sdl"}}`},
+		{"{b: x, a: []}", `{"kind":"ExpressionStatement","span":{"start":{"line":1,"column":1},"end":{"line":1,"column":14}},` +
+			`"expression":{"kind":"PropertiesExpression","span":{"start":{"line":1,"column":1},"end":{"line":1,"column":14}},"properties":[` +
+			`{"key":{"kind":"Identifier","span":{"start":{"line":1,"column":2},"end":{"line":1,"column":3}},"value":"b"},` +
+			`"value":{"kind":"Identifier","span":{"start":{"line":1,"column":5},"end":{"line":1,"column":6}},"value":"x"}},` +
+			`{"key":{"kind":"Identifier","span":{"start":{"line":1,"column":8},"end":{"line":1,"column":9}},"value":"a"},` +
+			`"value":{"kind":"ArrayExpression","span":{"start":{"line":1,"column":11},"end":{"line":1,"column":13}},"elements":[]}}]}}`},
+	}
+
+	for _, tt := range tests {
+		file := parse(t, tt.input)
+		data, err := json.Marshal(file.Statements[0])
+		if err != nil {
+			t.Fatalf("error marshalling %q: %v", tt.input, err)
+		}
+		if string(data) != tt.expected {
+			t.Errorf("wrong JSON of %q.\ngot=%s\nwant=%s", tt.input, data, tt.expected)
+		}
+	}
+}
+
+func TestSpans(t *testing.T) {
+	file := parse(t, scene)
+
+	tests := []struct {
+		statement int
+		expected  string
+	}{
+		{0, `INCLUDE "materials.sdl"`},
+		{1, "NUMBER r = -(1 + 2) * 3"},
+		{2, "MATERIAL red = {color: [1, 0, 0]}"},
+		{3, "SPHERE ball EXTENDS base AT [0, r / 2, 5.0] = {\n    radius: r,\n    material: red,\n    // a comment\n    matrix: [[1, 0], [0, 1]],\n}"},
+		{4, `MODIFY CAMERA {fov: ball.radius[0], name: "main"}`},
+		{5, "(r + 1).x"},
+	}
+
+	lines := strings.Split(scene, "\n")
+	text := func(span ast.Span) string {
+		if span.Start.Line == span.End.Line {
+			return lines[span.Start.Line-1][span.Start.Column-1 : span.End.Column-1]
+		}
+		parts := []string{lines[span.Start.Line-1][span.Start.Column-1:]}
+		parts = append(parts, lines[span.Start.Line:span.End.Line-1]...)
+		return strings.Join(append(parts, lines[span.End.Line-1][:span.End.Column-1]), "\n")
+	}
+
+	for _, tt := range tests {
+		statement := file.Statements[tt.statement]
+		if got := text(ast.Span{Start: statement.Pos(), End: statement.End()}); got != tt.expected {
+			t.Errorf("wrong span of statement %d.\ngot=%q\nwant=%q", tt.statement, got, tt.expected)
+		}
+	}
+}
+
+func TestJSONDecodingErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"kind":"Program"}`, `unknown node kind "Program"`},
+		{`{"statements":[]}`, "node without a kind"},
+		{`{"kind":"File","statements":[{"kind":"Identifier","value":"a"}]}`, "statements[0]: *ast.Identifier is not a statement"},
+		{`{"kind":"AssignStatement","class":"SPHERE","name":{"kind":"Identifier","value":"s"}}`, "missing AssignStatement.value"},
+		{`{"kind":"AssignStatement","class":"CUBE","name":{"kind":"Identifier","value":"s"}}`, `AssignStatement: unknown class "CUBE"`},
+		{`{"kind":"InfixExpression","operator":"%"}`, `InfixExpression: unknown operator "%"`},
+		{`{"kind":"FloatLiteral","literal":"x"}`, `FloatLiteral: invalid literal "x"`},
+		{`{"kind":"PropertiesExpression","properties":[` +
+			`{"key":{"kind":"Identifier","value":"a"},"value":{"kind":"FloatLiteral","value":1}},` +
+			`{"key":{"kind":"Identifier","value":"a"},"value":{"kind":"FloatLiteral","value":2}}]}`,
+			`PropertiesExpression.properties[1]: duplicate property "a"`},
+	}
+
+	for _, tt := range tests {
+		_, err := ast.UnmarshalNode([]byte(tt.input))
+		if err == nil {
+			t.Errorf("expected an error for %s", tt.input)
+			continue
+		}
+		if err.Error() != tt.expected {
+			t.Errorf("wrong error for %s.\ngot=%q\nwant=%q", tt.input, err.Error(), tt.expected)
+		}
+	}
+
+	var identifier ast.Identifier
+	err := json.Unmarshal([]byte(`{"kind":"FloatLiteral","value":1}`), &identifier)
+	if err == nil || err.Error() != "cannot decode FloatLiteral into Identifier" {
+		t.Errorf("wrong error decoding another kind. got=%v", err)
+	}
+}
@@ -0,0 +1,64 @@
+package ast
+
+// Inspect traverses the AST in depth-first order, starting with node.
+// It calls f for each node; if f returns true, Inspect continues
+// with the children of the node. Nil children are skipped.
+func Inspect(node Node, f func(Node) bool) {
+	if node == nil || !f(node) {
+		return
+	}
+
+	switch n := node.(type) {
+	case *File:
+		for _, s := range n.Statements {
+			Inspect(s, f)
+		}
+	case *AssignStatement:
+		inspectIdentifier(n.Name, f)
+		inspectIdentifier(n.Parent, f)
+		inspectExpression(n.Position, f)
+		inspectExpression(n.Value, f)
+	case *ModifyStatement:
+		inspectIdentifier(n.Name, f)
+		inspectExpression(n.Value, f)
+	case *IncludeStatement:
+		if n.Path != nil {
+			Inspect(n.Path, f)
+		}
+	case *ExpressionStatement:
+		inspectExpression(n.Expression, f)
+	case *PrefixExpression:
+		inspectExpression(n.Right, f)
+	case *InfixExpression:
+		inspectExpression(n.Left, f)
+		inspectExpression(n.Right, f)
+	case *IndexExpression:
+		inspectExpression(n.Left, f)
+		inspectExpression(n.Index, f)
+	case *MemberExpression:
+		inspectExpression(n.Object, f)
+		inspectIdentifier(n.Property, f)
+	case *ArrayExpression:
+		for _, e := range n.Elements {
+			inspectExpression(e, f)
+		}
+	case *PropertiesExpression:
+		// Keys are names of properties, not references, so only values are visited.
+		for _, key := range n.Keys {
+			inspectExpression(n.Properties[key.Value], f)
+		}
+	}
+}
+
+// inspectIdentifier avoids passing a typed nil *Identifier as a Node.
+func inspectIdentifier(ident *Identifier, f func(Node) bool) {
+	if ident != nil {
+		Inspect(ident, f)
+	}
+}
+
+func inspectExpression(exp Expression, f func(Node) bool) {
+	if exp != nil {
+		Inspect(exp, f)
+	}
+}
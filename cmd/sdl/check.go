@@ -0,0 +1,20 @@
+package main
+
+// runCheck parses, evaluates and validates every file,
+// reporting the problems of all of them.
+func runCheck(args []string, stdio stdio) int {
+	flags := newFlagSet("check", stdio, "[file ...]")
+	if status, ok := parseFlags(flags, args); !ok {
+		return status
+	}
+
+	status := 0
+	for _, path := range fileArgs(flags) {
+		if _, err := load(path, stdio.in); err != nil {
+			report(stdio.err, err)
+			status = 1
+		}
+	}
+
+	return status
+}
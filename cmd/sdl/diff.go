@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around every change.
+const diffContext = 3
+
+// unifiedDiff returns the differences between the lines of a and b in the unified format,
+// or an empty string if they are equal.
+func unifiedDiff(nameA, nameB string, a, b []byte) string {
+	if string(a) == string(b) {
+		return ""
+	}
+
+	linesA := splitLines(string(a))
+	linesB := splitLines(string(b))
+	edits := diffLines(linesA, linesB)
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", nameA, nameB)
+
+	for start := 0; start < len(edits); {
+		// Find the next change and the unchanged lines around it, merging
+		// changes separated by fewer than twice the context.
+		for start < len(edits) && edits[start].kind == ' ' {
+			start++
+		}
+		if start == len(edits) {
+			break
+		}
+
+		first := start - diffContext
+		if first < 0 {
+			first = 0
+		}
+
+		end := start
+		for unchanged := 0; end < len(edits) && unchanged <= 2*diffContext; end++ {
+			if edits[end].kind == ' ' {
+				unchanged++
+			} else {
+				unchanged = 0
+			}
+		}
+		for end > start && edits[end-1].kind == ' ' {
+			end--
+		}
+		last := end + diffContext
+		if last > len(edits) {
+			last = len(edits)
+		}
+
+		hunk := edits[first:last]
+		lineA, lineB := edits[first].lineA, edits[first].lineB
+		countA, countB := 0, 0
+		for _, e := range hunk {
+			if e.kind != '+' {
+				countA++
+			}
+			if e.kind != '-' {
+				countB++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(lineA, countA), hunkRange(lineB, countB))
+		for _, e := range hunk {
+			fmt.Fprintf(&out, "%c%s\n", e.kind, e.text)
+		}
+
+		start = last
+	}
+
+	return out.String()
+}
+
+// edit is a line of a diff: ' ' if it is in both files, '-' if only in the first
+// and '+' if only in the second one. The line numbers count from 0.
+type edit struct {
+	kind         byte
+	text         string
+	lineA, lineB int
+}
+
+// diffLines finds the edits turning a into b using their longest common subsequence.
+func diffLines(a, b []string) []edit {
+	// common[i][j] is the length of the longest common subsequence of a[i:] and b[j:].
+	common := make([][]int, len(a)+1)
+	for i := range common {
+		common[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else if common[i+1][j] >= common[i][j+1] {
+				common[i][j] = common[i+1][j]
+			} else {
+				common[i][j] = common[i][j+1]
+			}
+		}
+	}
+
+	var edits []edit
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			edits = append(edits, edit{' ', a[i], i, j})
+			i++
+			j++
+		case j == len(b) || i < len(a) && common[i+1][j] >= common[i][j+1]:
+			edits = append(edits, edit{'-', a[i], i, j})
+			i++
+		default:
+			edits = append(edits, edit{'+', b[j], i, j})
+			j++
+		}
+	}
+	return edits
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// hunkRange formats the lines of a hunk in one file as start,count, counting from 1.
+func hunkRange(line, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", line)
+	}
+	return fmt.Sprintf("%d,%d", line+1, count)
+}
@@ -0,0 +1,34 @@
+package main
+
+import "testing"
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected string
+	}{
+		{"a\nb\n", "a\nb\n", ""},
+		{
+			"a\nb\nc\n",
+			"a\nB\nc\n",
+			"--- old\n+++ new\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+		},
+		{
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			"0\n1\n2\n3\n4\n5\n6\n7\n8\n10\n",
+			"--- old\n+++ new\n@@ -1,3 +1,4 @@\n+0\n 1\n 2\n 3\n@@ -6,5 +7,4 @@\n 6\n 7\n 8\n-9\n 10\n",
+		},
+		{
+			"",
+			"a\n",
+			"--- old\n+++ new\n@@ -0,0 +1,1 @@\n+a\n",
+		},
+	}
+
+	for _, tt := range tests {
+		got := unifiedDiff("old", "new", []byte(tt.a), []byte(tt.b))
+		if got != tt.expected {
+			t.Errorf("wrong diff of %q and %q.\ngot=%q\nwant=%q", tt.a, tt.b, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"encoding/json"
+)
+
+// runEval prints the objects of a file as JSON, grouped by their class
+// and listed in the order they were defined.
+func runEval(args []string, stdio stdio) int {
+	flags := newFlagSet("eval", stdio, "[file]")
+	if status, ok := parseFlags(flags, args); !ok {
+		return status
+	}
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return 2
+	}
+
+	program, err := load(fileArgs(flags)[0], stdio.in)
+	if err != nil {
+		report(stdio.err, err)
+		return 1
+	}
+
+	encoder := json.NewEncoder(stdio.out)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(program.values.Entities); err != nil {
+		report(stdio.err, err)
+		return 1
+	}
+
+	return 0
+}
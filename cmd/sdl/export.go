@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kacperkrolak/scene-description-language/export"
+)
+
+// exporter writes an evaluated file in one format.
+type exporter struct {
+	name       string
+	extensions []string // extensions of the output files which select the format
+	write      func(w io.Writer, p *program, options exportOptions) error
+}
+
+// exportOptions holds the flags used by some of the formats.
+type exportOptions struct {
+	tessellation export.Tessellation
+	aspectRatio  float64
+	view         export.View
+	output       string // path of the output file, "-" for standard output
+}
+
+var exporters = []exporter{
+	{"json", []string{".json"}, func(w io.Writer, p *program, _ exportOptions) error { return export.JSON(w, p.values) }},
+	{"yaml", []string{".yaml", ".yml"}, func(w io.Writer, p *program, _ exportOptions) error { return export.YAML(w, p.values) }},
+	{"gltf", []string{".gltf"}, func(w io.Writer, p *program, options exportOptions) error {
+		return export.GLTF(w, p.values, export.GLTFOptions{Tessellation: options.tessellation, AspectRatio: options.aspectRatio})
+	}},
+	{"glb", []string{".glb"}, func(w io.Writer, p *program, options exportOptions) error {
+		return export.GLB(w, p.values, export.GLTFOptions{Tessellation: options.tessellation, AspectRatio: options.aspectRatio})
+	}},
+	{"pov", []string{".pov"}, func(w io.Writer, p *program, _ exportOptions) error { return export.POVRay(w, p.values) }},
+	{"obj", []string{".obj"}, writeOBJ},
+	{"mtl", []string{".mtl"}, func(w io.Writer, p *program, _ exportOptions) error { return export.MTL(w, p.values) }},
+	{"svg", []string{".svg"}, func(w io.Writer, p *program, options exportOptions) error {
+		return export.SVG(w, p.values, export.SVGOptions{View: options.view, AspectRatio: options.aspectRatio})
+	}},
+}
+
+// writeOBJ writes the geometry and, next to an output file, the MTL file with its materials.
+func writeOBJ(w io.Writer, p *program, options exportOptions) error {
+	objOptions := export.OBJOptions{Tessellation: options.tessellation}
+	if options.output != "-" {
+		library := strings.TrimSuffix(options.output, filepath.Ext(options.output)) + ".mtl"
+		f, err := os.Create(library)
+		if err != nil {
+			return err
+		}
+		if err := export.MTL(f, p.values); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		objOptions.MaterialLibrary = filepath.Base(library)
+	}
+	return export.OBJ(w, p.values, objOptions)
+}
+
+// runExport writes the evaluated objects of a file in another format,
+// chosen with -format or by the extension of the output file.
+func runExport(args []string, stdio stdio) int {
+	var names []string
+	for _, e := range exporters {
+		names = append(names, e.name)
+	}
+
+	flags := newFlagSet("export", stdio, "[file]")
+	output := flags.String("o", "-", `path of the output file, "-" for standard output`)
+	formatName := flags.String("format", "", "output format: "+strings.Join(names, ", ")+"; by default chosen by the extension of -o, or json")
+	printSchema := flags.Bool("schema", false, "print the JSON Schema of the json and yaml formats instead")
+	segments := flags.Int("segments", export.DefaultTessellation.Segments, "divisions of spheres around their axis, in formats made of triangles")
+	rings := flags.Int("rings", export.DefaultTessellation.Rings, "divisions of spheres from pole to pole, in formats made of triangles")
+	aspectRatio := flags.Float64("aspect", 0, "width divided by height of the camera image, 0 to leave it to the viewer")
+	viewName := flags.String("view", string(export.FrontView), "plane of the svg drawing: top, front or side")
+	if status, ok := parseFlags(flags, args); !ok {
+		return status
+	}
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return 2
+	}
+
+	if *printSchema {
+		stdio.out.Write(export.Schema())
+		return 0
+	}
+
+	e, ok := lookupExporter(*formatName, *output)
+	if !ok {
+		fmt.Fprintf(stdio.err, "sdl export: unknown format %q, expected one of: %s\n", *formatName, strings.Join(names, ", "))
+		return 2
+	}
+
+	view, err := export.ParseView(*viewName)
+	if err != nil {
+		fmt.Fprintf(stdio.err, "sdl export: %s\n", err)
+		return 2
+	}
+
+	program, err := load(fileArgs(flags)[0], stdio.in)
+	if err != nil {
+		report(stdio.err, err)
+		return 1
+	}
+
+	options := exportOptions{
+		tessellation: export.Tessellation{Segments: *segments, Rings: *rings},
+		aspectRatio:  *aspectRatio,
+		view:         view,
+		output:       *output,
+	}
+	if *output == "-" {
+		err = e.write(stdio.out, program, options)
+	} else {
+		err = writeExport(*output, e, program, options)
+	}
+	if err != nil {
+		report(stdio.err, err)
+		return 1
+	}
+
+	return 0
+}
+
+// lookupExporter finds the format with the given name or, if the name
+// is empty, the format of the output file, defaulting to the first one.
+func lookupExporter(name, output string) (exporter, bool) {
+	if name == "" {
+		ext := strings.ToLower(filepath.Ext(output))
+		for _, e := range exporters {
+			for _, extension := range e.extensions {
+				if extension == ext {
+					return e, true
+				}
+			}
+		}
+		return exporters[0], true
+	}
+
+	for _, e := range exporters {
+		if e.name == name {
+			return e, true
+		}
+	}
+	return exporter{}, false
+}
+
+func writeExport(path string, e exporter, p *program, options exportOptions) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := e.write(f, p, options); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
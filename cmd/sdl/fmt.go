@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/kacperkrolak/scene-description-language/evaluator"
+	"github.com/kacperkrolak/scene-description-language/format"
+)
+
+// runFmt prints every file in the canonical format, or with -w rewrites
+// the files which are not formatted, or with -d prints how they would change.
+func runFmt(args []string, stdio stdio) int {
+	flags := newFlagSet("fmt", stdio, "[file ...]")
+	write := flags.Bool("w", false, "write the result to the files instead of standard output")
+	diff := flags.Bool("d", false, "print the changes as a diff instead of the formatted files")
+	if status, ok := parseFlags(flags, args); !ok {
+		return status
+	}
+
+	status := 0
+	for _, path := range fileArgs(flags) {
+		if *write && path == "-" {
+			fmt.Fprintln(stdio.err, "sdl: cannot use -w with standard input")
+			return 2
+		}
+
+		src, formatted, err := formatFile(path, stdio)
+		if err != nil {
+			report(stdio.err, err)
+			status = 1
+			continue
+		}
+
+		switch {
+		case *diff:
+			name := path
+			if path == "-" {
+				name = stdinName
+			}
+			fmt.Fprint(stdio.out, unifiedDiff(name+".orig", name, src, formatted))
+		case *write:
+			if string(src) == string(formatted) {
+				continue
+			}
+			if err := writeFormatted(path, formatted); err != nil {
+				report(stdio.err, err)
+				status = 1
+			}
+		default:
+			stdio.out.Write(formatted)
+		}
+	}
+
+	return status
+}
+
+// formatFile returns the contents of the file before and after formatting,
+// reporting syntax errors as diagnostics.
+func formatFile(path string, stdio stdio) ([]byte, []byte, error) {
+	src, err := readSource(path, stdio.in)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	formatted, err := format.Source(src)
+	var syntaxError *format.SyntaxError
+	if errors.As(err, &syntaxError) {
+		diagnostics := make(evaluator.Diagnostics, len(syntaxError.Errors))
+		for i, e := range syntaxError.Errors {
+			diagnostics[i] = evaluator.Diagnostic{Pos: e.Pos, Message: e.Message}
+			if path != "-" {
+				diagnostics[i].File = path
+			}
+		}
+		return nil, nil, diagnostics
+	}
+
+	return src, formatted, err
+}
+
+// writeFormatted replaces the contents of the file, keeping its permissions.
+func writeFormatted(path string, formatted []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, formatted, info.Mode().Perm())
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"os"
+
+	"github.com/kacperkrolak/scene-description-language/export"
+)
+
+// runImport converts a scene in the JSON format of sdl export back to formatted SDL.
+func runImport(args []string, stdio stdio) int {
+	flags := newFlagSet("import", stdio, "[file.json]")
+	output := flags.String("o", "-", `path of the SDL file, "-" for standard output`)
+	if status, ok := parseFlags(flags, args); !ok {
+		return status
+	}
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return 2
+	}
+
+	data, err := readSource(fileArgs(flags)[0], stdio.in)
+	if err != nil {
+		report(stdio.err, err)
+		return 1
+	}
+
+	src, err := export.ImportSource(bytes.NewReader(data))
+	if err != nil {
+		report(stdio.err, err)
+		return 1
+	}
+
+	if *output == "-" {
+		_, err = stdio.out.Write(src)
+	} else {
+		err = os.WriteFile(*output, src, 0o644)
+	}
+	if err != nil {
+		report(stdio.err, err)
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/kacperkrolak/scene-description-language/evaluator"
+	"github.com/kacperkrolak/scene-description-language/scene"
+)
+
+// stdinName is used in diagnostics for the file read from standard input.
+const stdinName = "<stdin>"
+
+// program is an evaluated and validated file.
+type program struct {
+	evaluator *evaluator.Evaluator
+	values    evaluator.EvaluatedValues
+	scene     *scene.Scene
+}
+
+// load evaluates the file at path, or standard input for "-", and builds its scene.
+// Problems in the file are returned as evaluator.Diagnostics.
+func load(path string, stdin io.Reader) (*program, error) {
+	return loadWith(evaluator.NewEvaluator(), path, stdin)
+}
+
+// loadWith is load using the given evaluator, which is left
+// with the files it read even when the evaluation fails.
+func loadWith(e *evaluator.Evaluator, path string, stdin io.Reader) (*program, error) {
+	var err error
+	if path == "-" {
+		err = e.EvaluateFile(stdin)
+	} else {
+		err = e.EvaluatePath(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	values := e.ExportValues()
+	s, err := scene.New(values)
+	var problems scene.ValidationError
+	if errors.As(err, &problems) {
+		diagnostics := make(evaluator.Diagnostics, len(problems))
+		for i, problem := range problems {
+			location, _ := e.Definition(problem.Entity)
+			diagnostics[i] = evaluator.Diagnostic{File: location.File, Pos: location.Pos, Message: problem.String()}
+		}
+		return nil, diagnostics
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &program{evaluator: e, values: values, scene: s}, nil
+}
+
+// report writes the error to w, one diagnostic per line.
+// Diagnostics without a file come from standard input.
+func report(w io.Writer, err error) {
+	var diagnostics evaluator.Diagnostics
+	if !errors.As(err, &diagnostics) {
+		fmt.Fprintf(w, "sdl: %s\n", err)
+		return
+	}
+
+	for _, diagnostic := range diagnostics {
+		if diagnostic.File == "" {
+			diagnostic.File = stdinName
+		}
+		fmt.Fprintln(w, diagnostic)
+	}
+}
+
+// readSource reads the file at path, or standard input for "-".
+func readSource(path string, stdin io.Reader) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(stdin)
+	}
+	return os.ReadFile(path)
+}
+
+// fileArgs returns the files named by the arguments, standard input if there are none.
+func fileArgs(flags *flag.FlagSet) []string {
+	if flags.NArg() == 0 {
+		return []string{"-"}
+	}
+	return flags.Args()
+}
+
+// newFlagSet creates the flags of a command which reports its errors to stdio.err.
+func newFlagSet(name string, stdio stdio, args string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(stdio.err)
+	flags.Usage = func() {
+		fmt.Fprintf(stdio.err, "usage: sdl %s [flags] %s\n", name, args)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// parseFlags parses the arguments and returns the exit status to use if they are invalid.
+func parseFlags(flags *flag.FlagSet, args []string) (int, bool) {
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0, false
+		}
+		return 2, false
+	}
+	return 0, true
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"github.com/kacperkrolak/scene-description-language/lsp"
+)
+
+// runLSP serves the Language Server Protocol over standard input and output.
+func runLSP(args []string, stdio stdio) int {
+	flags := newFlagSet("lsp", stdio, "")
+	if status, ok := parseFlags(flags, args); !ok {
+		return status
+	}
+	if flags.NArg() > 0 {
+		flags.Usage()
+		return 2
+	}
+
+	if err := lsp.NewServer(stdio.in, stdio.out).Run(); err != nil {
+		report(stdio.err, err)
+		return 1
+	}
+	return 0
+}
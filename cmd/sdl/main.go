@@ -0,0 +1,83 @@
+// Command sdl checks, evaluates, formats and renders SDL files,
+// and explores them interactively.
+//
+// Usage:
+//
+//	sdl <command> [flags] [file ...]
+//
+// Files are read from standard input when none is given or the name is "-".
+// Problems are reported on standard error as file:line:column: message,
+// and the exit status is 1 if any were found, or 2 if the command was misused.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+type command struct {
+	name    string
+	summary string
+	run     func(args []string, stdio stdio) int
+}
+
+// stdio holds the streams used by the commands, so they can be replaced in tests.
+type stdio struct {
+	in  io.Reader
+	out io.Writer
+	err io.Writer
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"check", "parse, evaluate and validate files", runCheck},
+		{"parse", "print the syntax tree of a file, as JSON with -json", runParse},
+		{"eval", "print the evaluated objects of a file as JSON", runEval},
+		{"export", "write the evaluated objects of a file as JSON or YAML, or the scene as glTF, POV-Ray, OBJ or an SVG plan", runExport},
+		{"import", "convert a scene exported as JSON back to SDL", runImport},
+		{"fmt", "print files in the canonical format", runFmt},
+		{"render", "render a file to a PNG or HDR image", runRender},
+		{"repl", "evaluate statements interactively", runREPL},
+		{"lsp", "serve the Language Server Protocol over standard input and output", runLSP},
+	}
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], stdio{in: os.Stdin, out: os.Stdout, err: os.Stderr}))
+}
+
+func run(args []string, stdio stdio) int {
+	if len(args) == 0 {
+		usage(stdio.err)
+		return 2
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd.run(args[1:], stdio)
+		}
+	}
+
+	if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		usage(stdio.out)
+		return 0
+	}
+
+	fmt.Fprintf(stdio.err, "sdl: unknown command %q\n", args[0])
+	usage(stdio.err)
+	return 2
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: sdl <command> [flags] [file ...]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-8s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `Files are read from standard input when none is given or the name is "-".`)
+}
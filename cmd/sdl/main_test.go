@@ -0,0 +1,426 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func runWith(args []string, input string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	status := run(args, stdio{in: strings.NewReader(input), out: &stdout, err: &stderr})
+	return status, stdout.String(), stderr.String()
+}
+
+func writeFile(t *testing.T, name, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCheck(t *testing.T) {
+	valid := writeFile(t, "valid.sdl", "SPHERE s = {radius: 1}")
+	invalid := writeFile(t, "invalid.sdl", "NUMBER a = 1\nSPHERE s = {radius: a, size: 2}")
+
+	tests := []struct {
+		args   []string
+		input  string
+		status int
+		stderr string
+	}{
+		{[]string{"check", valid}, "", 0, ""},
+		{[]string{"check", "-"}, "NUMBER a = b", 1, "<stdin>:1:12: undefined identifier: b\n"},
+		{[]string{"check"}, "NUMBER = 1", 1, "<stdin>:1:8: expected next token to be IDENT, got = instead\n<stdin>:1:8: no prefix parse function for = found\n"},
+		{[]string{"check", valid, invalid}, "", 1, invalid + ":2:1: SPHERE s: size: unknown property of SPHERE\n"},
+		{[]string{"check", "missing.sdl"}, "", 1, "sdl: open missing.sdl: no such file or directory\n"},
+		{[]string{"check", "-unknown"}, "", 2, ""},
+	}
+
+	for _, tt := range tests {
+		status, _, stderr := runWith(tt.args, tt.input)
+		if status != tt.status {
+			t.Errorf("wrong status for %v. got=%d, want=%d (%s)", tt.args, status, tt.status, stderr)
+		}
+		if tt.stderr != "" && stderr != tt.stderr {
+			t.Errorf("wrong output for %v.\ngot=%q\nwant=%q", tt.args, stderr, tt.stderr)
+		}
+	}
+}
+
+func TestEval(t *testing.T) {
+	status, stdout, stderr := runWith([]string{"eval"}, "COLOR red = [1, 0, 0] SPHERE s = {radius: 2}")
+	if status != 0 {
+		t.Fatalf("eval failed: %s", stderr)
+	}
+
+	var entities map[string][]struct {
+		Name  string          `json:"name"`
+		Class string          `json:"class"`
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &entities); err != nil {
+		t.Fatalf("output is not JSON: %s\n%s", err, stdout)
+	}
+
+	if len(entities["COLOR"]) != 1 || entities["COLOR"][0].Name != "red" {
+		t.Errorf("wrong colors. got=%+v", entities["COLOR"])
+	}
+	if len(entities["SPHERE"]) != 1 || string(entities["SPHERE"][0].Value) != "{\n        \"radius\": 2\n      }" {
+		t.Errorf("wrong spheres. got=%+v", entities["SPHERE"])
+	}
+}
+
+func TestFmt(t *testing.T) {
+	status, stdout, _ := runWith([]string{"fmt"}, "NUMBER a=1+2")
+	if status != 0 || stdout != "NUMBER a = 1 + 2\n" {
+		t.Errorf("wrong output. status=%d, got=%q", status, stdout)
+	}
+
+	path := writeFile(t, "broken.sdl", "NUMBER a = ")
+	status, _, stderr := runWith([]string{"fmt", path}, "")
+	if status != 1 || !strings.HasPrefix(stderr, path+":1:") {
+		t.Errorf("expected a diagnostic for the file. status=%d, got=%q", status, stderr)
+	}
+}
+
+func TestFmtWrite(t *testing.T) {
+	path := writeFile(t, "scene.sdl", "NUMBER a=1.0 // one\n")
+	formatted := writeFile(t, "formatted.sdl", "NUMBER a = 1\n")
+
+	status, stdout, stderr := runWith([]string{"fmt", "-w", path, formatted}, "")
+	if status != 0 || stdout != "" {
+		t.Fatalf("fmt -w failed. status=%d, stdout=%q, stderr=%q", status, stdout, stderr)
+	}
+
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(contents) != "NUMBER a = 1 // one\n" {
+		t.Errorf("file was not formatted. got=%q", contents)
+	}
+
+	if status, _, _ := runWith([]string{"fmt", "-w"}, "NUMBER a = 1"); status != 2 {
+		t.Errorf("fmt -w of standard input should be a usage error. got=%d", status)
+	}
+}
+
+func TestFmtDiff(t *testing.T) {
+	status, stdout, _ := runWith([]string{"fmt", "-d"}, "NUMBER a = 1\nNUMBER b=2\n")
+	expected := "--- <stdin>.orig\n+++ <stdin>\n@@ -1,2 +1,2 @@\n NUMBER a = 1\n-NUMBER b=2\n+NUMBER b = 2\n"
+	if status != 0 || stdout != expected {
+		t.Errorf("wrong diff. status=%d\ngot=%q\nwant=%q", status, stdout, expected)
+	}
+
+	if _, stdout, _ := runWith([]string{"fmt", "-d"}, "NUMBER a = 1\n"); stdout != "" {
+		t.Errorf("formatted source should have no diff. got=%q", stdout)
+	}
+}
+
+func TestRender(t *testing.T) {
+	input := "SPHERE s = {radius: 1, position: [0, 0, 5]} LIGHT l = {position: [0, 0, -5]}"
+	status, stdout, stderr := runWith([]string{"render", "-o", "-", "-width", "8", "-height", "6"}, input)
+	if status != 0 {
+		t.Fatalf("render failed: %s", stderr)
+	}
+
+	img, err := png.Decode(strings.NewReader(stdout))
+	if err != nil {
+		t.Fatalf("output is not a PNG: %s", err)
+	}
+	if size := img.Bounds().Size(); size.X != 8 || size.Y != 6 {
+		t.Errorf("wrong size. got=%v", size)
+	}
+
+	output := filepath.Join(t.TempDir(), "out.png")
+	if status, _, stderr := runWith([]string{"render", "-o", output, "-width", "4", "-height", "4"}, input); status != 0 {
+		t.Fatalf("render failed: %s", stderr)
+	}
+	if _, err := os.Stat(output); err != nil {
+		t.Errorf("image was not written: %s", err)
+	}
+
+	status, _, stderr = runWith([]string{"render", "-o", "-", "-shading", "flat"}, input)
+	if status != 2 || stderr != "sdl render: unknown shading model \"flat\", expected phong or blinn-phong\n" {
+		t.Errorf("wrong error for an unknown shading model. got=%d %q", status, stderr)
+	}
+	status, _, stderr = runWith([]string{"render", "-o", "-", "-integrator", "radiosity"}, input)
+	if status != 2 || stderr != "sdl render: unknown integrator \"radiosity\", expected whitted or path\n" {
+		t.Errorf("wrong error for an unknown integrator. got=%d %q", status, stderr)
+	}
+	status, _, stderr = runWith([]string{"render", "-o", "-", "-filter", "lanczos"}, input)
+	if status != 2 || stderr != "sdl render: unknown filter \"lanczos\", expected box, tent, gaussian or mitchell\n" {
+		t.Errorf("wrong error for an unknown filter. got=%d %q", status, stderr)
+	}
+}
+
+func TestRenderFormats(t *testing.T) {
+	input := "SPHERE s = {radius: 1, position: [0, 0, 5], material: {emission: [4, 2, 1]}}"
+	dir := t.TempDir()
+	for _, tt := range []struct{ args, prefix string }{
+		{"-o " + filepath.Join(dir, "out.hdr"), "#?RADIANCE\n"},
+		{"-o " + filepath.Join(dir, "out.pfm"), "PF\n4 3\n"},
+		{"-o - -format pfm", "PF\n4 3\n"},
+	} {
+		args := append([]string{"render", "-width", "4", "-height", "3"}, strings.Fields(tt.args)...)
+		status, stdout, stderr := runWith(args, input)
+		if status != 0 {
+			t.Fatalf("render %s failed: %s", tt.args, stderr)
+		}
+		data := []byte(stdout)
+		if output := args[len(args)-1]; strings.HasPrefix(output, dir) {
+			var err error
+			if data, err = os.ReadFile(output); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if !strings.HasPrefix(string(data), tt.prefix) {
+			t.Errorf("render %s wrote the wrong format. got=%q", tt.args, data)
+		}
+	}
+
+	status, _, stderr := runWith([]string{"render", "-o", "-", "-format", "exr"}, input)
+	if status != 2 || stderr != "sdl render: unknown format \"exr\", expected one of: png, hdr, pfm\n" {
+		t.Errorf("wrong error for an unknown format. got=%d %q", status, stderr)
+	}
+	status, _, stderr = runWith([]string{"render", "-o", "-", "-tonemap", "filmic"}, input)
+	if status != 2 || stderr != "sdl render: unknown tone mapping \"filmic\", expected none, reinhard or aces\n" {
+		t.Errorf("wrong error for an unknown tone mapping. got=%d %q", status, stderr)
+	}
+
+	// The bright center is clipped to white, unless it is tone mapped.
+	center := func(args ...string) uint32 {
+		t.Helper()
+		status, stdout, stderr := runWith(append([]string{"render", "-o", "-", "-width", "4", "-height", "3"}, args...), input)
+		if status != 0 {
+			t.Fatalf("render failed: %s", stderr)
+		}
+		img, err := png.Decode(strings.NewReader(stdout))
+		if err != nil {
+			t.Fatalf("output is not a PNG: %s", err)
+		}
+		_, g, _, _ := img.At(2, 1).RGBA()
+		return g >> 8
+	}
+	if clipped, mapped := center(), center("-tonemap", "aces", "-exposure", "-2"); clipped != 255 || mapped >= 255 || mapped == 0 {
+		t.Errorf("wrong tone mapping. got=%d clipped and %d mapped", clipped, mapped)
+	}
+}
+
+func TestRenderSettings(t *testing.T) {
+	input := "RENDER settings = {filterRadius: -1} SPHERE s = {radius: 1, position: [0, 0, 5]}"
+	status, _, stderr := runWith([]string{"render", "-o", "-", "-width", "4", "-height", "4"}, input)
+	if status != 1 || stderr != "sdl: filter radius must not be negative, got -1\n" {
+		t.Errorf("settings of the scene should be used. got=%d %q", status, stderr)
+	}
+
+	// Flags take precedence over the settings.
+	status, _, stderr = runWith([]string{"render", "-o", "-", "-width", "4", "-height", "4", "-filter-radius", "1"}, input)
+	if status != 0 {
+		t.Errorf("flags should override the settings of the scene. got=%d %q", status, stderr)
+	}
+
+	// Settings which cannot be used are reported by the check of the scene.
+	status, _, stderr = runWith([]string{"render", "-o", "-", "-samples", "2"}, "RENDER settings = {samples: 2.5}")
+	if status != 1 || stderr != "<stdin>:1:1: RENDER settings: samples: expected a whole NUMBER, got 2.5\n" {
+		t.Errorf("wrong error for fractional samples. got=%d %q", status, stderr)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	if status, _, _ := runWith(nil, ""); status != 2 {
+		t.Errorf("missing command should be a usage error. got=%d", status)
+	}
+	if status, _, stderr := runWith([]string{"paint"}, ""); status != 2 || !strings.Contains(stderr, `unknown command "paint"`) {
+		t.Errorf("unknown command should be a usage error. got=%d, %q", status, stderr)
+	}
+	if status, _, _ := runWith([]string{"eval", "a.sdl", "b.sdl"}, ""); status != 2 {
+		t.Errorf("eval of two files should be a usage error. got=%d", status)
+	}
+}
+
+func TestLSP(t *testing.T) {
+	var input strings.Builder
+	for _, message := range []string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"shutdown"}`,
+		`{"jsonrpc":"2.0","method":"exit"}`,
+	} {
+		fmt.Fprintf(&input, "Content-Length: %d\r\n\r\n%s", len(message), message)
+	}
+
+	status, stdout, stderr := runWith([]string{"lsp"}, input.String())
+	if status != 0 {
+		t.Fatalf("lsp failed: %s", stderr)
+	}
+	if !strings.Contains(stdout, `"hoverProvider":true`) || !strings.Contains(stdout, `"id":2,"result":null`) {
+		t.Errorf("wrong responses. got=%q", stdout)
+	}
+
+	if status, _, _ := runWith([]string{"lsp"}, "Content-Length: 33\r\n\r\n"+`{"jsonrpc":"2.0","method":"exit"}`); status != 1 {
+		t.Errorf("exit without shutdown should fail. got=%d", status)
+	}
+}
+
+func TestREPL(t *testing.T) {
+	path := writeFile(t, "scene.sdl", "NUMBER r = 2")
+
+	status, stdout, stderr := runWith([]string{"repl", path}, "r * 2\n")
+	if status != 0 {
+		t.Fatalf("repl failed: %s", stderr)
+	}
+	expected := "loaded 1 objects from " + path + "\n>> 4\n>> \n"
+	if stdout != expected {
+		t.Errorf("wrong output.\ngot=%q\nwant=%q", stdout, expected)
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		args   []string
+		input  string
+		status int
+		stdout string
+		stderr string
+	}{
+		{[]string{"parse"}, "NUMBER a = 1 + 2 * 3", 0, "NUMBER a = (1 + (2 * 3))\n", ""},
+		{[]string{"parse", "-json"}, "a", 0, `{
+  "kind": "File",
+  "span": {
+    "start": {
+      "line": 1,
+      "column": 1
+    },
+    "end": {
+      "line": 1,
+      "column": 2
+    }
+  },
+  "statements": [
+    {
+      "kind": "ExpressionStatement",
+      "span": {
+        "start": {
+          "line": 1,
+          "column": 1
+        },
+        "end": {
+          "line": 1,
+          "column": 2
+        }
+      },
+      "expression": {
+        "kind": "Identifier",
+        "span": {
+          "start": {
+            "line": 1,
+            "column": 1
+          },
+          "end": {
+            "line": 1,
+            "column": 2
+          }
+        },
+        "value": "a"
+      }
+    }
+  ]
+}
+`, ""},
+		{[]string{"parse", "-json"}, "NUMBER = 1", 1, "", "<stdin>:1:8: expected next token to be IDENT, got = instead\n<stdin>:1:8: no prefix parse function for = found\n"},
+		{[]string{"parse", "a.sdl", "b.sdl"}, "", 2, "", "usage: sdl parse [flags] [file]\n  -json\n    \tprint the syntax tree as JSON\n"},
+	}
+
+	for _, tt := range tests {
+		status, stdout, stderr := runWith(tt.args, tt.input)
+		if status != tt.status || stdout != tt.stdout || stderr != tt.stderr {
+			t.Errorf("wrong result of %v.\ngot=%d %q %q\nwant=%d %q %q", tt.args, status, stdout, stderr, tt.status, tt.stdout, tt.stderr)
+		}
+	}
+}
+
+func TestExport(t *testing.T) {
+	path := writeFile(t, "scene.sdl", "NUMBER r = 2")
+	yamlPath := filepath.Join(filepath.Dir(path), "scene.yml")
+
+	tests := []struct {
+		args   []string
+		status int
+		stdout string
+		stderr string
+	}{
+		{[]string{"export", path}, 0, "{\n  \"objects\": [\n    {\n      \"name\": \"r\",\n      \"class\": \"NUMBER\",\n      \"value\": 2\n    }\n  ]\n}\n", ""},
+		{[]string{"export", "-format", "yaml", path}, 0, "objects:\n  - name: \"r\"\n    class: \"NUMBER\"\n    value: 2\n", ""},
+		{[]string{"export", "-o", yamlPath, path}, 0, "", ""},
+		{[]string{"export", "-format", "xml", path}, 2, "", "sdl export: unknown format \"xml\", expected one of: json, yaml, gltf, glb, pov, obj, mtl, svg\n"},
+		{[]string{"export", "-view", "back", path}, 2, "", "sdl export: unknown view \"back\", expected top, front or side\n"},
+		{[]string{"export", "-format", "gltf", "-segments", "2", path}, 1, "", "sdl: a sphere needs at least 3 segments and 2 rings, got 2 and 16\n"},
+	}
+
+	for _, tt := range tests {
+		status, stdout, stderr := runWith(tt.args, "")
+		if status != tt.status || stdout != tt.stdout || stderr != tt.stderr {
+			t.Errorf("wrong result of %v.\ngot=%d %q %q\nwant=%d %q %q", tt.args, status, stdout, stderr, tt.status, tt.stdout, tt.stderr)
+		}
+	}
+
+	written, err := os.ReadFile(yamlPath)
+	if err != nil || string(written) != "objects:\n  - name: \"r\"\n    class: \"NUMBER\"\n    value: 2\n" {
+		t.Errorf("wrong file written by -o. got=%q, %v", written, err)
+	}
+
+	if status, stdout, _ := runWith([]string{"export", "-schema"}, ""); status != 0 || !strings.Contains(stdout, `"$schema"`) {
+		t.Errorf("wrong schema. got=%d %q", status, stdout)
+	}
+
+	glbPath := filepath.Join(filepath.Dir(path), "scene.glb")
+	if status, _, stderr := runWith([]string{"export", "-o", glbPath, path}, ""); status != 0 {
+		t.Fatalf("error exporting glb: %d %q", status, stderr)
+	}
+	if written, err := os.ReadFile(glbPath); err != nil || !strings.HasPrefix(string(written), "glTF") {
+		t.Errorf("wrong glb file written. got=%.8q, %v", written, err)
+	}
+
+	if status, stdout, stderr := runWith([]string{"export", "-format", "pov", path}, ""); status != 0 || !strings.Contains(stdout, "camera {") {
+		t.Errorf("wrong pov export. got=%d %q %q", status, stdout, stderr)
+	}
+
+	if status, stdout, stderr := runWith([]string{"export", "-format", "svg", "-view", "top", path}, ""); status != 0 || !strings.Contains(stdout, "<title>top view</title>") {
+		t.Errorf("wrong svg export. got=%d %q %q", status, stdout, stderr)
+	}
+
+	objPath := filepath.Join(filepath.Dir(path), "scene.obj")
+	if status, _, stderr := runWith([]string{"export", "-o", objPath, "-segments", "4", "-rings", "2", path}, ""); status != 0 {
+		t.Fatalf("error exporting obj: %d %q", status, stderr)
+	}
+	if written, err := os.ReadFile(objPath); err != nil || !strings.Contains(string(written), "mtllib scene.mtl\n") {
+		t.Errorf("wrong obj file written. got=%q, %v", written, err)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(path), "scene.mtl")); err != nil {
+		t.Errorf("the mtl file was not written: %v", err)
+	}
+}
+
+func TestImport(t *testing.T) {
+	path := writeFile(t, "scene.sdl", "MATERIAL m = {color: [1, 0, 0]}\nSPHERE s = {radius: 1, material: m}")
+
+	_, exported, stderr := runWith([]string{"export", path}, "")
+	status, stdout, stderr := runWith([]string{"import"}, exported)
+	expected := "MATERIAL m = {\n    color: [1, 0, 0],\n}\nSPHERE s = {\n    radius: 1,\n    material: m,\n}\n"
+	if status != 0 || stdout != expected {
+		t.Errorf("wrong result.\ngot=%d %q %q\nwant=0 %q", status, stdout, stderr, expected)
+	}
+
+	status, _, stderr = runWith([]string{"import"}, `{"objects": 1}`)
+	if status != 1 || stderr != "sdl: expected \"objects\" to be an array\n" {
+		t.Errorf("wrong error. got=%d %q", status, stderr)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/kacperkrolak/scene-description-language/evaluator"
+	"github.com/kacperkrolak/scene-description-language/lexer"
+	"github.com/kacperkrolak/scene-description-language/parser"
+)
+
+// runParse prints the syntax tree of a file, fully parenthesized
+// or with -json as the nodes with their kinds and spans.
+func runParse(args []string, stdio stdio) int {
+	flags := newFlagSet("parse", stdio, "[file]")
+	asJSON := flags.Bool("json", false, "print the syntax tree as JSON")
+	if status, ok := parseFlags(flags, args); !ok {
+		return status
+	}
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return 2
+	}
+
+	path := fileArgs(flags)[0]
+	src, err := readSource(path, stdio.in)
+	if err != nil {
+		report(stdio.err, err)
+		return 1
+	}
+
+	p := parser.New(lexer.New(string(src)))
+	file := p.ParseFile()
+	if errs := p.SyntaxErrors(); len(errs) > 0 {
+		diagnostics := make(evaluator.Diagnostics, len(errs))
+		for i, e := range errs {
+			diagnostics[i] = evaluator.Diagnostic{Pos: e.Pos, Message: e.Message}
+			if path != "-" {
+				diagnostics[i].File = path
+			}
+		}
+		report(stdio.err, diagnostics)
+		return 1
+	}
+
+	if !*asJSON {
+		fmt.Fprint(stdio.out, file.String())
+		return 0
+	}
+
+	encoder := json.NewEncoder(stdio.out)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(file); err != nil {
+		report(stdio.err, err)
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kacperkrolak/scene-description-language/render"
+	"github.com/kacperkrolak/scene-description-language/scene"
+	"github.com/kacperkrolak/scene-description-language/shading"
+)
+
+// imageFormat writes rendered images in one format.
+type imageFormat struct {
+	name       string
+	extensions []string // extensions of the output files which select the format
+	write      func(w io.Writer, img *render.Image, display render.Display) error
+}
+
+var imageFormats = []imageFormat{
+	{"png", []string{".png"}, func(w io.Writer, img *render.Image, display render.Display) error { return img.WritePNG(w, display) }},
+	{"hdr", []string{".hdr", ".pic"}, func(w io.Writer, img *render.Image, _ render.Display) error { return img.WriteHDR(w) }},
+	{"pfm", []string{".pfm"}, func(w io.Writer, img *render.Image, _ render.Display) error { return img.WritePFM(w) }},
+}
+
+// lookupImageFormat finds the format with the given name or, if the name
+// is empty, the format of the output file, defaulting to PNG.
+func lookupImageFormat(name, output string) (imageFormat, bool) {
+	if name == "" {
+		ext := strings.ToLower(filepath.Ext(output))
+		for _, f := range imageFormats {
+			for _, extension := range f.extensions {
+				if extension == ext {
+					return f, true
+				}
+			}
+		}
+		return imageFormats[0], true
+	}
+
+	for _, f := range imageFormats {
+		if f.name == name {
+			return f, true
+		}
+	}
+	return imageFormat{}, false
+}
+
+// renderOptions are the options of sdl render. Flags given on the command
+// line take precedence over the RENDER settings of the scene.
+type renderOptions struct {
+	render.Options
+	display render.Display
+	format  imageFormat
+	given   map[string]bool // names of the flags given
+}
+
+// forScene returns the options used to render the scene and to write the image.
+func (o renderOptions) forScene(s *scene.Scene) (render.Options, render.Display) {
+	display := o.display.WithSettings(s.Settings)
+	if o.given["exposure"] {
+		display.Exposure = o.display.Exposure
+	}
+	if o.given["tonemap"] {
+		display.ToneMap = o.display.ToneMap
+	}
+
+	options := o.Options.WithSettings(s.Settings)
+	if o.given["shading"] {
+		options.Shading = o.Shading
+	}
+	if o.given["depth"] {
+		options.MaxDepth = o.MaxDepth
+	}
+	if o.given["integrator"] {
+		options.Integrator = o.Integrator
+	}
+	if o.given["samples"] {
+		options.Samples = o.Samples
+	}
+	if o.given["sampler"] {
+		options.Sampler = o.Sampler
+	}
+	if o.given["filter"] {
+		options.Filter = o.Filter
+	}
+	if o.given["filter-radius"] {
+		options.FilterRadius = o.FilterRadius
+	}
+	return options, display
+}
+
+// runRender renders a file to a PNG image, or an HDR image chosen with
+// -format or by the extension of the output file. With -watch it keeps
+// rendering the file again whenever it or its includes change, tracing
+// only the rows which the changes can affect.
+// Options which are not given come from the RENDER settings of the file.
+func runRender(args []string, stdio stdio) int {
+	flags := newFlagSet("render", stdio, "[file]")
+	var formats []string
+	for _, f := range imageFormats {
+		formats = append(formats, f.name)
+	}
+
+	output := flags.String("o", "out.png", `path of the image, "-" for standard output`)
+	formatName := flags.String("format", "", "image format: "+strings.Join(formats, ", ")+"; by default chosen by the extension of -o, or png")
+	width := flags.Int("width", render.DefaultOptions.Width, "width of the image in pixels")
+	height := flags.Int("height", render.DefaultOptions.Height, "height of the image in pixels")
+	shadingName := flags.String("shading", string(render.DefaultOptions.Shading), "shading model: phong or blinn-phong")
+	maxDepth := flags.Int("depth", render.DefaultOptions.MaxDepth, "number of reflections and refractions, or bounces with -integrator path, followed from each camera ray")
+	integratorName := flags.String("integrator", string(render.DefaultOptions.Integrator), "how light is traced: whitted or path")
+	samples := flags.Int("samples", render.DefaultOptions.Samples, "samples per pixel, 0 for 1 with whitted and 16 with path")
+	samplerName := flags.String("sampler", string(render.DefaultOptions.Sampler), "how samples are spread over a pixel: stratified or jittered")
+	filterName := flags.String("filter", string(render.DefaultOptions.Filter), "how samples are weighted: box, tent, gaussian or mitchell")
+	filterRadius := flags.Float64("filter-radius", render.DefaultOptions.FilterRadius, "radius of the filter in pixels, 0 for its default")
+	exposure := flags.Float64("exposure", 0, "stops by which png images are brightened, negative to darken them")
+	toneMapName := flags.String("tonemap", string(render.Clamp), "how colors brighter than white are shown in png images: none, reinhard or aces")
+	watch := flags.Bool("watch", false, "render again whenever the file or its includes change, until interrupted, tracing only the rows affected by changed spheres")
+	interval := flags.Duration("interval", 500*time.Millisecond, "how often to check the files for changes with -watch")
+	if status, ok := parseFlags(flags, args); !ok {
+		return status
+	}
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return 2
+	}
+
+	model, err := shading.ParseModel(*shadingName)
+	if err != nil {
+		fmt.Fprintf(stdio.err, "sdl render: %s\n", err)
+		return 2
+	}
+	integrator, err := render.ParseIntegrator(*integratorName)
+	if err != nil {
+		fmt.Fprintf(stdio.err, "sdl render: %s\n", err)
+		return 2
+	}
+	sampler, err := render.ParseSampler(*samplerName)
+	if err != nil {
+		fmt.Fprintf(stdio.err, "sdl render: %s\n", err)
+		return 2
+	}
+	filter, err := render.ParseFilter(*filterName)
+	if err != nil {
+		fmt.Fprintf(stdio.err, "sdl render: %s\n", err)
+		return 2
+	}
+	toneMap, err := render.ParseToneMap(*toneMapName)
+	if err != nil {
+		fmt.Fprintf(stdio.err, "sdl render: %s\n", err)
+		return 2
+	}
+	format, ok := lookupImageFormat(*formatName, *output)
+	if !ok {
+		fmt.Fprintf(stdio.err, "sdl render: unknown format %q, expected one of: %s\n", *formatName, strings.Join(formats, ", "))
+		return 2
+	}
+
+	options := renderOptions{
+		Options: render.Options{
+			Width:        *width,
+			Height:       *height,
+			Shading:      model,
+			MaxDepth:     *maxDepth,
+			Integrator:   integrator,
+			Samples:      *samples,
+			Sampler:      sampler,
+			Filter:       filter,
+			FilterRadius: *filterRadius,
+		},
+		display: render.Display{Exposure: *exposure, ToneMap: toneMap},
+		format:  format,
+		given:   make(map[string]bool),
+	}
+	flags.Visit(func(f *flag.Flag) { options.given[f.Name] = true })
+	if *watch {
+		if flags.NArg() == 0 || flags.Arg(0) == "-" || *output == "-" {
+			fmt.Fprintln(stdio.err, "sdl render: -watch needs a file to read and a file to write")
+			return 2
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		return watchRender(ctx, flags.Arg(0), *output, options, *interval, stdio)
+	}
+
+	program, err := load(fileArgs(flags)[0], stdio.in)
+	if err != nil {
+		report(stdio.err, err)
+		return 1
+	}
+
+	sceneOptions, display := options.forScene(program.scene)
+	img, err := render.Render(program.scene, sceneOptions)
+	if err != nil {
+		report(stdio.err, err)
+		return 1
+	}
+
+	if *output == "-" {
+		err = format.write(stdio.out, img, display)
+	} else {
+		err = writeImage(*output, img, format, display)
+	}
+	if err != nil {
+		report(stdio.err, err)
+		return 1
+	}
+
+	return 0
+}
+
+// writeImage writes the image to a temporary file next to path and renames
+// it over path, so a failed write leaves the previous image in place.
+func writeImage(path string, img *render.Image, format imageFormat, display render.Display) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+
+	err = format.write(f, img, display)
+	if err == nil {
+		err = f.Chmod(0o644)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
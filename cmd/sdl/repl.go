@@ -0,0 +1,21 @@
+package main
+
+import (
+	"github.com/kacperkrolak/scene-description-language/repl"
+)
+
+// runREPL starts an interactive session on standard input and output,
+// with the objects of the given files already defined.
+func runREPL(args []string, stdio stdio) int {
+	flags := newFlagSet("repl", stdio, "[file ...]")
+	if status, ok := parseFlags(flags, args); !ok {
+		return status
+	}
+
+	session := repl.New(stdio.out)
+	for _, path := range flags.Args() {
+		session.Execute(":load " + path)
+	}
+	session.Run(stdio.in)
+	return 0
+}
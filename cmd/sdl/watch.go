@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"time"
+
+	"github.com/kacperkrolak/scene-description-language/evaluator"
+	"github.com/kacperkrolak/scene-description-language/render"
+	"github.com/kacperkrolak/scene-description-language/scene"
+)
+
+// fileStamp identifies a version of a file. Files which
+// cannot be read, e.g. missing includes, have the zero stamp.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+func stat(path string) fileStamp {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{modTime: info.ModTime(), size: info.Size()}
+}
+
+// watchedFiles are the files a scene was evaluated from,
+// with their versions from before they were read.
+type watchedFiles map[string]fileStamp
+
+func (files watchedFiles) changed() bool {
+	for path, stamp := range files {
+		if stat(path) != stamp {
+			return true
+		}
+	}
+	return false
+}
+
+// watchRender renders the file to output, then polls the file and every file it
+// includes, rendering the image again after each change until ctx is done.
+// Renders after the first are incremental: only the rows of the image which
+// the changed spheres can affect are traced again, and every row when the
+// camera, the lights or the settings change. A failed edit is reported and
+// leaves the last good image in place. Rendering is skipped when the
+// evaluated scene is unchanged, e.g. after edits to comments.
+func watchRender(ctx context.Context, path, output string, options renderOptions, interval time.Duration, stdio stdio) int {
+	var files []string
+	var last *scene.Scene
+	var frame *render.Frame
+
+	for {
+		// Stamps are taken before reading, so edits made during
+		// the evaluation are picked up by the next poll.
+		before := make(watchedFiles, len(files))
+		for _, file := range files {
+			before[file] = stat(file)
+		}
+
+		e := evaluator.NewEvaluator()
+		program, err := loadWith(e, path, nil)
+
+		files = e.Files()
+		stamps := make(watchedFiles, len(files))
+		for _, file := range files {
+			stamp, ok := before[file]
+			if !ok {
+				stamp = stat(file)
+			}
+			stamps[file] = stamp
+		}
+
+		switch {
+		case err != nil:
+			report(stdio.err, err)
+			if last != nil {
+				fmt.Fprintf(stdio.out, "keeping the last good image in %s\n", output)
+			}
+		case last != nil && reflect.DeepEqual(program.scene, last):
+			fmt.Fprintln(stdio.out, "scene unchanged, not rendering")
+		default:
+			start := time.Now()
+			updated, err := renderTo(output, program.scene, options, frame)
+			if err != nil {
+				report(stdio.err, err)
+				break
+			}
+			last, frame = program.scene, updated
+			fmt.Fprintf(stdio.out, "rendered %s in %s, traced %d of %d rows\n", output, time.Since(start).Round(time.Millisecond), frame.Traced, frame.Image.Height)
+		}
+
+		if !waitForChange(ctx, stamps, interval) {
+			return 0
+		}
+	}
+}
+
+// waitForChange polls the files until one of them changes and reports
+// whether it happened, or returns false once ctx is done.
+func waitForChange(ctx context.Context, files watchedFiles, interval time.Duration) bool {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+			if files.changed() {
+				return true
+			}
+		}
+	}
+}
+
+// renderTo renders the scene to path, updating the last frame if there is one.
+func renderTo(path string, s *scene.Scene, options renderOptions, last *render.Frame) (*render.Frame, error) {
+	sceneOptions, display := options.forScene(s)
+	var frame *render.Frame
+	var err error
+	if last == nil {
+		frame, err = render.RenderFrame(s, sceneOptions)
+	} else {
+		frame, err = last.Update(s, sceneOptions)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := writeImage(path, frame.Image, options.format, display); err != nil {
+		return nil, err
+	}
+	return frame, nil
+}
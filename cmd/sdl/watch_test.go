@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kacperkrolak/scene-description-language/render"
+)
+
+// syncBuffer is a buffer which can be written by the watcher while the test reads it.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func waitFor(t *testing.T, what string, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestWatchRender(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "scene.sdl")
+	include := filepath.Join(dir, "materials.sdl")
+	output := filepath.Join(dir, "out.png")
+	write := func(path, contents string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(path, "INCLUDE \"materials.sdl\"\nSPHERE s = {radius: 1, position: [0, 0, 5], material: red}\n")
+	write(include, "MATERIAL red = {color: [1, 0, 0]}\n")
+
+	var stdout, stderr syncBuffer
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan int)
+	go func() {
+		done <- watchRender(ctx, path, output, renderOptions{Options: render.Options{Width: 8, Height: 6}, format: imageFormats[0]}, time.Millisecond, stdio{out: &stdout, err: &stderr})
+	}()
+
+	rendered := func(n int) func() bool {
+		return func() bool { return strings.Count(stdout.String(), "rendered "+output) == n }
+	}
+	waitFor(t, "the first render", rendered(1))
+	first, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A syntax error in an include keeps the last image.
+	write(include, "MATERIAL red = {color: [1, 0, 0]\n")
+	waitFor(t, "the error", func() bool { return strings.Contains(stdout.String(), "keeping the last good image") })
+	if !strings.Contains(stderr.String(), include+":2:1:") {
+		t.Errorf("wrong error. got=%q", stderr.String())
+	}
+	if got, _ := os.ReadFile(output); !bytes.Equal(got, first) {
+		t.Errorf("the last good image was replaced")
+	}
+
+	// Fixing the include renders again, even though it failed to parse before.
+	write(include, "MATERIAL red = {color: [0, 1, 0]}\n")
+	waitFor(t, "the second render", rendered(2))
+	if got, _ := os.ReadFile(output); bytes.Equal(got, first) {
+		t.Errorf("the image was not rendered again")
+	}
+
+	// Edits which do not change the scene are not rendered.
+	write(path, "// A red sphere.\nINCLUDE \"materials.sdl\"\nSPHERE s = {radius: 1, position: [0, 0, 5], material: red}\n")
+	waitFor(t, "the unchanged scene", func() bool { return strings.Contains(stdout.String(), "scene unchanged") })
+
+	// Only the rows which a new sphere can affect are traced again.
+	write(path, "INCLUDE \"materials.sdl\"\nSPHERE s = {radius: 1, position: [0, 0, 5], material: red}\nSPHERE t = {radius: 0.1, position: [0, 2, 5]}\n")
+	waitFor(t, "the third render", rendered(3))
+	if !strings.Contains(stdout.String(), "traced 6 of 6 rows\n") || !strings.Contains(stdout.String(), "traced 2 of 6 rows\n") {
+		t.Errorf("wrong rows traced. got=%q", stdout.String())
+	}
+
+	cancel()
+	if status := <-done; status != 0 {
+		t.Errorf("wrong exit status. got=%d", status)
+	}
+	if !rendered(3)() {
+		t.Errorf("wrong number of renders. got=%q", stdout.String())
+	}
+}
+
+func TestWriteImageKeepsOldImage(t *testing.T) {
+	dir := t.TempDir()
+	output := filepath.Join(dir, "out.png")
+	if err := os.WriteFile(output, []byte("old image"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	failing := imageFormat{name: "failing", write: func(w io.Writer, _ *render.Image, _ render.Display) error {
+		w.Write([]byte("partial"))
+		return errors.New("disk full")
+	}}
+	if err := writeImage(output, render.NewImage(1, 1), failing, render.Display{}); err == nil || err.Error() != "disk full" {
+		t.Errorf("wrong error. got=%v", err)
+	}
+	if got, _ := os.ReadFile(output); string(got) != "old image" {
+		t.Errorf("the old image was replaced. got=%q", got)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("temporary file left behind. got=%v", entries)
+	}
+
+	if err := writeImage(output, render.NewImage(1, 1), imageFormats[0], render.Display{}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got, _ := os.ReadFile(output); !bytes.HasPrefix(got, []byte("\x89PNG")) {
+		t.Errorf("the image was not written. got=%q", got)
+	}
+}
+
+func TestWatchUsage(t *testing.T) {
+	for _, args := range [][]string{
+		{"render", "-watch"},
+		{"render", "-watch", "-"},
+		{"render", "-watch", "-o", "-", "scene.sdl"},
+	} {
+		if status, _, stderr := runWith(args, ""); status != 2 || !strings.Contains(stderr, "-watch needs a file") {
+			t.Errorf("wrong result of %v. got=%d %q", args, status, stderr)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package evaluator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kacperkrolak/scene-description-language/ast"
+)
+
+// statementGraph records which statements of a file have to be evaluated
+// before each statement, based on the identifiers the statements reference.
+//
+// A reference to a name depends on the statement defining it and on every
+// MODIFY of that name, so objects always see the final value of the objects
+// they reference. The MODIFY statements of a name are applied in source order.
+// Names not defined in the file are resolved from the environment during evaluation.
+type statementGraph struct {
+	statements   []sourceStatement
+	dependencies [][]int // indices of the statements each statement depends on
+}
+
+func newStatementGraph(statements []sourceStatement) (*statementGraph, *Error) {
+	graph := &statementGraph{
+		statements:   statements,
+		dependencies: make([][]int, len(statements)),
+	}
+
+	// providers holds the index of the last statement changing the value of each name.
+	providers := make(map[string]int)
+	for i, statement := range statements {
+		if assign, ok := statement.Statement.(*ast.AssignStatement); ok {
+			if _, ok := providers[assign.Name.Value]; ok {
+				return nil, &Error{
+					Message: fmt.Sprintf("redefining objects is not allowed: %s", assign.Name.Value),
+					File:    statement.file,
+					Pos:     assign.Pos(),
+				}
+			}
+
+			providers[assign.Name.Value] = i
+		}
+	}
+
+	// previous holds the statement each MODIFY statement applies its changes to.
+	previous := make(map[int]int)
+	for i, statement := range statements {
+		if modify, ok := statement.Statement.(*ast.ModifyStatement); ok {
+			if provider, ok := providers[modify.Name.Value]; ok {
+				previous[i] = provider
+			}
+
+			providers[modify.Name.Value] = i
+		}
+	}
+
+	for i, statement := range statements {
+		for _, name := range referencedNames(statement.Statement) {
+			provider, ok := providers[name]
+			if modify, isModify := statement.Statement.(*ast.ModifyStatement); isModify && name == modify.Name.Value {
+				provider, ok = previous[i]
+			}
+
+			if ok {
+				graph.dependencies[i] = append(graph.dependencies[i], provider)
+			}
+		}
+	}
+
+	return graph, nil
+}
+
+// evaluate evaluates every statement after its dependencies
+// and returns the value of the last statement. Errors without
+// a position get the position of the statement which failed.
+func (graph *statementGraph) evaluate(eval func(sourceStatement) Object) Object {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	states := make([]int, len(graph.statements))
+	results := make([]Object, len(graph.statements))
+	var path []int
+
+	var visit func(i int) Object
+	visit = func(i int) Object {
+		switch states[i] {
+		case visited:
+			return results[i]
+		case visiting:
+			return graph.cycleError(path, i)
+		}
+
+		states[i] = visiting
+		path = append(path, i)
+		for _, dependency := range graph.dependencies[i] {
+			if result := visit(dependency); isError(result) {
+				return result
+			}
+		}
+		path = path[:len(path)-1]
+
+		results[i] = eval(graph.statements[i])
+		// Errors without a position of their own point at the whole statement.
+		if err, ok := results[i].(Error); ok {
+			err.File = graph.statements[i].file
+			if !err.Pos.IsValid() {
+				err.Pos = graph.statements[i].Pos()
+			}
+			results[i] = err
+		}
+		states[i] = visited
+
+		return results[i]
+	}
+
+	var result Object
+	for i := range graph.statements {
+		result = visit(i)
+		if isError(result) {
+			return result
+		}
+	}
+
+	return result
+}
+
+func (graph *statementGraph) cycleError(path []int, start int) Error {
+	var names []string
+	for i := len(path) - 1; i >= 0; i-- {
+		names = append([]string{statementName(graph.statements[path[i]].Statement)}, names...)
+		if path[i] == start {
+			break
+		}
+	}
+	names = append(names, statementName(graph.statements[start].Statement))
+
+	return Error{
+		Message: fmt.Sprintf("dependency cycle: %s", strings.Join(names, " -> ")),
+		File:    graph.statements[start].file,
+		Pos:     graph.statements[start].Pos(),
+	}
+}
+
+// statementName describes a statement in error messages.
+func statementName(statement ast.Statement) string {
+	switch statement := statement.(type) {
+	case *ast.AssignStatement:
+		return statement.Name.Value
+	case *ast.ModifyStatement:
+		return fmt.Sprintf("%s %s", statement.TokenLiteral(), statement.Name.Value)
+	default:
+		return statement.String()
+	}
+}
+
+// referencedNames returns the names of the objects a statement uses, without duplicates.
+// The target of a MODIFY statement is included, the name defined by an assignment is not.
+func referencedNames(statement ast.Statement) []string {
+	var names []string
+	seen := make(map[string]bool)
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	var collect func(node ast.Node) bool
+	collect = func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.Identifier:
+			add(node.Value)
+		case *ast.MemberExpression:
+			// The property is a key of the object, not a reference.
+			ast.Inspect(node.Object, collect)
+			return false
+		}
+		return true
+	}
+
+	switch statement := statement.(type) {
+	case *ast.AssignStatement:
+		if statement.Parent != nil {
+			add(statement.Parent.Value)
+		}
+		if statement.Position != nil {
+			ast.Inspect(statement.Position, collect)
+		}
+		if statement.Value != nil {
+			ast.Inspect(statement.Value, collect)
+		}
+	case *ast.ModifyStatement:
+		add(statement.Name.Value)
+		if statement.Value != nil {
+			ast.Inspect(statement.Value, collect)
+		}
+	default:
+		ast.Inspect(statement, collect)
+	}
+
+	return names
+}
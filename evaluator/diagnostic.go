@@ -0,0 +1,43 @@
+package evaluator
+
+import (
+	"strings"
+
+	"github.com/kacperkrolak/scene-description-language/token"
+)
+
+// Diagnostic describes a problem found in a source file.
+type Diagnostic struct {
+	File    string         // Path of the file, empty if it was read from a reader.
+	Pos     token.Position // Position of the problem, zero if it is unknown.
+	Message string
+}
+
+// String formats the diagnostic as file:line:column: message,
+// leaving out the parts which are not known.
+func (d Diagnostic) String() string {
+	var location []string
+	if d.File != "" {
+		location = append(location, d.File)
+	}
+	if d.Pos.IsValid() {
+		location = append(location, d.Pos.String())
+	}
+
+	if len(location) == 0 {
+		return d.Message
+	}
+
+	return strings.Join(location, ":") + ": " + d.Message
+}
+
+// Diagnostics is the error returned when files cannot be parsed or evaluated.
+type Diagnostics []Diagnostic
+
+func (d Diagnostics) Error() string {
+	lines := make([]string, len(d))
+	for i, diagnostic := range d {
+		lines[i] = diagnostic.String()
+	}
+	return strings.Join(lines, "\n")
+}
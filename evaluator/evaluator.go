@@ -3,28 +3,34 @@ package evaluator
 import (
 	"fmt"
 	"io"
+	"math"
+	"path/filepath"
 	"strings"
 
 	"github.com/kacperkrolak/scene-description-language/ast"
 	"github.com/kacperkrolak/scene-description-language/lexer"
 	"github.com/kacperkrolak/scene-description-language/parser"
+	"github.com/kacperkrolak/scene-description-language/token"
 )
 
 type ObjectType string
 
 const (
 	NUMBER_OBJ     ObjectType = "NUMBER"
+	STRING_OBJ     ObjectType = "STRING"
 	COLOR_OBJ      ObjectType = "COLOR"
 	MATERIAL_OBJ   ObjectType = "MATERIAL"
 	ERROR_OBJ      ObjectType = "ERROR"
 	ARRAY_OBJ      ObjectType = "ARRAY"
 	DICTIONARY_OBJ ObjectType = "DICTIONARY"
 	ENTITY_OBJ     ObjectType = "ENTITY"
+	REFERENCE_OBJ  ObjectType = "REFERENCE"
 )
 
 // Object represent a constant defined in the SDL file.
 type Object interface {
 	Type() ObjectType
+	Inspect() string // The value written in the syntax of the language.
 }
 
 // Array represents an array of objects.
@@ -36,15 +42,29 @@ func (a Array) Type() ObjectType {
 	return ARRAY_OBJ
 }
 
-// Array represents an array of objects.
+// Dictionary represents properties, remembering the order they were defined in.
+// Properties should be added with Set, which keeps Keys and Properties in sync.
 type Dictionary struct {
+	Keys       []string // Names of the properties in the order they were defined.
 	Properties map[string]Object
 }
 
+func NewDictionary() *Dictionary {
+	return &Dictionary{Properties: make(map[string]Object)}
+}
+
 func (d Dictionary) Type() ObjectType {
 	return DICTIONARY_OBJ
 }
 
+// Set sets the value of a property. A new property is added after the existing ones.
+func (d *Dictionary) Set(key string, value Object) {
+	if _, ok := d.Properties[key]; !ok {
+		d.Keys = append(d.Keys, key)
+	}
+	d.Properties[key] = value
+}
+
 // Number represents a number constant defined in the SDL file.
 type Number struct {
 	Value float64
@@ -54,8 +74,18 @@ func (n Number) Type() ObjectType {
 	return NUMBER_OBJ
 }
 
+// String represents a text constant, e.g. the name of an option.
+type String struct {
+	Value string
+}
+
+func (s String) Type() ObjectType {
+	return STRING_OBJ
+}
+
 // Entity represents a scene object (like Sphere, Light) with its properties
 type Entity struct {
+	Name  string
 	Class string
 	Value Object
 }
@@ -64,9 +94,24 @@ func (e Entity) Type() ObjectType {
 	return ENTITY_OBJ
 }
 
+// Reference points to an entity by its name, e.g. a child of a GROUP.
+type Reference struct {
+	Name string
+}
+
+func (r Reference) Type() ObjectType {
+	return REFERENCE_OBJ
+}
+
 // Error represents an object that could not be evaluated.
 type Error struct {
 	Message string
+	File    string         // file containing the statement which failed, empty for the evaluated file
+	Pos     token.Position // position of the statement which failed, zero if unknown
+}
+
+func (e Error) diagnostic() Diagnostic {
+	return Diagnostic{File: e.File, Pos: e.Pos, Message: e.Message}
 }
 
 func (e Error) Type() ObjectType {
@@ -76,69 +121,154 @@ func (e Error) Type() ObjectType {
 // Environment represents the environment in which the SDL file is evaluated.
 // It contains the contants defined in the SDL file.
 type Environment struct {
-	store map[string]Entity
+	store       map[string]Entity
+	names       []string            // names of the stored entities in the order they were defined
+	groups      map[string]string   // name of the GROUP containing each entity
+	definitions map[string]Location // where each entity was defined
+}
+
+// Location is a position in one of the evaluated files.
+type Location struct {
+	File string // empty for the file read from a reader
+	Pos  token.Position
+}
+
+func newEnvironment() *Environment {
+	return &Environment{
+		store:       make(map[string]Entity),
+		groups:      make(map[string]string),
+		definitions: make(map[string]Location),
+	}
+}
+
+func (env *Environment) set(name string, entity Entity) {
+	if _, ok := env.store[name]; !ok {
+		env.names = append(env.names, name)
+	}
+	env.store[name] = entity
 }
 
 // EvaluatedValues groups the most high-level objects that can be evaluated.
 type EvaluatedValues struct {
 	Entities map[string][]Entity // Entities grouped by their class name.
+	Objects  []Entity            // All entities in the order they were defined.
+	Roots    []*SceneNode        // Top-level nodes of the scene graph, in declaration order.
+	Nodes    []*SceneNode        // All nodes of the scene graph in depth-first order.
 }
 
 type Evaluator struct {
-	env *Environment
+	env      *Environment
+	included map[string]bool // absolute paths of the files that were already evaluated
+	files    []string        // absolute paths of the files read, in the order they were read
 }
 
 func Eval(node ast.Node) (EvaluatedValues, error) {
 	evaluator := NewEvaluator()
 	ev := evaluator.Eval(node)
 	if isError(ev) {
-		return EvaluatedValues{}, fmt.Errorf("failed to evaluate file: %s", ev.(Error).Message)
+		return EvaluatedValues{}, fmt.Errorf("failed to evaluate file: %s", ev.(Error).diagnostic())
 	}
 
 	return evaluator.ExportValues(), nil
 }
 
 func NewEvaluator() *Evaluator {
-	return &Evaluator{env: &Environment{store: make(map[string]Entity)}}
+	return &Evaluator{env: newEnvironment(), included: make(map[string]bool)}
 }
 
 func (evaluator *Evaluator) ExportValues() EvaluatedValues {
 	entities := make(map[string][]Entity)
-	for _, entity := range evaluator.env.store {
+	var objects []Entity
+	for _, name := range evaluator.env.names {
+		entity := evaluator.env.store[name]
 		entities[entity.Class] = append(entities[entity.Class], entity)
+		objects = append(objects, entity)
 	}
 
-	return EvaluatedValues{Entities: entities}
+	roots, nodes := evaluator.buildSceneGraph()
+
+	return EvaluatedValues{Entities: entities, Objects: objects, Roots: roots, Nodes: nodes}
 }
 
+// EvaluatePath evaluates the file at the given path. Paths in its INCLUDE
+// statements are resolved relative to the directory of the file.
+// Problems in the files are returned as Diagnostics.
+func (evaluator *Evaluator) EvaluatePath(path string) error {
+	fileAst, absPath, err := evaluator.loadFile(path)
+	if err != nil {
+		return err
+	}
+
+	obj := evaluator.evalFile(fileAst, path, filepath.Dir(path), []string{absPath})
+	if isError(obj) {
+		return Diagnostics{obj.(Error).diagnostic()}
+	}
+
+	return nil
+}
+
+// EvaluateSource evaluates src as the contents of the file at the given path,
+// which may differ from what is saved, e.g. in an editor. Paths in its INCLUDE
+// statements are resolved relative to the directory of the file.
+// Problems in the files are returned as Diagnostics.
+func (evaluator *Evaluator) EvaluateSource(src io.Reader, path string) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("cannot resolve path %q: %w", path, err)
+	}
+
+	fileAst, err := getAst(src, path)
+	if err != nil {
+		return err
+	}
+	evaluator.included[absPath] = true
+	evaluator.files = append(evaluator.files, absPath)
+
+	obj := evaluator.evalFile(fileAst, path, filepath.Dir(path), []string{absPath})
+	if isError(obj) {
+		return Diagnostics{obj.(Error).diagnostic()}
+	}
+
+	return nil
+}
+
+// EvaluateFile evaluates the file read from r. Paths in its INCLUDE
+// statements are resolved relative to the working directory.
+// Problems in the files are returned as Diagnostics.
 func (evaluator *Evaluator) EvaluateFile(r io.Reader) error {
-	fileAst, err := getAst(r)
+	fileAst, err := getAst(r, "")
 	if err != nil {
 		return err
 	}
 
-	obj := evaluator.Eval(fileAst)
+	obj := evaluator.evalFile(fileAst, "", "", nil)
 	if isError(obj) {
-		return fmt.Errorf("failed to evaluate file: %s", obj.(Error).Message)
+		return Diagnostics{obj.(Error).diagnostic()}
 	}
 
 	return nil
 }
 
 func isError(obj Object) bool {
-	return obj.Type() == ERROR_OBJ
+	return obj != nil && obj.Type() == ERROR_OBJ
 }
 
 func (evaluator *Evaluator) Eval(node ast.Node) Object {
 	switch node := node.(type) {
 	case *ast.File:
-		return evaluator.evalFile(node)
+		return evaluator.evalFile(node, "", "", nil)
+	case *ast.IncludeStatement:
+		return Error{Message: "INCLUDE is only allowed at the top level of a file"}
 	case *ast.AssignStatement:
 		return evaluator.evalAssignStatement(node)
+	case *ast.ModifyStatement:
+		return evaluator.evalModifyStatement(node)
 	case *ast.ExpressionStatement:
 		return evaluator.Eval(node.Expression)
 	case *ast.FloatLiteral:
 		return &Number{Value: node.Value}
+	case *ast.StringLiteral:
+		return &String{Value: node.Value}
 	case *ast.ArrayExpression:
 		return evaluator.evalArrayExpression(node)
 	case *ast.PropertiesExpression:
@@ -162,6 +292,25 @@ func (evaluator *Evaluator) Eval(node ast.Node) Object {
 		}
 
 		return evaluator.evalInfixExpression(node.Operator, left, right)
+	case *ast.IndexExpression:
+		left := evaluator.Eval(node.Left)
+		if isError(left) {
+			return left
+		}
+
+		index := evaluator.Eval(node.Index)
+		if isError(index) {
+			return index
+		}
+
+		return evalIndexExpression(left, index)
+	case *ast.MemberExpression:
+		object := evaluator.Eval(node.Object)
+		if isError(object) {
+			return object
+		}
+
+		return evalMemberExpression(node, object)
 	case *ast.Identifier:
 		return evaluator.evalIdentifier(node)
 	default:
@@ -169,15 +318,65 @@ func (evaluator *Evaluator) Eval(node ast.Node) Object {
 	}
 }
 
-func (evaluator *Evaluator) evalFile(file *ast.File) Object {
-	var result Object
-	for _, statement := range file.Statements {
-		result = evaluator.Eval(statement)
+// evalFile evaluates the statements of the file and of the files it includes,
+// ordering them so that objects can be referenced before they are declared.
+// The file is named name in errors, INCLUDE paths are resolved relative to dir
+// and stack holds the absolute paths of the files that include this one.
+// The result is the value of the last statement of the file.
+func (evaluator *Evaluator) evalFile(file *ast.File, name string, dir string, stack []string) Object {
+	statements, err := evaluator.expandIncludes(sourceStatements(file, name), dir, stack)
+	if err != nil {
+		return *err
+	}
+
+	graph, err := newStatementGraph(statements)
+	if err != nil {
+		return *err
+	}
+
+	return graph.evaluate(func(statement sourceStatement) Object {
+		result := evaluator.Eval(statement.Statement)
 		if isError(result) {
 			return result
 		}
-	}
-	return result
+
+		location := Location{File: statement.file, Pos: statement.Pos()}
+		switch node := statement.Statement.(type) {
+		case *ast.AssignStatement:
+			evaluator.env.definitions[node.Name.Value] = location
+		case *ast.ModifyStatement:
+			// The camera is not defined anywhere, it is first mentioned when modified.
+			if _, ok := evaluator.env.definitions[node.Name.Value]; !ok {
+				evaluator.env.definitions[node.Name.Value] = location
+			}
+		}
+		return result
+	})
+}
+
+// Lookup returns the entity with the given name. After a failed evaluation
+// it still finds the entities evaluated before the error.
+func (evaluator *Evaluator) Lookup(name string) (Entity, bool) {
+	entity, ok := evaluator.env.store[name]
+	return entity, ok
+}
+
+// Files returns the absolute paths of the files the evaluator read, or tried
+// to read, in the order it read them. It includes the files which failed to
+// parse, so they can be watched for changes after a failed evaluation.
+func (evaluator *Evaluator) Files() []string {
+	return append([]string{}, evaluator.files...)
+}
+
+// Names returns the names of the entities in the order they were defined.
+func (evaluator *Evaluator) Names() []string {
+	return append([]string{}, evaluator.env.names...)
+}
+
+// Definition returns where the entity with the given name was defined.
+func (evaluator *Evaluator) Definition(name string) (Location, bool) {
+	location, ok := evaluator.env.definitions[name]
+	return location, ok
 }
 
 func (evaluator *Evaluator) evalArrayExpression(node *ast.ArrayExpression) Object {
@@ -194,23 +393,37 @@ func (evaluator *Evaluator) evalArrayExpression(node *ast.ArrayExpression) Objec
 }
 
 func (evaluator *Evaluator) evalPropertiesExpression(node *ast.PropertiesExpression) Object {
-	properties := make(map[string]Object)
+	return evaluator.evalProperties(node, nil)
+}
 
-	for key, element := range node.Properties {
-		result := evaluator.Eval(element)
+// evalProperties evaluates properties, treating the values of properties
+// listed in references as arrays of object names.
+func (evaluator *Evaluator) evalProperties(node *ast.PropertiesExpression, references map[string]bool) Object {
+	properties := NewDictionary()
+
+	for _, keyNode := range node.Keys {
+		key := keyNode.Value
+		element := node.Properties[key]
+		var result Object
+		if references[key] {
+			result = evaluator.evalReferences(element)
+		} else {
+			result = evaluator.Eval(element)
+		}
 		if isError(result) {
 			return result
 		}
 
-		properties[key] = result
+		properties.Set(key, result)
 	}
 
-	return &Dictionary{Properties: properties}
+	return properties
 }
 
 // GetAst uses scene-description-language module to parse
-// the configuration file into an AST.
-func getAst(r io.Reader) (*ast.File, error) {
+// the configuration file into an AST. Syntax errors are returned
+// as Diagnostics in the file with the given name.
+func getAst(r io.Reader, name string) (*ast.File, error) {
 	configString, err := io.ReadAll(r)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config: %w", err)
@@ -220,8 +433,12 @@ func getAst(r io.Reader) (*ast.File, error) {
 	sdlParser := parser.New(sdlLexer)
 
 	ast := sdlParser.ParseFile()
-	if len(sdlParser.Errors()) > 0 {
-		return nil, fmt.Errorf("failed to parse config: %s", strings.Join(sdlParser.Errors(), ";"))
+	if len(sdlParser.SyntaxErrors()) > 0 {
+		var diagnostics Diagnostics
+		for _, syntaxError := range sdlParser.SyntaxErrors() {
+			diagnostics = append(diagnostics, Diagnostic{File: name, Pos: syntaxError.Pos, Message: syntaxError.Message})
+		}
+		return nil, diagnostics
 	}
 
 	return ast, nil
@@ -280,23 +497,281 @@ func (evaluator *Evaluator) evalAssignStatement(s *ast.AssignStatement) Object {
 		return Error{Message: fmt.Sprintf("redefining objects is not allowed: %s", s.Name.Value)}
 	}
 
-	evaluatedValue := evaluator.Eval(s.Value)
+	evaluatedValue := evaluator.evalValue(s.Token.Literal, s.Value)
 	if isError(evaluatedValue) {
 		return evaluatedValue
 	}
 
-	evaluatedEntity := Entity{Class: s.Token.Literal, Value: evaluatedValue}
+	if s.Position != nil {
+		evaluatedValue = evaluator.evalAt(s, evaluatedValue)
+		if isError(evaluatedValue) {
+			return evaluatedValue
+		}
+	}
+
+	if s.Parent != nil {
+		evaluatedValue = evaluator.evalExtends(s, evaluatedValue)
+		if isError(evaluatedValue) {
+			return evaluatedValue
+		}
+	}
+
+	evaluatedEntity := Entity{Name: s.Name.Value, Class: s.Token.Literal, Value: evaluatedValue}
+
+	if result := evaluator.placeEntity(evaluatedEntity); isError(result) {
+		return result
+	}
 
-	evaluator.env.store[s.Name.Value] = evaluatedEntity
+	evaluator.env.set(s.Name.Value, evaluatedEntity)
 
 	return evaluatedValue
 }
 
+// evalAt sets the position property to the value given after AT.
+func (evaluator *Evaluator) evalAt(s *ast.AssignStatement, value Object) Object {
+	position := evaluator.Eval(s.Position)
+	if isError(position) {
+		return position
+	}
+
+	properties, ok := value.(*Dictionary)
+	if !ok {
+		return Error{Message: fmt.Sprintf("AT requires properties, %s is %s", s.Name.Value, value.Type())}
+	}
+
+	if _, ok := properties.Properties["position"]; ok {
+		return Error{Message: fmt.Sprintf("position of %s is given both with AT and as a property", s.Name.Value)}
+	}
+
+	override := NewDictionary()
+	override.Set("position", position)
+	return mergeDictionaries(properties, override)
+}
+
+// evalModifyStatement merges the given properties into an existing object, like EXTENDS does.
+// The camera does not have to be defined before it is modified.
+func (evaluator *Evaluator) evalModifyStatement(s *ast.ModifyStatement) Object {
+	entity, ok := evaluator.env.store[s.Name.Value]
+	if !ok {
+		if s.Name.Value != token.CAMERA {
+			return Error{Message: fmt.Sprintf("undefined identifier: %s", s.Name.Value)}
+		}
+
+		entity = Entity{Name: token.CAMERA, Class: token.CAMERA, Value: NewDictionary()}
+	}
+
+	base, ok := entity.Value.(*Dictionary)
+	if !ok {
+		return Error{Message: fmt.Sprintf("only properties can be modified, %s is %s", s.Name.Value, entity.Value.Type())}
+	}
+
+	value := evaluator.evalValue(entity.Class, s.Value)
+	if isError(value) {
+		return value
+	}
+
+	override, ok := value.(*Dictionary)
+	if !ok {
+		return Error{Message: fmt.Sprintf("only properties can modify %s, got %s", s.Name.Value, value.Type())}
+	}
+
+	entity.Value = mergeDictionaries(base, override)
+
+	if result := evaluator.placeEntity(entity); isError(result) {
+		return result
+	}
+
+	evaluator.env.set(s.Name.Value, entity)
+
+	return entity.Value
+}
+
+// evalValue evaluates the value of an object of the given class.
+func (evaluator *Evaluator) evalValue(class string, node ast.Expression) Object {
+	properties, ok := node.(*ast.PropertiesExpression)
+	if !ok {
+		return evaluator.Eval(node)
+	}
+
+	return evaluator.evalProperties(properties, referenceProperties[class])
+}
+
+// evalReferences evaluates an array of object names into an array of references.
+func (evaluator *Evaluator) evalReferences(node ast.Expression) Object {
+	array, ok := node.(*ast.ArrayExpression)
+	if !ok {
+		return Error{Message: fmt.Sprintf("expected an array of object names, got: %s", node.String())}
+	}
+
+	elements := []Object{}
+	for _, element := range array.Elements {
+		identifier, ok := element.(*ast.Identifier)
+		if !ok {
+			return Error{Message: fmt.Sprintf("expected an object name, got: %s", element.String())}
+		}
+
+		if _, ok := evaluator.env.store[identifier.Value]; !ok {
+			return Error{Message: fmt.Sprintf("undefined identifier: %s", identifier.Value)}
+		}
+
+		elements = append(elements, &Reference{Name: identifier.Value})
+	}
+
+	return &Array{Elements: elements}
+}
+
+// evalExtends merges the properties of the object named after EXTENDS
+// with the properties given in the statement. Properties of the statement
+// take precedence, nested properties are merged recursively.
+func (evaluator *Evaluator) evalExtends(s *ast.AssignStatement, value Object) Object {
+	parent, ok := evaluator.env.store[s.Parent.Value]
+	if !ok {
+		return Error{Message: fmt.Sprintf("undefined identifier: %s", s.Parent.Value)}
+	}
+
+	if parent.Class != s.Token.Literal {
+		return Error{Message: fmt.Sprintf("%s %s cannot extend %s %s", s.Token.Literal, s.Name.Value, parent.Class, s.Parent.Value)}
+	}
+
+	base, ok := parent.Value.(*Dictionary)
+	if !ok {
+		return Error{Message: fmt.Sprintf("only properties can be extended, %s is %s", s.Parent.Value, parent.Value.Type())}
+	}
+
+	override, ok := value.(*Dictionary)
+	if !ok {
+		return Error{Message: fmt.Sprintf("only properties can extend %s, got %s", s.Parent.Value, value.Type())}
+	}
+
+	// An object is the child of one group only, so groups do not inherit children.
+	if parent.Class == token.GROUP {
+		inherited := NewDictionary()
+		for _, key := range base.Keys {
+			if key != "children" {
+				inherited.Set(key, base.Properties[key])
+			}
+		}
+		base = inherited
+	}
+
+	return mergeDictionaries(base, override)
+}
+
+// mergeDictionaries returns a new dictionary with properties of base
+// overridden by properties of override. Neither argument is modified.
+// Properties keep their place from base, new ones follow in the order of override.
+func mergeDictionaries(base *Dictionary, override *Dictionary) *Dictionary {
+	merged := NewDictionary()
+	for _, key := range base.Keys {
+		merged.Set(key, base.Properties[key])
+	}
+
+	for _, key := range override.Keys {
+		value := override.Properties[key]
+		baseValue, baseIsDictionary := merged.Properties[key].(*Dictionary)
+		overrideValue, overrideIsDictionary := value.(*Dictionary)
+		if baseIsDictionary && overrideIsDictionary {
+			merged.Set(key, mergeDictionaries(baseValue, overrideValue))
+			continue
+		}
+
+		merged.Set(key, value)
+	}
+
+	return merged
+}
+
 func (evaluator *Evaluator) evalIdentifier(node *ast.Identifier) Object {
 	entity, ok := evaluator.env.store[node.Value]
 	if !ok {
-		return Error{Message: fmt.Sprintf("undefined identifier: %s", node.Value)}
+		return Error{Message: fmt.Sprintf("undefined identifier: %s", node.Value), Pos: node.Pos()}
 	}
 
 	return entity.Value
 }
+
+func evalIndexExpression(left Object, index Object) Object {
+	array, ok := left.(*Array)
+	if !ok {
+		return Error{Message: fmt.Sprintf("index operator not supported: %s", left.Type())}
+	}
+
+	number, ok := index.(*Number)
+	if !ok {
+		return Error{Message: fmt.Sprintf("array index must be a NUMBER, got: %s", index.Type())}
+	}
+
+	if number.Value != math.Trunc(number.Value) {
+		return Error{Message: fmt.Sprintf("array index must be an integer, got: %g", number.Value)}
+	}
+
+	if number.Value < 0 || number.Value >= float64(len(array.Elements)) {
+		return Error{Message: fmt.Sprintf("index %g out of range for array of length %d", number.Value, len(array.Elements))}
+	}
+
+	return array.Elements[int(number.Value)]
+}
+
+// swizzleComponents maps the names usable after a dot on an array
+// to the indices of the elements they refer to.
+var swizzleComponents = []map[byte]int{
+	{'x': 0, 'y': 1, 'z': 2, 'w': 3},
+	{'r': 0, 'g': 1, 'b': 2, 'a': 3},
+}
+
+func evalMemberExpression(node *ast.MemberExpression, object Object) Object {
+	switch object := object.(type) {
+	case *Dictionary:
+		value, ok := object.Properties[node.Property.Value]
+		if !ok {
+			return Error{Message: fmt.Sprintf("undefined property %q of %s (available: %s)", node.Property.Value, node.Object.String(), strings.Join(object.Keys, ", "))}
+		}
+
+		return value
+	case *Array:
+		return evalSwizzle(node, object)
+	default:
+		return Error{Message: fmt.Sprintf("cannot access property %q of %s", node.Property.Value, object.Type())}
+	}
+}
+
+// evalSwizzle picks elements of an array by component names,
+// e.g. pos.x returns the first element and pos.xz an array of the first and third.
+func evalSwizzle(node *ast.MemberExpression, array *Array) Object {
+	swizzle := node.Property.Value
+	var components map[byte]int
+	for _, candidate := range swizzleComponents {
+		if _, ok := candidate[swizzle[0]]; ok {
+			components = candidate
+			break
+		}
+	}
+
+	if components == nil {
+		return Error{Message: fmt.Sprintf("undefined property %q of %s (arrays only support swizzles like x, xy, rgb)", swizzle, node.Object.String())}
+	}
+
+	if len(swizzle) > 4 {
+		return Error{Message: fmt.Sprintf("swizzle %q of %s is longer than 4 components", swizzle, node.Object.String())}
+	}
+
+	elements := make([]Object, 0, len(swizzle))
+	for i := 0; i < len(swizzle); i++ {
+		index, ok := components[swizzle[i]]
+		if !ok {
+			return Error{Message: fmt.Sprintf("invalid component %q in swizzle %q of %s", swizzle[i], swizzle, node.Object.String())}
+		}
+
+		if index >= len(array.Elements) {
+			return Error{Message: fmt.Sprintf("component %q in swizzle %q out of range for array of length %d", swizzle[i], swizzle, len(array.Elements))}
+		}
+
+		elements = append(elements, array.Elements[index])
+	}
+
+	if len(elements) == 1 {
+		return elements[0]
+	}
+
+	return &Array{Elements: elements}
+}
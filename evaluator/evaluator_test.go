@@ -2,7 +2,10 @@ package evaluator
 
 import (
 	"encoding/json"
+	"errors"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/kacperkrolak/scene-description-language/lexer"
@@ -194,6 +197,8 @@ func TestOperatorPrecedence(t *testing.T) {
 
 func testEnvironmentObject(t *testing.T, env Environment, expected map[string]Entity) bool {
 	for expectedKey, expectedValue := range expected {
+		// Entities are stored under their names.
+		expectedValue.Name = expectedKey
 		evaluatedValue, ok := env.store[expectedKey]
 		if !ok {
 			t.Errorf("key not found in environment: %s, env=%v", expectedKey, env.store)
@@ -270,19 +275,19 @@ SPHERE sphere = {color: red, radius: 1}
 
 	expected := map[string][]Entity{
 		"NUMBER": []Entity{
-			Entity{Class: "NUMBER", Value: &Number{Value: 255}},
-			Entity{Class: "NUMBER", Value: &Number{Value: 0}},
-			Entity{Class: "NUMBER", Value: &Number{Value: 0}},
+			Entity{Name: "r", Class: "NUMBER", Value: &Number{Value: 255}},
+			Entity{Name: "g", Class: "NUMBER", Value: &Number{Value: 0}},
+			Entity{Name: "b", Class: "NUMBER", Value: &Number{Value: 0}},
 		},
 		"COLOR": []Entity{
-			Entity{Class: "COLOR", Value: &Array{Elements: []Object{
+			Entity{Name: "red", Class: "COLOR", Value: &Array{Elements: []Object{
 				&Number{Value: 255},
 				&Number{Value: 0},
 				&Number{Value: 0},
 			}}},
 		},
 		"SPHERE": []Entity{
-			Entity{Class: "SPHERE", Value: &Dictionary{Properties: map[string]Object{
+			Entity{Name: "sphere", Class: "SPHERE", Value: &Dictionary{Keys: []string{"color", "radius"}, Properties: map[string]Object{
 				"color": &Array{Elements: []Object{
 					&Number{Value: 255},
 					&Number{Value: 0},
@@ -299,3 +304,521 @@ SPHERE sphere = {color: red, radius: 1}
 		}
 	}
 }
+
+func TestEvalExtends(t *testing.T) {
+	input := `
+COLOR white = [1, 1, 1]
+COLOR red = [1, 0, 0]
+MATERIAL shiny = {
+	ambientIntensity: 0.1,
+	color: white,
+	texture: {scale: 2, offset: 1},
+}
+MATERIAL shinyRed EXTENDS shiny = {
+	color: red,
+	texture: {offset: 3},
+}
+`
+	evaluator := NewEvaluator()
+	evaluated := testEval(evaluator, input)
+	if isError(evaluated) {
+		t.Fatalf("error: %v", evaluated)
+	}
+
+	expected := map[string]Entity{
+		"shiny": {Class: "MATERIAL", Value: &Dictionary{Keys: []string{"ambientIntensity", "color", "texture"}, Properties: map[string]Object{
+			"ambientIntensity": &Number{Value: 0.1},
+			"color":            &Array{Elements: []Object{&Number{Value: 1}, &Number{Value: 1}, &Number{Value: 1}}},
+			"texture": &Dictionary{Keys: []string{"scale", "offset"}, Properties: map[string]Object{
+				"scale":  &Number{Value: 2},
+				"offset": &Number{Value: 1},
+			}},
+		}}},
+		"shinyRed": {Class: "MATERIAL", Value: &Dictionary{Keys: []string{"ambientIntensity", "color", "texture"}, Properties: map[string]Object{
+			"ambientIntensity": &Number{Value: 0.1},
+			"color":            &Array{Elements: []Object{&Number{Value: 1}, &Number{Value: 0}, &Number{Value: 0}}},
+			"texture": &Dictionary{Keys: []string{"scale", "offset"}, Properties: map[string]Object{
+				"scale":  &Number{Value: 2},
+				"offset": &Number{Value: 3},
+			}},
+		}}},
+	}
+
+	testEnvironmentObject(t, *evaluator.env, expected)
+}
+
+func TestPropertiesKeepTheirOrder(t *testing.T) {
+	input := `
+MATERIAL base = {specularIntensity: 1, color: [1, 1, 1], texture: {scale: 2, offset: 1}}
+MATERIAL derived EXTENDS base = {texture: {rotation: 90, scale: 3}, ambientIntensity: 0.2}
+MODIFY derived {diffuseIntensity: 0.5, color: [1, 0, 0]}
+`
+	evaluator := NewEvaluator()
+	evaluated := testEval(evaluator, input)
+	if isError(evaluated) {
+		t.Fatalf("error: %v", evaluated)
+	}
+
+	got, err := json.Marshal(evaluator.env.store["derived"].Value)
+	if err != nil {
+		t.Fatalf("error marshalling: %v", err)
+	}
+
+	expected := `{"specularIntensity":1,"color":[1,0,0],"texture":{"scale":3,"offset":1,"rotation":90},"ambientIntensity":0.2,"diffuseIntensity":0.5}`
+	if string(got) != expected {
+		t.Errorf("wrong order of properties.\ngot=%s\nwant=%s", got, expected)
+	}
+}
+
+func TestDictionarySet(t *testing.T) {
+	dictionary := NewDictionary()
+	dictionary.Set("b", &Number{Value: 1})
+	dictionary.Set("a", &Number{Value: 2})
+	dictionary.Set("b", &Number{Value: 3})
+
+	if strings.Join(dictionary.Keys, ",") != "b,a" {
+		t.Errorf("wrong keys. got=%v", dictionary.Keys)
+	}
+	testNumberObject(t, dictionary.Properties["b"], 3)
+}
+
+func TestEvalExtendsErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"MATERIAL a EXTENDS b = {}", "undefined identifier: b"},
+		{"MATERIAL a EXTENDS a = {}", "dependency cycle: a -> a"},
+		{"SPHERE a = {radius: 1}\nLIGHT b EXTENDS a = {}", "LIGHT b cannot extend SPHERE a"},
+		{"NUMBER a = 1\nNUMBER b EXTENDS a = {}", "only properties can be extended, a is NUMBER"},
+		{"SPHERE a = {radius: 1}\nSPHERE b EXTENDS a = 2", "only properties can extend a, got NUMBER"},
+	}
+
+	for _, tt := range tests {
+		evaluator := NewEvaluator()
+		evaluated := testEval(evaluator, tt.input)
+		err, ok := evaluated.(Error)
+		if !ok {
+			t.Errorf("expected error for %q. got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+
+		if err.Message != tt.expected {
+			t.Errorf("wrong error message for %q. expected=%q, got=%q", tt.input, tt.expected, err.Message)
+		}
+	}
+}
+
+func TestExtendsDoesNotModifyParent(t *testing.T) {
+	input := `
+SPHERE template = {radius: 1, material: {diffuseIntensity: 0.5}}
+SPHERE big EXTENDS template = {radius: 10, material: {diffuseIntensity: 0.9}}
+`
+	evaluator := NewEvaluator()
+	evaluated := testEval(evaluator, input)
+	if isError(evaluated) {
+		t.Fatalf("error: %v", evaluated)
+	}
+
+	template := evaluator.env.store["template"].Value.(*Dictionary)
+	testNumberObject(t, template.Properties["radius"], 1)
+	testNumberObject(t, template.Properties["material"].(*Dictionary).Properties["diffuseIntensity"], 0.5)
+}
+
+func TestEvalMemberAndIndexExpressions(t *testing.T) {
+	definitions := `
+NUMBER n = 2
+COLOR pos = [1, 2, 3]
+SPHERE sphere1 = {radius: 1.5, position: pos, material: {diffuseIntensity: 0.7}}
+`
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"sphere1.radius", 1.5},
+		{"sphere1.radius * 2", 3.0},
+		{"sphere1.material.diffuseIntensity", 0.7},
+		{"sphere1.position.z", 3.0},
+		{"pos[0]", 1.0},
+		{"pos[n]", 3.0},
+		{"pos[n - 1]", 2.0},
+		{"pos.y", 2.0},
+		{"pos.g", 2.0},
+		{"NUMBER m = -pos.x", -1.0},
+		{"pos.xz", []float64{1, 3}},
+		{"pos.zyx", []float64{3, 2, 1}},
+	}
+
+	for _, tt := range tests {
+		evaluator := NewEvaluator()
+		evaluated := testEval(evaluator, definitions+tt.input)
+		if isError(evaluated) {
+			t.Errorf("error for %q: %v", tt.input, evaluated)
+			continue
+		}
+
+		switch expected := tt.expected.(type) {
+		case float64:
+			testNumberObject(t, evaluated, expected)
+		case []float64:
+			testArrayObject(t, evaluated, expected)
+		}
+	}
+
+	evaluated := testEval(NewEvaluator(), "[4, 5, 6].bg")
+	testArrayObject(t, evaluated, []float64{6, 5})
+}
+
+func TestEvalMemberAndIndexExpressionErrors(t *testing.T) {
+	definitions := `
+NUMBER n = 2
+COLOR pos = [1, 2, 3]
+SPHERE sphere1 = {radius: 1.5, position: pos}
+`
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"sphere1.color", `undefined property "color" of sphere1 (available: radius, position)`},
+		{"sphere1.position.w", `component 'w' in swizzle "w" out of range for array of length 3`},
+		{"pos.xg", `invalid component 'g' in swizzle "xg" of pos`},
+		{"pos.length", `undefined property "length" of pos (arrays only support swizzles like x, xy, rgb)`},
+		{"pos.xyzwx", `swizzle "xyzwx" of pos is longer than 4 components`},
+		{"n.x", `cannot access property "x" of NUMBER`},
+		{"n[0]", "index operator not supported: NUMBER"},
+		{"pos[3]", "index 3 out of range for array of length 3"},
+		{"pos[-1]", "index -1 out of range for array of length 3"},
+		{"pos[0.5]", "array index must be an integer, got: 0.5"},
+		{"pos[pos]", "array index must be a NUMBER, got: ARRAY"},
+	}
+
+	for _, tt := range tests {
+		evaluator := NewEvaluator()
+		evaluated := testEval(evaluator, definitions+tt.input)
+		err, ok := evaluated.(Error)
+		if !ok {
+			t.Errorf("expected error for %q. got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+
+		if err.Message != tt.expected {
+			t.Errorf("wrong error message for %q. expected=%q, got=%q", tt.input, tt.expected, err.Message)
+		}
+	}
+}
+
+func TestEvalForwardReferences(t *testing.T) {
+	input := `
+SPHERE sphere1 = {material: shiny, radius: r * 2}
+MATERIAL shiny EXTENDS base = {color: red}
+MATERIAL base = {diffuseIntensity: 0.7, color: white}
+NUMBER r = 1.5
+COLOR red = [1, 0, 0]
+COLOR white = [1, 1, 1]
+`
+	evaluator := NewEvaluator()
+	evaluated := testEval(evaluator, input)
+	if isError(evaluated) {
+		t.Fatalf("error: %v", evaluated)
+	}
+
+	red := &Array{Elements: []Object{&Number{Value: 1}, &Number{Value: 0}, &Number{Value: 0}}}
+	shiny := &Dictionary{Keys: []string{"diffuseIntensity", "color"}, Properties: map[string]Object{
+		"diffuseIntensity": &Number{Value: 0.7},
+		"color":            red,
+	}}
+	expected := map[string]Entity{
+		"sphere1": {Class: "SPHERE", Value: &Dictionary{Keys: []string{"material", "radius"}, Properties: map[string]Object{
+			"material": shiny,
+			"radius":   &Number{Value: 3},
+		}}},
+		"shiny": {Class: "MATERIAL", Value: shiny},
+	}
+
+	testEnvironmentObject(t, *evaluator.env, expected)
+
+	// The value of a file is the value of its last statement, even if it was evaluated earlier.
+	testArrayObject(t, evaluated, []float64{1, 1, 1})
+
+	exported := evaluator.ExportValues()
+	if len(exported.Entities["SPHERE"]) != 1 || len(exported.Entities["MATERIAL"]) != 2 {
+		t.Errorf("wrong exported entities: %v", exported.Entities)
+	}
+}
+
+func TestEvalDependencyErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"NUMBER a = b\nNUMBER b = c\nNUMBER c = a", "dependency cycle: a -> b -> c -> a"},
+		{"NUMBER x = 1\nNUMBER a = x + b\nNUMBER b = [a].x", "dependency cycle: a -> b -> a"},
+		{"MATERIAL a EXTENDS b = {}\nMATERIAL b EXTENDS a = {}", "dependency cycle: a -> b -> a"},
+		{"NUMBER a = 1\nNUMBER a = 2", "redefining objects is not allowed: a"},
+		{"NUMBER a = b", "undefined identifier: b"},
+	}
+
+	for _, tt := range tests {
+		evaluator := NewEvaluator()
+		evaluated := testEval(evaluator, tt.input)
+		err, ok := evaluated.(Error)
+		if !ok {
+			t.Errorf("expected error for %q. got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+
+		if err.Message != tt.expected {
+			t.Errorf("wrong error message for %q. expected=%q, got=%q", tt.input, tt.expected, err.Message)
+		}
+	}
+}
+
+func TestEvalMemberAccessIsNotAReference(t *testing.T) {
+	input := `
+NUMBER radius = s.radius * 2
+SPHERE s = {radius: 1}
+`
+	evaluator := NewEvaluator()
+	evaluated := testEval(evaluator, input)
+	if isError(evaluated) {
+		t.Fatalf("error: %v", evaluated)
+	}
+
+	testNumberObject(t, evaluator.env.store["radius"].Value, 2)
+}
+
+func TestEvaluatePathWithIncludes(t *testing.T) {
+	evaluator := NewEvaluator()
+	if err := evaluator.EvaluatePath("testdata/scene.sdl"); err != nil {
+		t.Fatalf("error: %v", err)
+	}
+
+	sphere, ok := evaluator.env.store["sphere1"]
+	if !ok {
+		t.Fatalf("sphere1 not found in environment: %v", evaluator.env.store)
+	}
+
+	material := sphere.Value.(*Dictionary).Properties["material"].(*Dictionary)
+	testArrayObject(t, material.Properties["color"], []float64{1, 0, 0})
+
+	if _, ok := evaluator.env.store["white"]; !ok {
+		t.Errorf("white from a nested include not found in environment")
+	}
+}
+
+func TestFiles(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected []string
+	}{
+		{"testdata/scene.sdl", []string{"scene.sdl", "materials/materials.sdl", "materials/colors.sdl"}},
+		{"testdata/missing_include.sdl", []string{"missing_include.sdl", "does_not_exist.sdl"}},
+	}
+
+	for _, tt := range tests {
+		evaluator := NewEvaluator()
+		evaluator.EvaluatePath(tt.path)
+
+		var expected []string
+		for _, name := range tt.expected {
+			path, err := filepath.Abs(filepath.Join("testdata", name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected = append(expected, path)
+		}
+
+		if got := evaluator.Files(); !reflect.DeepEqual(got, expected) {
+			t.Errorf("wrong files read for %s.\ngot=%v\nwant=%v", tt.path, got, expected)
+		}
+	}
+}
+
+func TestEvaluatePathIncludeErrors(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"testdata/cycle_a.sdl", "include cycle: "},
+		{"testdata/missing_include.sdl", "cannot include"},
+	}
+
+	for _, tt := range tests {
+		evaluator := NewEvaluator()
+		err := evaluator.EvaluatePath(tt.path)
+		if err == nil {
+			t.Errorf("expected error for %s", tt.path)
+			continue
+		}
+
+		if !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("wrong error for %s. expected to contain %q, got=%q", tt.path, tt.expected, err.Error())
+		}
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"NUMBER a = 1\nNUMBER b = a + c", "2:16: undefined identifier: c"},
+		{"NUMBER a = 1\nNUMBER a = 2", "2:1: redefining objects is not allowed: a"},
+		{"SPHERE s = {\n  radius: 1 +\n}", "3:1: no prefix parse function for } found"},
+	}
+
+	for _, tt := range tests {
+		evaluator := NewEvaluator()
+		err := evaluator.EvaluateFile(strings.NewReader(tt.input))
+
+		var diagnostics Diagnostics
+		if !errors.As(err, &diagnostics) {
+			t.Errorf("expected Diagnostics for %q. got=%T (%v)", tt.input, err, err)
+			continue
+		}
+		if diagnostics[0].String() != tt.expected {
+			t.Errorf("wrong diagnostic for %q. got=%q, want=%q", tt.input, diagnostics[0], tt.expected)
+		}
+	}
+
+	evaluator := NewEvaluator()
+	err := evaluator.EvaluatePath("testdata/missing_include.sdl")
+	if !strings.HasPrefix(err.Error(), "testdata/missing_include.sdl:1:1: cannot include") {
+		t.Errorf("diagnostic should start with the file and position. got=%q", err)
+	}
+}
+
+func TestEvalModifyStatement(t *testing.T) {
+	input := `
+MODIFY CAMERA {position: [0, 1.5, -10], focalDistance: 35}
+MODIFY CAMERA {focalDistance: 50}
+SPHERE sphere1 = {radius: 1, material: {diffuseIntensity: 0.5, specularIntensity: 1}}
+MODIFY sphere1 {radius: sphere1.radius * 2, material: {diffuseIntensity: 0.7}}
+NUMBER height = CAMERA.position.y
+`
+	evaluator := NewEvaluator()
+	evaluated := testEval(evaluator, input)
+	if isError(evaluated) {
+		t.Fatalf("error: %v", evaluated)
+	}
+
+	expected := map[string]Entity{
+		"CAMERA": {Class: "CAMERA", Value: &Dictionary{Keys: []string{"position", "focalDistance"}, Properties: map[string]Object{
+			"position":      &Array{Elements: []Object{&Number{Value: 0}, &Number{Value: 1.5}, &Number{Value: -10}}},
+			"focalDistance": &Number{Value: 50},
+		}}},
+		"sphere1": {Class: "SPHERE", Value: &Dictionary{Keys: []string{"radius", "material"}, Properties: map[string]Object{
+			"radius": &Number{Value: 2},
+			"material": &Dictionary{Keys: []string{"diffuseIntensity", "specularIntensity"}, Properties: map[string]Object{
+				"diffuseIntensity":  &Number{Value: 0.7},
+				"specularIntensity": &Number{Value: 1},
+			}},
+		}}},
+		"height": {Class: "NUMBER", Value: &Number{Value: 1.5}},
+	}
+
+	testEnvironmentObject(t, *evaluator.env, expected)
+}
+
+func TestEvalModifyStatementErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"MODIFY sphere1 {radius: 1}", "undefined identifier: sphere1"},
+		{"NUMBER n = 1\nMODIFY n {radius: 1}", "only properties can be modified, n is NUMBER"},
+		{"SPHERE s = {}\nMODIFY s 1", "only properties can modify s, got NUMBER"},
+		{"GROUP a = {children: []}\nGROUP b = {children: [a]}\nMODIFY a {children: [b]}", "dependency cycle: b -> MODIFY a -> b"},
+	}
+
+	for _, tt := range tests {
+		evaluator := NewEvaluator()
+		evaluated := testEval(evaluator, tt.input)
+		err, ok := evaluated.(Error)
+		if !ok {
+			t.Errorf("expected error for %q. got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+
+		if err.Message != tt.expected {
+			t.Errorf("wrong error message for %q. expected=%q, got=%q", tt.input, tt.expected, err.Message)
+		}
+	}
+}
+
+func TestEvalAt(t *testing.T) {
+	input := `
+LIGHT base = {diffuseIntensity: 0.7}
+LIGHT light1 EXTENDS base AT [0, 1.5, 0] = {}
+LIGHT light2 AT light1.position = base
+`
+	evaluator := NewEvaluator()
+	evaluated := testEval(evaluator, input)
+	if isError(evaluated) {
+		t.Fatalf("error: %v", evaluated)
+	}
+
+	position := &Array{Elements: []Object{&Number{Value: 0}, &Number{Value: 1.5}, &Number{Value: 0}}}
+	expected := map[string]Entity{
+		"base": {Class: "LIGHT", Value: &Dictionary{Keys: []string{"diffuseIntensity"}, Properties: map[string]Object{
+			"diffuseIntensity": &Number{Value: 0.7},
+		}}},
+		"light1": {Class: "LIGHT", Value: &Dictionary{Keys: []string{"diffuseIntensity", "position"}, Properties: map[string]Object{
+			"diffuseIntensity": &Number{Value: 0.7},
+			"position":         position,
+		}}},
+		"light2": {Class: "LIGHT", Value: &Dictionary{Keys: []string{"diffuseIntensity", "position"}, Properties: map[string]Object{
+			"diffuseIntensity": &Number{Value: 0.7},
+			"position":         position,
+		}}},
+	}
+
+	testEnvironmentObject(t, *evaluator.env, expected)
+
+	evaluated = testEval(NewEvaluator(), "LIGHT l AT [0, 0, 0] = {position: [1, 1, 1]}")
+	err, ok := evaluated.(Error)
+	if !ok || err.Message != "position of l is given both with AT and as a property" {
+		t.Errorf("expected an error for two positions. got=%+v", evaluated)
+	}
+}
+
+func TestEvalStringLiteral(t *testing.T) {
+	evaluated := testEval(NewEvaluator(), `"XYZ"`)
+	str, ok := evaluated.(*String)
+	if !ok {
+		t.Fatalf("object is not String. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	if str.Value != "XYZ" {
+		t.Errorf("object has wrong value. got=%q, want=%q", str.Value, "XYZ")
+	}
+}
+
+func TestInspect(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1.5", "1.5"},
+		{"-2", "-2"},
+		{`"name"`, `"name"`},
+		{"[1, [2, 3]]", "[1, [2, 3]]"},
+		{"{}", "{}"},
+		{"{b: 1, a: {c: [1, 2]}}", "{\n    b: 1,\n    a: {\n        c: [1, 2],\n    },\n}"},
+		{"MATERIAL m = {color: [1, 0, 0]}\nSPHERE s = {radius: 1, material: m}", "{\n    radius: 1,\n    material: {\n        color: [1, 0, 0],\n    },\n}"},
+		{"SPHERE a = {radius: 1}\nGROUP g = {children: [a]}", "{\n    children: [a],\n}"},
+		{"unknown", "error: 1:1: undefined identifier: unknown"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(NewEvaluator(), tt.input)
+		if got := evaluated.Inspect(); got != tt.expected {
+			t.Errorf("wrong result of inspecting %q.\ngot=%q\nwant=%q", tt.input, got, tt.expected)
+		}
+	}
+
+	entity := Entity{Name: "r", Class: "NUMBER", Value: &Number{Value: 2}}
+	if got := entity.Inspect(); got != "NUMBER r = 2" {
+		t.Errorf("wrong entity. got=%q", got)
+	}
+}
@@ -0,0 +1,112 @@
+package evaluator
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kacperkrolak/scene-description-language/ast"
+)
+
+// sourceStatement is a statement together with the name of the file it comes from.
+type sourceStatement struct {
+	ast.Statement
+	file string
+}
+
+func sourceStatements(file *ast.File, name string) []sourceStatement {
+	statements := make([]sourceStatement, len(file.Statements))
+	for i, statement := range file.Statements {
+		statements[i] = sourceStatement{Statement: statement, file: name}
+	}
+	return statements
+}
+
+// expandIncludes replaces INCLUDE statements with the statements of the included files.
+// Every file is included at most once, so several files can share the same include,
+// but a file including itself, directly or not, is an error.
+func (evaluator *Evaluator) expandIncludes(statements []sourceStatement, dir string, stack []string) ([]sourceStatement, *Error) {
+	var expanded []sourceStatement
+	for _, statement := range statements {
+		include, ok := statement.Statement.(*ast.IncludeStatement)
+		if !ok {
+			expanded = append(expanded, statement)
+			continue
+		}
+
+		includeError := func(format string, a ...interface{}) *Error {
+			return &Error{Message: fmt.Sprintf(format, a...), File: statement.file, Pos: include.Pos()}
+		}
+
+		path := include.Path.Value
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return nil, includeError("cannot include %q: %s", include.Path.Value, err)
+		}
+
+		for i, included := range stack {
+			if included == absPath {
+				cycle := append(append([]string{}, stack[i:]...), absPath)
+				return nil, includeError("include cycle: %s", strings.Join(cycle, " -> "))
+			}
+		}
+
+		if evaluator.included[absPath] {
+			continue
+		}
+
+		file, _, err := evaluator.loadFile(path)
+		var diagnostics Diagnostics
+		if errors.As(err, &diagnostics) {
+			first := diagnostics[0]
+			if len(diagnostics) > 1 {
+				first.Message += fmt.Sprintf(" (and %d more errors)", len(diagnostics)-1)
+			}
+			return nil, &Error{Message: first.Message, File: first.File, Pos: first.Pos}
+		}
+		if err != nil {
+			return nil, includeError("cannot include %q: %s", include.Path.Value, err)
+		}
+
+		includedStatements, includeErr := evaluator.expandIncludes(sourceStatements(file, path), filepath.Dir(path), append(stack, absPath))
+		if includeErr != nil {
+			return nil, includeErr
+		}
+
+		expanded = append(expanded, includedStatements...)
+	}
+
+	return expanded, nil
+}
+
+// loadFile parses the file at the given path and marks it as included.
+// It returns the AST and the absolute path of the file.
+// Syntax errors are returned as Diagnostics.
+func (evaluator *Evaluator) loadFile(path string) (*ast.File, string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("cannot resolve path %q: %w", path, err)
+	}
+	evaluator.files = append(evaluator.files, absPath)
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, "", err
+	}
+	defer f.Close()
+
+	fileAst, err := getAst(f, path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	evaluator.included[absPath] = true
+
+	return fileAst, absPath, nil
+}
@@ -0,0 +1,57 @@
+package evaluator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Objects are inspected in the canonical layout of the formatter,
+// so values can be shown next to the source they come from.
+
+const inspectIndentation = "    "
+
+func (n Number) Inspect() string {
+	return strconv.FormatFloat(n.Value, 'f', -1, 64)
+}
+
+func (s String) Inspect() string {
+	return `"` + s.Value + `"`
+}
+
+func (a Array) Inspect() string {
+	elements := make([]string, len(a.Elements))
+	for i, element := range a.Elements {
+		elements[i] = element.Inspect()
+	}
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+
+// Inspect writes one property per line, in the order they were defined.
+func (d Dictionary) Inspect() string {
+	if len(d.Keys) == 0 {
+		return "{}"
+	}
+
+	var out strings.Builder
+	out.WriteString("{\n")
+	for _, key := range d.Keys {
+		value := strings.ReplaceAll(d.Properties[key].Inspect(), "\n", "\n"+inspectIndentation)
+		fmt.Fprintf(&out, "%s%s: %s,\n", inspectIndentation, key, value)
+	}
+	out.WriteString("}")
+	return out.String()
+}
+
+// Inspect writes the entity as the statement defining it.
+func (e Entity) Inspect() string {
+	return fmt.Sprintf("%s %s = %s", e.Class, e.Name, e.Value.Inspect())
+}
+
+func (r Reference) Inspect() string {
+	return r.Name
+}
+
+func (e Error) Inspect() string {
+	return "error: " + e.diagnostic().String()
+}
@@ -0,0 +1,64 @@
+package evaluator
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// The objects are marshaled to their plain JSON counterparts,
+// so evaluated values can be inspected by other tools.
+
+func (n Number) MarshalJSON() ([]byte, error) {
+	return json.Marshal(n.Value)
+}
+
+func (s String) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.Value)
+}
+
+func (a Array) MarshalJSON() ([]byte, error) {
+	elements := a.Elements
+	if elements == nil {
+		elements = []Object{}
+	}
+	return json.Marshal(elements)
+}
+
+// MarshalJSON writes the properties in the order they were defined.
+func (d Dictionary) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range d.Keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		name, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(d.Properties[key])
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// MarshalJSON writes a reference as {"ref": "name"}, to tell it apart from a string.
+func (r Reference) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]string{"ref": r.Name})
+}
+
+func (e Entity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Name  string `json:"name"`
+		Class string `json:"class"`
+		Value Object `json:"value"`
+	}{e.Name, e.Class, e.Value})
+}
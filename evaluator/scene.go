@@ -0,0 +1,303 @@
+package evaluator
+
+import (
+	"fmt"
+
+	"github.com/kacperkrolak/scene-description-language/token"
+	"github.com/kacperkrolak/scene-description-language/transform"
+)
+
+// spatialClasses lists the object classes which are placed in the scene graph.
+var spatialClasses = map[string]bool{
+	token.CAMERA: true,
+	token.SPHERE: true,
+	token.LIGHT:  true,
+	token.GROUP:  true,
+}
+
+// referenceProperties lists the properties, per object class, which hold
+// names of other objects rather than their values.
+var referenceProperties = map[string]map[string]bool{
+	token.GROUP: {"children": true},
+}
+
+// SceneNode is an entity placed in the scene graph.
+type SceneNode struct {
+	Entity   Entity
+	Local    transform.Mat4 // Transform relative to the parent node.
+	World    transform.Mat4 // Transform relative to the origin of the scene.
+	Children []*SceneNode
+}
+
+// placeEntity checks the transform of a spatial entity and,
+// for a GROUP, makes it the parent of its children.
+func (evaluator *Evaluator) placeEntity(entity Entity) Object {
+	if !spatialClasses[entity.Class] {
+		return nil
+	}
+
+	if _, err := localTransform(entity.Value); err != nil {
+		return Error{Message: fmt.Sprintf("invalid transform of %s: %s", entity.Name, err)}
+	}
+
+	if entity.Class != token.GROUP {
+		return nil
+	}
+
+	for _, child := range children(entity.Value) {
+		childEntity := evaluator.env.store[child.Name]
+		if !spatialClasses[childEntity.Class] {
+			return Error{Message: fmt.Sprintf("%s %s cannot contain %s %s", entity.Class, entity.Name, childEntity.Class, child.Name)}
+		}
+
+		if group, ok := evaluator.env.groups[child.Name]; ok && group != entity.Name {
+			return Error{Message: fmt.Sprintf("%s is already a child of %s", child.Name, group)}
+		}
+	}
+
+	// A modified group may no longer contain some of its children.
+	for child, group := range evaluator.env.groups {
+		if group == entity.Name {
+			delete(evaluator.env.groups, child)
+		}
+	}
+
+	for _, child := range children(entity.Value) {
+		evaluator.env.groups[child.Name] = entity.Name
+	}
+
+	return nil
+}
+
+// children returns the references listed in the children property of a GROUP.
+func children(value Object) []*Reference {
+	properties, ok := value.(*Dictionary)
+	if !ok {
+		return nil
+	}
+
+	array, ok := properties.Properties["children"].(*Array)
+	if !ok {
+		return nil
+	}
+
+	var references []*Reference
+	for _, element := range array.Elements {
+		if reference, ok := element.(*Reference); ok {
+			references = append(references, reference)
+		}
+	}
+
+	return references
+}
+
+// buildSceneGraph links the spatial entities into a tree and computes their world transforms.
+// It returns the top-level nodes and all nodes in depth-first order.
+func (evaluator *Evaluator) buildSceneGraph() ([]*SceneNode, []*SceneNode) {
+	nodesByName := make(map[string]*SceneNode)
+	for _, name := range evaluator.env.names {
+		entity := evaluator.env.store[name]
+		if !spatialClasses[entity.Class] {
+			continue
+		}
+
+		// The transform was validated when the entity was evaluated.
+		local, _ := localTransform(entity.Value)
+		nodesByName[name] = &SceneNode{Entity: entity, Local: local}
+	}
+
+	var roots []*SceneNode
+	for _, name := range evaluator.env.names {
+		node, ok := nodesByName[name]
+		if !ok {
+			continue
+		}
+
+		for _, child := range children(node.Entity.Value) {
+			node.Children = append(node.Children, nodesByName[child.Name])
+		}
+
+		if _, ok := evaluator.env.groups[name]; !ok {
+			roots = append(roots, node)
+		}
+	}
+
+	var nodes []*SceneNode
+	var place func(node *SceneNode, parent transform.Mat4)
+	place = func(node *SceneNode, parent transform.Mat4) {
+		node.World = parent.Mul(node.Local)
+		nodes = append(nodes, node)
+		for _, child := range node.Children {
+			place(child, node.World)
+		}
+	}
+
+	for _, root := range roots {
+		place(root, transform.Identity())
+	}
+
+	return roots, nodes
+}
+
+// localTransform computes the transform of an entity from its properties:
+//   - position: [x, y, z]
+//   - rotation: Euler angles [x, y, z] in degrees applied in rotationOrder ("XYZ" by default),
+//     or a quaternion [x, y, z, w]
+//   - scale: a number or [x, y, z]
+//   - matrix: a 4x4 affine matrix given as 4 rows or 16 numbers,
+//     used instead of the properties above
+func localTransform(value Object) (transform.Mat4, error) {
+	properties, ok := value.(*Dictionary)
+	if !ok {
+		return transform.Identity(), nil
+	}
+
+	if obj, ok := properties.Properties["matrix"]; ok {
+		for _, key := range []string{"position", "rotation", "rotationOrder", "scale"} {
+			if _, ok := properties.Properties[key]; ok {
+				return transform.Mat4{}, fmt.Errorf("matrix cannot be combined with %s", key)
+			}
+		}
+
+		matrix, err := toMat4(obj)
+		if err != nil {
+			return transform.Mat4{}, fmt.Errorf("matrix: %w", err)
+		}
+		return matrix, nil
+	}
+
+	position := transform.Vec3{}
+	if obj, ok := properties.Properties["position"]; ok {
+		vector, err := toVec3(obj)
+		if err != nil {
+			return transform.Mat4{}, fmt.Errorf("position: %w", err)
+		}
+		position = vector
+	}
+
+	rotation, err := rotationTransform(properties)
+	if err != nil {
+		return transform.Mat4{}, err
+	}
+
+	scale := transform.Vec3{X: 1, Y: 1, Z: 1}
+	if obj, ok := properties.Properties["scale"]; ok {
+		if number, ok := obj.(*Number); ok {
+			scale = transform.Vec3{X: number.Value, Y: number.Value, Z: number.Value}
+		} else {
+			vector, err := toVec3(obj)
+			if err != nil {
+				return transform.Mat4{}, fmt.Errorf("scale: %w", err)
+			}
+			scale = vector
+		}
+	}
+
+	return transform.Compose(position, rotation, scale), nil
+}
+
+func rotationTransform(properties *Dictionary) (transform.Mat4, error) {
+	order := transform.XYZ
+	orderObj, hasOrder := properties.Properties["rotationOrder"]
+	if hasOrder {
+		name, ok := orderObj.(*String)
+		if !ok {
+			return transform.Mat4{}, fmt.Errorf("rotationOrder: expected a STRING, got: %s", orderObj.Type())
+		}
+
+		parsed, err := transform.ParseEulerOrder(name.Value)
+		if err != nil {
+			return transform.Mat4{}, fmt.Errorf("rotationOrder: %w", err)
+		}
+		order = parsed
+	}
+
+	obj, ok := properties.Properties["rotation"]
+	if !ok {
+		return transform.Identity(), nil
+	}
+
+	if array, ok := obj.(*Array); ok && len(array.Elements) == 4 {
+		if hasOrder {
+			return transform.Mat4{}, fmt.Errorf("rotationOrder cannot be used with a quaternion rotation")
+		}
+
+		values, err := toNumbers(obj, 4)
+		if err != nil {
+			return transform.Mat4{}, fmt.Errorf("rotation: %w", err)
+		}
+
+		quaternion := transform.Quat{X: values[0], Y: values[1], Z: values[2], W: values[3]}
+		if quaternion.Length() == 0 {
+			return transform.Mat4{}, fmt.Errorf("rotation: quaternion must not be zero")
+		}
+		return quaternion.Normalize().Mat4(), nil
+	}
+
+	angles, err := toVec3(obj)
+	if err != nil {
+		return transform.Mat4{}, fmt.Errorf("rotation: %w", err)
+	}
+
+	return transform.EulerInOrder(angles, order), nil
+}
+
+// toVec3 converts an array of three numbers into a vector.
+func toVec3(obj Object) (transform.Vec3, error) {
+	values, err := toNumbers(obj, 3)
+	if err != nil {
+		return transform.Vec3{}, err
+	}
+
+	return transform.Vec3{X: values[0], Y: values[1], Z: values[2]}, nil
+}
+
+// toMat4 converts an array of 4 rows of 4 numbers, or of 16 numbers, into an affine matrix.
+func toMat4(obj Object) (transform.Mat4, error) {
+	var values []float64
+	if array, ok := obj.(*Array); ok && len(array.Elements) == 4 {
+		for _, row := range array.Elements {
+			rowValues, err := toNumbers(row, 4)
+			if err != nil {
+				return transform.Mat4{}, err
+			}
+			values = append(values, rowValues...)
+		}
+	} else {
+		numbers, err := toNumbers(obj, 16)
+		if err != nil {
+			return transform.Mat4{}, fmt.Errorf("expected 4 arrays of 4 numbers or an array of 16 numbers")
+		}
+		values = numbers
+	}
+
+	var matrix transform.Mat4
+	for i := range values {
+		matrix[i/4][i%4] = values[i]
+	}
+
+	if !matrix.IsAffine() {
+		return transform.Mat4{}, fmt.Errorf("the last row must be [0, 0, 0, 1]")
+	}
+
+	return matrix, nil
+}
+
+// toNumbers converts an array of n numbers into a slice.
+func toNumbers(obj Object, n int) ([]float64, error) {
+	array, ok := obj.(*Array)
+	if !ok || len(array.Elements) != n {
+		return nil, fmt.Errorf("expected an array of %d numbers, got: %s", n, obj.Type())
+	}
+
+	values := make([]float64, n)
+	for i, element := range array.Elements {
+		number, ok := element.(*Number)
+		if !ok {
+			return nil, fmt.Errorf("expected an array of %d numbers, got %s at index %d", n, element.Type(), i)
+		}
+		values[i] = number.Value
+	}
+
+	return values, nil
+}
@@ -0,0 +1,262 @@
+package evaluator
+
+import (
+	"math"
+	"testing"
+
+	"github.com/kacperkrolak/scene-description-language/transform"
+)
+
+func testVec3(t *testing.T, name string, got transform.Vec3, expected transform.Vec3) bool {
+	const epsilon = 1e-9
+	if math.Abs(got.X-expected.X) > epsilon || math.Abs(got.Y-expected.Y) > epsilon || math.Abs(got.Z-expected.Z) > epsilon {
+		t.Errorf("%s has wrong value. got=%v, want=%v", name, got, expected)
+		return false
+	}
+	return true
+}
+
+func TestSceneGraph(t *testing.T) {
+	input := `
+GROUP table = {
+	children: [top, legs],
+	position: [10, 0, 0],
+	rotation: [0, 0, 90],
+}
+GROUP legs = {children: [leg1, leg2], scale: 2}
+SPHERE top = {radius: 1, position: [0, 1, 0]}
+SPHERE leg1 = {radius: 0.1, position: [1, 0, 0]}
+SPHERE leg2 = {radius: 0.1, position: [-1, 0, 0]}
+SPHERE ball = {radius: 0.5}
+NUMBER notPlaced = 1
+`
+	evaluator := NewEvaluator()
+	evaluated := testEval(evaluator, input)
+	if isError(evaluated) {
+		t.Fatalf("error: %v", evaluated)
+	}
+
+	values := evaluator.ExportValues()
+
+	var roots []string
+	for _, root := range values.Roots {
+		roots = append(roots, root.Entity.Name)
+	}
+	if len(roots) != 2 || roots[0] != "table" || roots[1] != "ball" {
+		t.Errorf("wrong roots. got=%v", roots)
+	}
+
+	var nodes []string
+	positions := make(map[string]transform.Vec3)
+	for _, node := range values.Nodes {
+		nodes = append(nodes, node.Entity.Name)
+		positions[node.Entity.Name] = node.World.Position()
+	}
+
+	expectedNodes := []string{"table", "top", "legs", "leg1", "leg2", "ball"}
+	if len(nodes) != len(expectedNodes) {
+		t.Fatalf("wrong nodes. got=%v, want=%v", nodes, expectedNodes)
+	}
+	for i := range expectedNodes {
+		if nodes[i] != expectedNodes[i] {
+			t.Fatalf("wrong nodes. got=%v, want=%v", nodes, expectedNodes)
+		}
+	}
+
+	// The table is rotated by 90 degrees around Z, so its local X axis points up.
+	testVec3(t, "table", positions["table"], transform.Vec3{X: 10})
+	testVec3(t, "top", positions["top"], transform.Vec3{X: 9})
+	testVec3(t, "leg1", positions["leg1"], transform.Vec3{X: 10, Y: 2})
+	testVec3(t, "leg2", positions["leg2"], transform.Vec3{X: 10, Y: -2})
+	testVec3(t, "ball", positions["ball"], transform.Vec3{})
+
+	table := values.Roots[0]
+	if len(table.Children) != 2 || table.Children[1].Entity.Name != "legs" || len(table.Children[1].Children) != 2 {
+		t.Errorf("wrong hierarchy of table: %+v", table.Children)
+	}
+
+	testVec3(t, "local position of leg1", table.Children[1].Children[0].Local.Position(), transform.Vec3{X: 1})
+}
+
+func TestGroupExtendsGroup(t *testing.T) {
+	input := `
+GROUP a = {children: [s], position: [1, 0, 0]}
+GROUP b EXTENDS a = {children: [t]}
+GROUP c EXTENDS a = {}
+SPHERE s = {radius: 1}
+SPHERE t = {radius: 1}
+`
+	evaluator := NewEvaluator()
+	evaluated := testEval(evaluator, input)
+	if isError(evaluated) {
+		t.Fatalf("error: %v", evaluated)
+	}
+
+	children := make(map[string][]string)
+	positions := make(map[string]transform.Vec3)
+	for _, node := range evaluator.ExportValues().Nodes {
+		positions[node.Entity.Name] = node.World.Position()
+		for _, child := range node.Children {
+			children[node.Entity.Name] = append(children[node.Entity.Name], child.Entity.Name)
+		}
+	}
+
+	// The groups inherit the position of a, but not its children.
+	if len(children["a"]) != 1 || children["a"][0] != "s" || len(children["b"]) != 1 || children["b"][0] != "t" || len(children["c"]) != 0 {
+		t.Errorf("wrong children. got=%v", children)
+	}
+	testVec3(t, "b", positions["b"], transform.Vec3{X: 1})
+	testVec3(t, "c", positions["c"], transform.Vec3{X: 1})
+	testVec3(t, "t", positions["t"], transform.Vec3{X: 1})
+}
+
+func TestSceneGraphErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"SPHERE s = {radius: 1}\nGROUP a = {children: [s]}\nGROUP b = {children: [s]}",
+			"s is already a child of a",
+		},
+		{
+			"NUMBER n = 1\nGROUP a = {children: [n]}",
+			"GROUP a cannot contain NUMBER n",
+		},
+		{
+			"GROUP a = {children: [b]}\nGROUP b = {children: [a]}",
+			"dependency cycle: a -> b -> a",
+		},
+		{
+			"GROUP a = {children: [1]}",
+			"expected an object name, got: 1",
+		},
+		{
+			"GROUP a = {children: b}\nSPHERE b = {}",
+			"expected an array of object names, got: b",
+		},
+		{
+			"GROUP a = {children: [b]}",
+			"undefined identifier: b",
+		},
+		{
+			"SPHERE s = {position: [1, 2]}",
+			"invalid transform of s: position: expected an array of 3 numbers, got: ARRAY",
+		},
+		{
+			"LIGHT l = {rotation: [1, 2, [3]]}",
+			"invalid transform of l: rotation: expected an array of 3 numbers, got ARRAY at index 2",
+		},
+	}
+
+	for _, tt := range tests {
+		evaluator := NewEvaluator()
+		evaluated := testEval(evaluator, tt.input)
+		err, ok := evaluated.(Error)
+		if !ok {
+			t.Errorf("expected error for %q. got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+
+		if err.Message != tt.expected {
+			t.Errorf("wrong error message for %q. expected=%q, got=%q", tt.input, tt.expected, err.Message)
+		}
+	}
+}
+
+func TestTransformProperties(t *testing.T) {
+	point := transform.Vec3{X: 0, Y: 1, Z: 0}
+	tests := []struct {
+		properties string
+		expected   transform.Vec3
+	}{
+		{"{}", transform.Vec3{Y: 1}},
+		{"{position: [1, 2, 3]}", transform.Vec3{X: 1, Y: 3, Z: 3}},
+		{"{scale: 2}", transform.Vec3{Y: 2}},
+		{"{scale: [1, 3, 1]}", transform.Vec3{Y: 3}},
+		{"{rotation: [90, 90, 0]}", transform.Vec3{X: 1}},
+		{`{rotation: [90, 90, 0], rotationOrder: "YXZ"}`, transform.Vec3{Z: 1}},
+		// 90 degrees around X as a quaternion [x, y, z, w].
+		{"{rotation: [0.7071067811865476, 0, 0, 0.7071067811865476]}", transform.Vec3{Z: 1}},
+		{"{rotation: [2, 0, 0, 2]}", transform.Vec3{Z: 1}},
+		{"{matrix: [[1, 0, 0, 5], [0, 2, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]}", transform.Vec3{X: 5, Y: 2}},
+		{"{matrix: [1, 0, 0, 5, 0, 2, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1]}", transform.Vec3{X: 5, Y: 2}},
+	}
+
+	for _, tt := range tests {
+		evaluator := NewEvaluator()
+		evaluated := testEval(evaluator, "SPHERE s = "+tt.properties)
+		if isError(evaluated) {
+			t.Errorf("error for %s: %v", tt.properties, evaluated)
+			continue
+		}
+
+		nodes := evaluator.ExportValues().Nodes
+		if len(nodes) != 1 {
+			t.Fatalf("wrong number of nodes. got=%d", len(nodes))
+		}
+
+		testVec3(t, tt.properties, nodes[0].World.MulPoint(point), tt.expected)
+	}
+}
+
+func TestTransformPropertiesErrors(t *testing.T) {
+	tests := []struct {
+		properties string
+		expected   string
+	}{
+		{`{rotation: [0, 0, 0], rotationOrder: "XXZ"}`, `rotationOrder: unknown rotation order "XXZ", expected one of XYZ, XZY, YXZ, YZX, ZXY, ZYX`},
+		{`{rotationOrder: 1}`, "rotationOrder: expected a STRING, got: NUMBER"},
+		{`{rotation: [0, 0, 0, 1], rotationOrder: "XYZ"}`, "rotationOrder cannot be used with a quaternion rotation"},
+		{`{rotation: [0, 0, 0, 0]}`, "rotation: quaternion must not be zero"},
+		{`{rotation: [0, 0]}`, "rotation: expected an array of 3 numbers, got: ARRAY"},
+		{`{matrix: [1, 2, 3]}`, "matrix: expected 4 arrays of 4 numbers or an array of 16 numbers"},
+		{`{matrix: [[1, 0, 0, 0], [0, 1, 0, 0], [0, 0, 1, 0], [1, 0, 0, 1]]}`, "matrix: the last row must be [0, 0, 0, 1]"},
+		{`{matrix: [[1, 0, 0, 0], [0, 1, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]], scale: 2}`, "matrix cannot be combined with scale"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(NewEvaluator(), "SPHERE s = "+tt.properties)
+		err, ok := evaluated.(Error)
+		if !ok {
+			t.Errorf("expected error for %s. got=%T (%+v)", tt.properties, evaluated, evaluated)
+			continue
+		}
+
+		expected := "invalid transform of s: " + tt.expected
+		if err.Message != expected {
+			t.Errorf("wrong error message for %s. expected=%q, got=%q", tt.properties, expected, err.Message)
+		}
+	}
+}
+
+func TestModifyPlacesEntities(t *testing.T) {
+	input := `
+MODIFY CAMERA {position: [0, 0, -10]}
+SPHERE a = {position: [1, 0, 0]}
+SPHERE b = {}
+GROUP g = {children: [a], position: [0, 5, 0]}
+MODIFY g {children: [b]}
+MODIFY b {position: [2, 0, 0]}
+`
+	evaluator := NewEvaluator()
+	evaluated := testEval(evaluator, input)
+	if isError(evaluated) {
+		t.Fatalf("error: %v", evaluated)
+	}
+
+	positions := make(map[string]transform.Vec3)
+	for _, node := range evaluator.ExportValues().Nodes {
+		positions[node.Entity.Name] = node.World.Position()
+	}
+
+	testVec3(t, "CAMERA", positions["CAMERA"], transform.Vec3{Z: -10})
+	testVec3(t, "a", positions["a"], transform.Vec3{X: 1})
+	testVec3(t, "b", positions["b"], transform.Vec3{X: 2, Y: 5})
+
+	evaluated = testEval(NewEvaluator(), "SPHERE s = {}\nMODIFY s {position: 1}")
+	err, ok := evaluated.(Error)
+	if !ok || err.Message != "invalid transform of s: position: expected an array of 3 numbers, got: NUMBER" {
+		t.Errorf("expected an error for an invalid position. got=%+v", evaluated)
+	}
+}
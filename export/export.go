@@ -0,0 +1,126 @@
+// Package export writes evaluated scenes in formats read by other tools.
+//
+// JSON and YAML hold the same document: the objects of the scene in the
+// order they were defined, each with its class, its value with references
+// to NUMBER, COLOR and MATERIAL objects replaced by their values, and,
+// for objects placed in the scene, the transform relative to the origin.
+// Members of objects are written in a stable order, so exports of the same
+// scene are identical. The document is described by scene.schema.json,
+// and Import converts it back to SDL.
+package export
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/kacperkrolak/scene-description-language/evaluator"
+	"github.com/kacperkrolak/scene-description-language/transform"
+)
+
+// object is a JSON object which keeps its members in order.
+type object struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func newObject() *object {
+	return &object{values: make(map[string]interface{})}
+}
+
+func (o *object) set(key string, value interface{}) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+// document converts the evaluated values to the tree written by the encoders,
+// made of float64, string, []interface{} and *object values.
+func document(values evaluator.EvaluatedValues) (*object, error) {
+	world := make(map[string]transform.Mat4)
+	for _, node := range values.Nodes {
+		world[node.Entity.Name] = node.World
+	}
+
+	objects := make([]interface{}, 0, len(values.Objects))
+	for _, entity := range values.Objects {
+		value, err := convert(entity.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", entity.Class, entity.Name, err)
+		}
+
+		o := newObject()
+		o.set("name", entity.Name)
+		o.set("class", entity.Class)
+		o.set("value", value)
+		if m, ok := world[entity.Name]; ok {
+			o.set("world", matrix(m))
+		}
+		objects = append(objects, o)
+	}
+
+	doc := newObject()
+	doc.set("objects", objects)
+	return doc, nil
+}
+
+func convert(value evaluator.Object) (interface{}, error) {
+	switch value := value.(type) {
+	case *evaluator.Number:
+		if math.IsNaN(value.Value) || math.IsInf(value.Value, 0) {
+			return nil, fmt.Errorf("cannot export %v", value.Value)
+		}
+		return value.Value, nil
+	case *evaluator.String:
+		return value.Value, nil
+	case *evaluator.Reference:
+		ref := newObject()
+		ref.set("ref", value.Name)
+		return ref, nil
+	case *evaluator.Array:
+		elements := make([]interface{}, len(value.Elements))
+		for i, element := range value.Elements {
+			converted, err := convert(element)
+			if err != nil {
+				return nil, err
+			}
+			elements[i] = converted
+		}
+		return elements, nil
+	case *evaluator.Dictionary:
+		o := newObject()
+		for _, key := range value.Keys {
+			converted, err := convert(value.Properties[key])
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			o.set(key, converted)
+		}
+		return o, nil
+	default:
+		return nil, fmt.Errorf("cannot export %s", value.Type())
+	}
+}
+
+// matrix returns the rows of the matrix.
+func matrix(m transform.Mat4) []interface{} {
+	rows := make([]interface{}, 4)
+	for i, row := range m {
+		rows[i] = []interface{}{row[0], row[1], row[2], row[3]}
+	}
+	return rows
+}
+
+// isName reports whether s has the syntax of an identifier.
+func isName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, ch := range s {
+		letter := ch == '_' || 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z'
+		if !letter && (i == 0 || ch < '0' || ch > '9') {
+			return false
+		}
+	}
+	return true
+}
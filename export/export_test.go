@@ -0,0 +1,279 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kacperkrolak/scene-description-language/evaluator"
+)
+
+var update = flag.Bool("update", false, "rewrite the published schema and the golden files")
+
+func evaluate(t *testing.T, input string) evaluator.EvaluatedValues {
+	t.Helper()
+	e := evaluator.NewEvaluator()
+	if err := e.EvaluateFile(strings.NewReader(input)); err != nil {
+		t.Fatalf("error evaluating: %v", err)
+	}
+	return e.ExportValues()
+}
+
+const input = `MATERIAL red = {color: [1, 0, 0], diffuseIntensity: 0.5}
+SPHERE ball = {radius: 0.0000001, material: red, position: [0, 1, 0]}
+NUMBER on = 2
+GROUP g = {children: [ball], position: [1, 0, 0], rotationOrder: "ZYX"}
+`
+
+func TestJSON(t *testing.T) {
+	var out bytes.Buffer
+	if err := JSON(&out, evaluate(t, input)); err != nil {
+		t.Fatalf("error exporting: %v", err)
+	}
+
+	expected := `{
+  "objects": [
+    {
+      "name": "red",
+      "class": "MATERIAL",
+      "value": {
+        "color": [
+          1,
+          0,
+          0
+        ],
+        "diffuseIntensity": 0.5
+      }
+    },
+    {
+      "name": "ball",
+      "class": "SPHERE",
+      "value": {
+        "radius": 1e-7,
+        "material": {
+          "color": [
+            1,
+            0,
+            0
+          ],
+          "diffuseIntensity": 0.5
+        },
+        "position": [
+          0,
+          1,
+          0
+        ]
+      },
+      "world": [
+        [
+          1,
+          0,
+          0,
+          1
+        ],
+        [
+          0,
+          1,
+          0,
+          1
+        ],
+        [
+          0,
+          0,
+          1,
+          0
+        ],
+        [
+          0,
+          0,
+          0,
+          1
+        ]
+      ]
+    },
+    {
+      "name": "on",
+      "class": "NUMBER",
+      "value": 2
+    },
+    {
+      "name": "g",
+      "class": "GROUP",
+      "value": {
+        "children": [
+          {
+            "ref": "ball"
+          }
+        ],
+        "position": [
+          1,
+          0,
+          0
+        ],
+        "rotationOrder": "ZYX"
+      },
+      "world": [
+        [
+          1,
+          0,
+          0,
+          1
+        ],
+        [
+          0,
+          1,
+          0,
+          0
+        ],
+        [
+          0,
+          0,
+          1,
+          0
+        ],
+        [
+          0,
+          0,
+          0,
+          1
+        ]
+      ]
+    }
+  ]
+}
+`
+	if out.String() != expected {
+		t.Errorf("wrong JSON.\ngot=%s\nwant=%s", out.String(), expected)
+	}
+}
+
+func TestYAML(t *testing.T) {
+	var out bytes.Buffer
+	if err := YAML(&out, evaluate(t, input+`MODIFY CAMERA {fov: 45}
+GROUP empty = {children: []}
+`)); err != nil {
+		t.Fatalf("error exporting: %v", err)
+	}
+
+	expected := `objects:
+  - name: "red"
+    class: "MATERIAL"
+    value:
+      color: [1, 0, 0]
+      diffuseIntensity: 0.5
+  - name: "ball"
+    class: "SPHERE"
+    value:
+      radius: 1.0e-7
+      material:
+        color: [1, 0, 0]
+        diffuseIntensity: 0.5
+      position: [0, 1, 0]
+    world: [[1, 0, 0, 1], [0, 1, 0, 1], [0, 0, 1, 0], [0, 0, 0, 1]]
+  - name: "on"
+    class: "NUMBER"
+    value: 2
+  - name: "g"
+    class: "GROUP"
+    value:
+      children: [{ref: "ball"}]
+      position: [1, 0, 0]
+      rotationOrder: "ZYX"
+    world: [[1, 0, 0, 1], [0, 1, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]
+  - name: "CAMERA"
+    class: "CAMERA"
+    value:
+      fov: 45
+    world: [[1, 0, 0, 0], [0, 1, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]
+  - name: "empty"
+    class: "GROUP"
+    value:
+      children: []
+    world: [[1, 0, 0, 0], [0, 1, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]
+`
+	if out.String() != expected {
+		t.Errorf("wrong YAML.\ngot=%s\nwant=%s", out.String(), expected)
+	}
+}
+
+func TestExportIsStable(t *testing.T) {
+	values := evaluate(t, input)
+	var first, second bytes.Buffer
+	if err := JSON(&first, values); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		second.Reset()
+		if err := JSON(&second, evaluate(t, input)); err != nil {
+			t.Fatal(err)
+		}
+		if second.String() != first.String() {
+			t.Fatalf("exports of the same scene differ.\nfirst=%s\nthen=%s", first.String(), second.String())
+		}
+	}
+}
+
+func TestYAMLKeys(t *testing.T) {
+	tests := map[string]string{
+		"radius": "radius",
+		"_a1":    "_a1",
+		"on":     `"on"`,
+		"No":     `"No"`,
+		"1a":     `"1a"`,
+		"a-b":    `"a-b"`,
+		"":       `""`,
+	}
+	for key, expected := range tests {
+		if got := yamlKey(key); got != expected {
+			t.Errorf("wrong key for %q. got=%s, want=%s", key, got, expected)
+		}
+	}
+}
+
+func TestExportErrors(t *testing.T) {
+	values := evaluator.EvaluatedValues{Objects: []evaluator.Entity{
+		{Name: "n", Class: "NUMBER", Value: &evaluator.Number{Value: math.Inf(1)}},
+	}}
+	if err := JSON(&bytes.Buffer{}, values); err == nil || err.Error() != "NUMBER n: cannot export +Inf" {
+		t.Errorf("wrong error. got=%v", err)
+	}
+}
+
+// TestSchema checks that the published schema is up to date.
+// Run the tests with -update to rewrite it.
+func TestSchema(t *testing.T) {
+	path := filepath.Base(SchemaPath)
+	generated := Schema()
+	if *update {
+		if err := os.WriteFile(path, generated, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	published, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(published, generated) {
+		t.Errorf("%s is out of date, run go test ./export -update", SchemaPath)
+	}
+
+	var root map[string]interface{}
+	if err := json.Unmarshal(generated, &root); err != nil {
+		t.Fatalf("schema is not valid JSON: %v", err)
+	}
+
+	// Every reference points at a definition.
+	defs := root["$defs"].(map[string]interface{})
+	for _, ref := range strings.Split(string(generated), `"$ref": "#/$defs/`)[1:] {
+		name := ref[:strings.IndexByte(ref, '"')]
+		if _, ok := defs[name]; !ok {
+			t.Errorf("undefined reference to %s", name)
+		}
+	}
+}
@@ -0,0 +1,519 @@
+package export
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math"
+
+	"github.com/kacperkrolak/scene-description-language/evaluator"
+	"github.com/kacperkrolak/scene-description-language/scene"
+	"github.com/kacperkrolak/scene-description-language/token"
+	"github.com/kacperkrolak/scene-description-language/transform"
+)
+
+// GLTFOptions controls the conversion of a scene to glTF.
+type GLTFOptions struct {
+	Tessellation Tessellation // Zero uses DefaultTessellation.
+	AspectRatio  float64      // Width divided by height of the camera image, zero to leave it to the viewer.
+}
+
+// GLTF writes the scene as a glTF 2.0 file, with the binary data embedded as a data URI.
+//
+// Objects keep their names and the hierarchy of groups. As glTF is right-handed
+// with the camera looking along -Z, the Z axis of every transform is flipped.
+// Spheres become meshes, materials become metallic-roughness materials with
+// the ambient intensity kept in their extras, lights become point, spot and
+// directional lights of the KHR_lights_punctual extension with their shape
+// kept in their extras, and the CAMERA a perspective camera.
+func GLTF(w io.Writer, values evaluator.EvaluatedValues, options GLTFOptions) error {
+	doc, data, err := buildGLTF(values, options)
+	if err != nil {
+		return err
+	}
+	if len(data) > 0 {
+		doc.Buffers[0].URI = "data:application/octet-stream;base64," + base64.StdEncoding.EncodeToString(data)
+	}
+
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(out, '\n'))
+	return err
+}
+
+// GLB writes the scene in the binary form of glTF 2.0: the same document as
+// GLTF followed by the binary data, in a single file.
+func GLB(w io.Writer, values evaluator.EvaluatedValues, options GLTFOptions) error {
+	doc, data, err := buildGLTF(values, options)
+	if err != nil {
+		return err
+	}
+
+	content, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	content = pad(content, ' ')
+	data = pad(data, 0)
+
+	length := 12 + 8 + len(content)
+	if len(data) > 0 {
+		length += 8 + len(data)
+	}
+
+	var out bytes.Buffer
+	binary.Write(&out, binary.LittleEndian, []uint32{glbMagic, 2, uint32(length)})
+	binary.Write(&out, binary.LittleEndian, []uint32{uint32(len(content)), glbJSONChunk})
+	out.Write(content)
+	if len(data) > 0 {
+		binary.Write(&out, binary.LittleEndian, []uint32{uint32(len(data)), glbBinaryChunk})
+		out.Write(data)
+	}
+	_, err = w.Write(out.Bytes())
+	return err
+}
+
+const (
+	glbMagic       = 0x46546C67 // "glTF"
+	glbJSONChunk   = 0x4E4F534A // "JSON"
+	glbBinaryChunk = 0x004E4942 // "BIN"
+)
+
+// pad fills b with the byte up to a multiple of 4 bytes, as chunks and buffer views are aligned to it.
+func pad(b []byte, with byte) []byte {
+	for len(b)%4 != 0 {
+		b = append(b, with)
+	}
+	return b
+}
+
+// Values of the glTF enumerations.
+const (
+	gltfFloat         = 5126
+	gltfUnsignedShort = 5123
+	gltfUnsignedInt   = 5125
+	gltfArrayBuffer   = 34962
+	gltfElementBuffer = 34963
+)
+
+// Extensions used by the documents.
+const (
+	lightsExtension       = "KHR_lights_punctual"
+	specularExtension     = "KHR_materials_specular"
+	transmissionExtension = "KHR_materials_transmission"
+	iorExtension          = "KHR_materials_ior"
+	emissiveExtension     = "KHR_materials_emissive_strength"
+)
+
+type gltfDocument struct {
+	Asset          gltfAsset              `json:"asset"`
+	ExtensionsUsed []string               `json:"extensionsUsed,omitempty"`
+	Extensions     map[string]interface{} `json:"extensions,omitempty"`
+	Scene          int                    `json:"scene"`
+	Scenes         []gltfScene            `json:"scenes"`
+	Nodes          []gltfNode             `json:"nodes"`
+	Cameras        []gltfCamera           `json:"cameras,omitempty"`
+	Meshes         []gltfMesh             `json:"meshes,omitempty"`
+	Materials      []gltfMaterial         `json:"materials,omitempty"`
+	Accessors      []gltfAccessor         `json:"accessors,omitempty"`
+	BufferViews    []gltfBufferView       `json:"bufferViews,omitempty"`
+	Buffers        []gltfBuffer           `json:"buffers,omitempty"`
+}
+
+type gltfAsset struct {
+	Version   string `json:"version"`
+	Generator string `json:"generator"`
+}
+
+type gltfScene struct {
+	Nodes []int `json:"nodes"`
+}
+
+type gltfNode struct {
+	Name       string                 `json:"name,omitempty"`
+	Matrix     []float64              `json:"matrix,omitempty"`
+	Children   []int                  `json:"children,omitempty"`
+	Mesh       *int                   `json:"mesh,omitempty"`
+	Camera     *int                   `json:"camera,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+type gltfCamera struct {
+	Name        string                 `json:"name,omitempty"`
+	Type        string                 `json:"type"`
+	Perspective gltfPerspective        `json:"perspective"`
+	Extras      map[string]interface{} `json:"extras,omitempty"`
+}
+
+type gltfPerspective struct {
+	YFov        float64 `json:"yfov"`
+	AspectRatio float64 `json:"aspectRatio,omitempty"`
+	ZNear       float64 `json:"znear"`
+}
+
+type gltfMesh struct {
+	Name       string          `json:"name,omitempty"`
+	Primitives []gltfPrimitive `json:"primitives"`
+}
+
+type gltfPrimitive struct {
+	Attributes map[string]int `json:"attributes"`
+	Indices    int            `json:"indices"`
+	Material   int            `json:"material"`
+}
+
+type gltfMaterial struct {
+	Name                 string                 `json:"name,omitempty"`
+	PBRMetallicRoughness gltfPBR                `json:"pbrMetallicRoughness"`
+	EmissiveFactor       *[3]float64            `json:"emissiveFactor,omitempty"`
+	Extensions           map[string]interface{} `json:"extensions,omitempty"`
+	Extras               map[string]interface{} `json:"extras,omitempty"`
+}
+
+type gltfPBR struct {
+	BaseColorFactor [4]float64 `json:"baseColorFactor"`
+	MetallicFactor  float64    `json:"metallicFactor"`
+	RoughnessFactor float64    `json:"roughnessFactor"`
+}
+
+type gltfLight struct {
+	Name      string                 `json:"name,omitempty"`
+	Type      string                 `json:"type"`
+	Color     [3]float64             `json:"color"`
+	Intensity float64                `json:"intensity"`
+	Spot      *gltfSpot              `json:"spot,omitempty"`
+	Extras    map[string]interface{} `json:"extras,omitempty"`
+}
+
+type gltfSpot struct {
+	InnerConeAngle float64 `json:"innerConeAngle"`
+	OuterConeAngle float64 `json:"outerConeAngle"`
+}
+
+type gltfAccessor struct {
+	BufferView    int       `json:"bufferView"`
+	ComponentType int       `json:"componentType"`
+	Count         int       `json:"count"`
+	Type          string    `json:"type"`
+	Min           []float64 `json:"min,omitempty"`
+	Max           []float64 `json:"max,omitempty"`
+}
+
+type gltfBufferView struct {
+	Buffer     int `json:"buffer"`
+	ByteOffset int `json:"byteOffset"`
+	ByteLength int `json:"byteLength"`
+	Target     int `json:"target"`
+}
+
+type gltfBuffer struct {
+	URI        string `json:"uri,omitempty"`
+	ByteLength int    `json:"byteLength"`
+}
+
+// gltfBuilder collects the parts of the document while the scene graph is walked.
+type gltfBuilder struct {
+	doc         gltfDocument
+	data        bytes.Buffer
+	options     GLTFOptions
+	spheres     map[string]scene.Sphere
+	lights      []gltfLight
+	sceneLights map[string]scene.Light
+	camera      scene.Camera
+	materials   map[scene.Material]int // index of the mesh of the sphere with each material
+	sphere      map[string]int         // attributes of the unit sphere, once written
+	indices     int
+}
+
+func buildGLTF(values evaluator.EvaluatedValues, options GLTFOptions) (gltfDocument, []byte, error) {
+	if options.Tessellation == (Tessellation{}) {
+		options.Tessellation = DefaultTessellation
+	}
+	if err := options.Tessellation.validate(); err != nil {
+		return gltfDocument{}, nil, err
+	}
+
+	s, err := scene.New(values)
+	if err != nil {
+		return gltfDocument{}, nil, err
+	}
+
+	b := &gltfBuilder{
+		doc: gltfDocument{
+			Asset:  gltfAsset{Version: "2.0", Generator: "sdl"},
+			Scenes: []gltfScene{{Nodes: []int{}}},
+			Nodes:  []gltfNode{},
+		},
+		options:     options,
+		spheres:     make(map[string]scene.Sphere),
+		sceneLights: make(map[string]scene.Light),
+		camera:      s.Camera,
+		materials:   make(map[scene.Material]int),
+	}
+	for _, sphere := range s.Spheres {
+		b.spheres[sphere.Name] = sphere
+	}
+	for _, light := range s.Lights {
+		b.sceneLights[light.Name] = light
+	}
+
+	hasCamera := false
+	for _, root := range values.Roots {
+		b.doc.Scenes[0].Nodes = append(b.doc.Scenes[0].Nodes, b.node(root))
+		hasCamera = hasCamera || root.Entity.Class == token.CAMERA
+	}
+	if !hasCamera {
+		camera := b.addCamera()
+		b.doc.Nodes = append(b.doc.Nodes, gltfNode{Name: token.CAMERA, Camera: &camera})
+		b.doc.Scenes[0].Nodes = append(b.doc.Scenes[0].Nodes, len(b.doc.Nodes)-1)
+	}
+
+	if len(b.lights) > 0 {
+		b.doc.ExtensionsUsed = append(b.doc.ExtensionsUsed, lightsExtension)
+		b.doc.Extensions = map[string]interface{}{lightsExtension: map[string]interface{}{"lights": b.lights}}
+	}
+	for _, extension := range []string{specularExtension, transmissionExtension, iorExtension, emissiveExtension} {
+		for _, material := range b.doc.Materials {
+			if _, ok := material.Extensions[extension]; ok {
+				b.doc.ExtensionsUsed = append(b.doc.ExtensionsUsed, extension)
+				break
+			}
+		}
+	}
+	if b.data.Len() > 0 {
+		b.doc.Buffers = []gltfBuffer{{ByteLength: b.data.Len()}}
+	}
+	return b.doc, b.data.Bytes(), nil
+}
+
+// node adds the scene node and its descendants, and returns its index.
+func (b *gltfBuilder) node(n *evaluator.SceneNode) int {
+	index := len(b.doc.Nodes)
+	b.doc.Nodes = append(b.doc.Nodes, gltfNode{Name: n.Entity.Name})
+	local := n.Local
+
+	switch n.Entity.Class {
+	case token.SPHERE:
+		sphere := b.spheres[n.Entity.Name]
+		mesh := b.sphereMesh(sphere.Material)
+		b.doc.Nodes[index].Mesh = &mesh
+		local = local.Mul(transform.Scaling(transform.Vec3{X: sphere.Radius, Y: sphere.Radius, Z: sphere.Radius}))
+	case token.LIGHT:
+		b.lights = append(b.lights, newGLTFLight(b.sceneLights[n.Entity.Name]))
+		b.doc.Nodes[index].Extensions = map[string]interface{}{
+			lightsExtension: map[string]int{"light": len(b.lights) - 1},
+		}
+	case token.CAMERA:
+		camera := b.addCamera()
+		b.doc.Nodes[index].Camera = &camera
+	}
+
+	if local != transform.Identity() {
+		b.doc.Nodes[index].Matrix = columns(flipZ(local))
+	}
+	for _, child := range n.Children {
+		i := b.node(child)
+		b.doc.Nodes[index].Children = append(b.doc.Nodes[index].Children, i)
+	}
+	return index
+}
+
+func (b *gltfBuilder) addCamera() int {
+	extras := map[string]interface{}{"ambientIntensity": b.camera.AmbientIntensity}
+	if b.camera.FocalDistance != 0 {
+		extras["focalDistance"] = b.camera.FocalDistance
+	}
+	if b.camera.Aperture != 0 {
+		extras["aperture"] = b.camera.Aperture
+	}
+	b.doc.Cameras = append(b.doc.Cameras, gltfCamera{
+		Name: token.CAMERA,
+		Type: "perspective",
+		Perspective: gltfPerspective{
+			YFov:        transform.Radians(b.camera.FieldOfView),
+			AspectRatio: b.options.AspectRatio,
+			ZNear:       0.01,
+		},
+		Extras: extras,
+	})
+	return len(b.doc.Cameras) - 1
+}
+
+// sphereMesh returns the mesh of a unit sphere with the material.
+// Spheres with equal materials share the mesh, and all of them the vertex data.
+func (b *gltfBuilder) sphereMesh(material scene.Material) int {
+	if mesh, ok := b.materials[material]; ok {
+		return mesh
+	}
+
+	if b.sphere == nil {
+		m := UnitSphere(b.options.Tessellation)
+		b.sphere = map[string]int{
+			"POSITION": b.vectors(m.Positions, true),
+			"NORMAL":   b.vectors(m.Normals, false),
+		}
+		b.indices = b.indexAccessor(m.Indices, len(m.Positions))
+	}
+
+	b.doc.Materials = append(b.doc.Materials, gltfMaterialOf(material))
+	b.doc.Meshes = append(b.doc.Meshes, gltfMesh{
+		Name: material.Name,
+		Primitives: []gltfPrimitive{{
+			Attributes: b.sphere,
+			Indices:    b.indices,
+			Material:   len(b.doc.Materials) - 1,
+		}},
+	})
+	b.materials[material] = len(b.doc.Meshes) - 1
+	return len(b.doc.Meshes) - 1
+}
+
+// gltfMaterialOf maps the Phong intensities to a dielectric material:
+// the diffuse intensity scales the base color, the specular intensity the
+// strength of the reflections, and the shininess gives the roughness with
+// the usual approximation sqrt(2 / (shininess + 2)). Mirrors become metals,
+// and transparent materials use the transmission and ior extensions.
+// Emission brighter than 1 is kept with the emissive strength extension.
+func gltfMaterialOf(m scene.Material) gltfMaterial {
+	base := m.Color.Scale(m.DiffuseIntensity)
+	roughness := 1.0
+	if m.Shininess > 0 {
+		roughness = math.Sqrt(2 / (m.Shininess + 2))
+	}
+	material := gltfMaterial{
+		Name: m.Name,
+		PBRMetallicRoughness: gltfPBR{
+			BaseColorFactor: [4]float64{clamp(base.R), clamp(base.G), clamp(base.B), 1},
+			MetallicFactor:  clamp(m.Reflectivity),
+			RoughnessFactor: roughness,
+		},
+		Extensions: map[string]interface{}{
+			specularExtension: map[string]float64{"specularFactor": clamp(m.SpecularIntensity)},
+		},
+		Extras: map[string]interface{}{"ambientIntensity": m.AmbientIntensity},
+	}
+	if m.Transparency > 0 {
+		material.Extensions[transmissionExtension] = map[string]float64{"transmissionFactor": clamp(m.Transparency)}
+		material.Extensions[iorExtension] = map[string]float64{"ior": m.IOR}
+	}
+	if e := m.Emission; e != (scene.Color{}) {
+		strength := math.Max(1, math.Max(e.R, math.Max(e.G, e.B)))
+		e = e.Scale(1 / strength)
+		material.EmissiveFactor = &[3]float64{clamp(e.R), clamp(e.G), clamp(e.B)}
+		if strength > 1 {
+			material.Extensions[emissiveExtension] = map[string]float64{"emissiveStrength": strength}
+		}
+	}
+	return material
+}
+
+// vectors writes the vectors as floats and returns the index of their accessor.
+func (b *gltfBuilder) vectors(vectors []transform.Vec3, bounds bool) int {
+	accessor := gltfAccessor{ComponentType: gltfFloat, Count: len(vectors), Type: "VEC3"}
+	if bounds {
+		accessor.Min = []float64{math.Inf(1), math.Inf(1), math.Inf(1)}
+		accessor.Max = []float64{math.Inf(-1), math.Inf(-1), math.Inf(-1)}
+	}
+
+	components := make([]float32, 0, 3*len(vectors))
+	for _, v := range vectors {
+		vector := []float32{float32(v.X), float32(v.Y), float32(v.Z)}
+		components = append(components, vector...)
+		if bounds {
+			for i, c := range vector {
+				accessor.Min[i] = math.Min(accessor.Min[i], float64(c))
+				accessor.Max[i] = math.Max(accessor.Max[i], float64(c))
+			}
+		}
+	}
+
+	accessor.BufferView = b.bufferView(components, gltfArrayBuffer)
+	b.doc.Accessors = append(b.doc.Accessors, accessor)
+	return len(b.doc.Accessors) - 1
+}
+
+// indexAccessor writes the indices, as 16-bit integers if they fit,
+// and returns the index of their accessor.
+func (b *gltfBuilder) indexAccessor(indices []uint32, vertices int) int {
+	accessor := gltfAccessor{ComponentType: gltfUnsignedInt, Count: len(indices), Type: "SCALAR"}
+	var data interface{} = indices
+	if vertices <= math.MaxUint16 {
+		short := make([]uint16, len(indices))
+		for i, index := range indices {
+			short[i] = uint16(index)
+		}
+		accessor.ComponentType = gltfUnsignedShort
+		data = short
+	}
+
+	accessor.BufferView = b.bufferView(data, gltfElementBuffer)
+	b.doc.Accessors = append(b.doc.Accessors, accessor)
+	return len(b.doc.Accessors) - 1
+}
+
+// bufferView appends the data to the buffer and returns the index of the view of it.
+func (b *gltfBuilder) bufferView(data interface{}, target int) int {
+	offset := b.data.Len()
+	binary.Write(&b.data, binary.LittleEndian, data)
+	view := gltfBufferView{ByteOffset: offset, ByteLength: b.data.Len() - offset, Target: target}
+	for b.data.Len()%4 != 0 {
+		b.data.WriteByte(0)
+	}
+
+	b.doc.BufferViews = append(b.doc.BufferViews, view)
+	return len(b.doc.BufferViews) - 1
+}
+
+// flipZ converts a transform between the left-handed coordinates of SDL
+// and the right-handed ones of glTF, by mirroring the Z axis on both sides.
+func flipZ(m transform.Mat4) transform.Mat4 {
+	mirror := transform.Scaling(transform.Vec3{X: 1, Y: 1, Z: -1})
+	return mirror.Mul(m).Mul(mirror)
+}
+
+// columns returns the elements of the matrix in column-major order.
+func columns(m transform.Mat4) []float64 {
+	elements := make([]float64, 0, 16)
+	for column := 0; column < 4; column++ {
+		for row := 0; row < 4; row++ {
+			elements = append(elements, m[row][column])
+		}
+	}
+	return elements
+}
+
+func clamp(x float64) float64 {
+	return math.Max(0, math.Min(1, x))
+}
+
+// newGLTFLight converts a light. Lights in glTF shine along their -Z axis,
+// which the flipped Z axis of the node turns into the Z axis of the LIGHT.
+func newGLTFLight(light scene.Light) gltfLight {
+	l := gltfLight{
+		Name:      light.Name,
+		Type:      light.Kind,
+		Color:     [3]float64{clamp(light.Color.R), clamp(light.Color.G), clamp(light.Color.B)},
+		Intensity: light.DiffuseIntensity,
+		Extras:    map[string]interface{}{"specularIntensity": light.SpecularIntensity},
+	}
+	if light.Kind == "spot" {
+		// The outer angle must be at most a right angle, and larger than the inner one.
+		outer := math.Min(transform.Radians(light.ConeAngle), math.Pi/2)
+		inner := math.Max(0, math.Min(outer-transform.Radians(light.Falloff), outer*0.999))
+		l.Spot = &gltfSpot{InnerConeAngle: inner, OuterConeAngle: outer}
+	}
+	switch light.Shape {
+	case "sphere", "disk":
+		l.Extras["shape"] = light.Shape
+		l.Extras["radius"] = light.Radius
+	case "rectangle":
+		l.Extras["shape"] = light.Shape
+		l.Extras["width"] = light.Width
+		l.Extras["height"] = light.Height
+	}
+	return l
+}
@@ -0,0 +1,274 @@
+package export
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kacperkrolak/scene-description-language/evaluator"
+	"github.com/kacperkrolak/scene-description-language/transform"
+)
+
+func TestUnitSphere(t *testing.T) {
+	tessellation := Tessellation{Segments: 8, Rings: 4}
+	m := UnitSphere(tessellation)
+
+	if len(m.Positions) != 9*5 || len(m.Normals) != len(m.Positions) {
+		t.Fatalf("wrong number of vertices. got=%d positions, %d normals", len(m.Positions), len(m.Normals))
+	}
+	if len(m.Indices) != 3*2*8*(4-1) {
+		t.Fatalf("wrong number of indices. got=%d", len(m.Indices))
+	}
+	for i, p := range m.Positions {
+		if math.Abs(p.Length()-1) > 1e-12 || m.Normals[i] != p {
+			t.Errorf("vertex %d is not on the unit sphere. got=%v, normal %v", i, p, m.Normals[i])
+		}
+	}
+
+	for i := 0; i < len(m.Indices); i += 3 {
+		a, b, c := m.Positions[m.Indices[i]], m.Positions[m.Indices[i+1]], m.Positions[m.Indices[i+2]]
+		normal := b.Sub(a).Cross(c.Sub(a))
+		center := a.Add(b).Add(c)
+		if normal.Length() < 1e-9 || normal.Dot(center) <= 0 {
+			t.Errorf("triangle %d is degenerate or faces inwards: %v %v %v", i/3, a, b, c)
+		}
+	}
+}
+
+const gltfInput = `MATERIAL shiny = {color: [1, 0.5, 0], diffuseIntensity: 0.8, specularIntensity: 0.25, shininess: 6}
+SPHERE ball = {radius: 2, material: shiny}
+SPHERE other = {radius: 1, material: shiny, position: [0, 0, 5]}
+LIGHT lamp = {position: [0, 10, 0], color: [1, 1, 2], diffuseIntensity: 3}
+GROUP g = {children: [ball], position: [1, 2, 3]}
+MODIFY CAMERA {position: [0, 0, -10], fov: 90, focalDistance: 10, aperture: 0.5}
+`
+
+func decodeGLTF(t *testing.T, data []byte) gltfDocument {
+	t.Helper()
+	var doc gltfDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("invalid glTF document: %v\n%s", err, data)
+	}
+	return doc
+}
+
+func TestGLTF(t *testing.T) {
+	var out bytes.Buffer
+	if err := GLTF(&out, evaluate(t, gltfInput), GLTFOptions{Tessellation: Tessellation{Segments: 8, Rings: 4}, AspectRatio: 1.5}); err != nil {
+		t.Fatalf("error exporting: %v", err)
+	}
+	doc := decodeGLTF(t, out.Bytes())
+
+	if doc.Asset.Version != "2.0" || !reflect.DeepEqual(doc.ExtensionsUsed, []string{lightsExtension, specularExtension}) {
+		t.Errorf("wrong asset or extensions. got=%v %v", doc.Asset, doc.ExtensionsUsed)
+	}
+
+	var names []string
+	for _, node := range doc.Nodes {
+		names = append(names, node.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"other", "lamp", "g", "ball", "CAMERA"}) {
+		t.Fatalf("wrong nodes. got=%v", names)
+	}
+	if !reflect.DeepEqual(doc.Scenes[0].Nodes, []int{0, 1, 2, 4}) || !reflect.DeepEqual(doc.Nodes[2].Children, []int{3}) {
+		t.Errorf("wrong hierarchy. got=%v, children of g %v", doc.Scenes[0].Nodes, doc.Nodes[2].Children)
+	}
+
+	// Z is flipped, matrices are column-major and spheres are scaled by their radius.
+	group := transform.Translation(transform.Vec3{X: 1, Y: 2, Z: -3})
+	if !reflect.DeepEqual(doc.Nodes[2].Matrix, columns(group)) {
+		t.Errorf("wrong matrix of the group. got=%v", doc.Nodes[2].Matrix)
+	}
+	ball := transform.Scaling(transform.Vec3{X: 2, Y: 2, Z: 2})
+	if !reflect.DeepEqual(doc.Nodes[3].Matrix, columns(ball)) {
+		t.Errorf("wrong matrix of the sphere. got=%v", doc.Nodes[3].Matrix)
+	}
+	if doc.Nodes[0].Matrix[14] != -5 || doc.Nodes[1].Matrix[13] != 10 {
+		t.Errorf("wrong positions. got=%v and %v", doc.Nodes[0].Matrix, doc.Nodes[1].Matrix)
+	}
+
+	// Spheres with the same material share a mesh.
+	if len(doc.Meshes) != 1 || *doc.Nodes[0].Mesh != 0 || *doc.Nodes[3].Mesh != 0 {
+		t.Errorf("wrong meshes. got=%d", len(doc.Meshes))
+	}
+	material := doc.Materials[0]
+	if material.Name != "shiny" ||
+		material.PBRMetallicRoughness != (gltfPBR{BaseColorFactor: [4]float64{0.8, 0.4, 0, 1}, RoughnessFactor: 0.5}) ||
+		!reflect.DeepEqual(material.Extensions[specularExtension], map[string]interface{}{"specularFactor": 0.25}) ||
+		material.Extras["ambientIntensity"] != 0.1 {
+		t.Errorf("wrong material. got=%+v", material)
+	}
+
+	lights := doc.Extensions[lightsExtension].(map[string]interface{})["lights"].([]interface{})
+	expectedLight := map[string]interface{}{
+		"name": "lamp", "type": "point", "color": []interface{}{1.0, 1.0, 1.0}, "intensity": 3.0,
+		"extras": map[string]interface{}{"specularIntensity": 1.0},
+	}
+	if len(lights) != 1 || !reflect.DeepEqual(lights[0], expectedLight) {
+		t.Errorf("wrong lights. got=%v", lights)
+	}
+	if !reflect.DeepEqual(doc.Nodes[1].Extensions[lightsExtension], map[string]interface{}{"light": 0.0}) {
+		t.Errorf("wrong light of the node. got=%v", doc.Nodes[1].Extensions)
+	}
+
+	camera := doc.Cameras[*doc.Nodes[4].Camera]
+	if camera.Perspective != (gltfPerspective{YFov: math.Pi / 2, AspectRatio: 1.5, ZNear: 0.01}) {
+		t.Errorf("wrong camera. got=%+v", camera.Perspective)
+	}
+	if !reflect.DeepEqual(camera.Extras, map[string]interface{}{"ambientIntensity": 1.0, "focalDistance": 10.0, "aperture": 0.5}) {
+		t.Errorf("wrong camera extras. got=%v", camera.Extras)
+	}
+	if doc.Nodes[4].Matrix[14] != 10 {
+		t.Errorf("wrong camera position. got=%v", doc.Nodes[4].Matrix)
+	}
+
+	uri := doc.Buffers[0].URI
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(uri, "data:application/octet-stream;base64,"))
+	if err != nil || len(data) != doc.Buffers[0].ByteLength {
+		t.Fatalf("wrong buffer. got %d bytes for %d, %v", len(data), doc.Buffers[0].ByteLength, err)
+	}
+	for i, accessor := range doc.Accessors {
+		view := doc.BufferViews[accessor.BufferView]
+		if view.ByteOffset%4 != 0 || view.ByteOffset+view.ByteLength > len(data) {
+			t.Errorf("view of accessor %d out of the buffer: %+v", i, view)
+		}
+	}
+	position := doc.Accessors[doc.Meshes[0].Primitives[0].Attributes["POSITION"]]
+	if position.Count != 45 || !reflect.DeepEqual(position.Max, []float64{1, 1, 1}) {
+		t.Errorf("wrong positions. got=%+v", position)
+	}
+	if indices := doc.Accessors[doc.Meshes[0].Primitives[0].Indices]; indices.ComponentType != gltfUnsignedShort || indices.Count != 144 {
+		t.Errorf("wrong indices. got=%+v", indices)
+	}
+}
+
+func TestGLTFOptics(t *testing.T) {
+	var out bytes.Buffer
+	input := `SPHERE mirror = {radius: 1, material: {reflectivity: 0.75}}
+SPHERE glass = {radius: 1, material: {transparency: 0.5, ior: 1.5}}
+SPHERE lamp = {radius: 1, material: {emission: [4, 2, 0]}}`
+	if err := GLTF(&out, evaluate(t, input), GLTFOptions{}); err != nil {
+		t.Fatalf("error exporting: %v", err)
+	}
+	doc := decodeGLTF(t, out.Bytes())
+
+	if !reflect.DeepEqual(doc.ExtensionsUsed, []string{specularExtension, transmissionExtension, iorExtension, emissiveExtension}) {
+		t.Errorf("wrong extensions. got=%v", doc.ExtensionsUsed)
+	}
+	mirror, glass := doc.Materials[0], doc.Materials[1]
+	if mirror.PBRMetallicRoughness.MetallicFactor != 0.75 || mirror.Extensions[transmissionExtension] != nil {
+		t.Errorf("wrong mirror. got=%+v", mirror)
+	}
+	if !reflect.DeepEqual(glass.Extensions[transmissionExtension], map[string]interface{}{"transmissionFactor": 0.5}) ||
+		!reflect.DeepEqual(glass.Extensions[iorExtension], map[string]interface{}{"ior": 1.5}) {
+		t.Errorf("wrong glass. got=%+v", glass)
+	}
+	lamp := doc.Materials[2]
+	if lamp.EmissiveFactor == nil || *lamp.EmissiveFactor != [3]float64{1, 0.5, 0} ||
+		!reflect.DeepEqual(lamp.Extensions[emissiveExtension], map[string]interface{}{"emissiveStrength": 4.0}) {
+		t.Errorf("wrong lamp. got=%+v", lamp)
+	}
+	if mirror.EmissiveFactor != nil {
+		t.Errorf("mirror should not glow. got=%v", *mirror.EmissiveFactor)
+	}
+}
+
+func TestGLTFLights(t *testing.T) {
+	var out bytes.Buffer
+	input := `LIGHT sun = {kind: "directional", rotation: [90, 0, 0]}
+LIGHT torch = {kind: "spot", coneAngle: 30, falloff: 10}
+LIGHT panel = {shape: "rectangle", width: 2, height: 3}`
+	if err := GLTF(&out, evaluate(t, input), GLTFOptions{}); err != nil {
+		t.Fatalf("error exporting: %v", err)
+	}
+	doc := decodeGLTF(t, out.Bytes())
+
+	lights := doc.Extensions[lightsExtension].(map[string]interface{})["lights"].([]interface{})
+	if len(lights) != 3 {
+		t.Fatalf("wrong number of lights. got=%d", len(lights))
+	}
+	if sun := lights[0].(map[string]interface{}); sun["type"] != "directional" || sun["spot"] != nil {
+		t.Errorf("wrong sun. got=%v", sun)
+	}
+	torch := lights[1].(map[string]interface{})
+	spot, _ := torch["spot"].(map[string]interface{})
+	if torch["type"] != "spot" || spot == nil ||
+		math.Abs(spot["innerConeAngle"].(float64)-math.Pi/9) > 1e-9 || math.Abs(spot["outerConeAngle"].(float64)-math.Pi/6) > 1e-9 {
+		t.Errorf("wrong spot light. got=%v", torch)
+	}
+	expectedExtras := map[string]interface{}{"specularIntensity": 1.0, "shape": "rectangle", "width": 2.0, "height": 3.0}
+	if panel := lights[2].(map[string]interface{}); panel["type"] != "point" || !reflect.DeepEqual(panel["extras"], expectedExtras) {
+		t.Errorf("wrong area light. got=%v", panel)
+	}
+
+	// The Z axis of the sun points down, and so does the -Z axis of its node.
+	matrix := doc.Nodes[0].Matrix
+	if len(matrix) != 16 || math.Abs(matrix[9]-1) > 1e-9 {
+		t.Errorf("wrong transform of the sun. got=%v", matrix)
+	}
+}
+
+func TestGLTFDefaultCamera(t *testing.T) {
+	var out bytes.Buffer
+	if err := GLTF(&out, evaluate(t, "NUMBER n = 1"), GLTFOptions{}); err != nil {
+		t.Fatalf("error exporting: %v", err)
+	}
+	doc := decodeGLTF(t, out.Bytes())
+
+	if len(doc.Nodes) != 1 || doc.Nodes[0].Camera == nil || doc.Nodes[0].Matrix != nil {
+		t.Fatalf("wrong nodes. got=%+v", doc.Nodes)
+	}
+	if doc.Buffers != nil || doc.Meshes != nil || doc.ExtensionsUsed != nil {
+		t.Errorf("unexpected data in an empty scene. got=%s", out.String())
+	}
+}
+
+func TestGLB(t *testing.T) {
+	values := evaluate(t, gltfInput)
+	var out bytes.Buffer
+	if err := GLB(&out, values, GLTFOptions{}); err != nil {
+		t.Fatalf("error exporting: %v", err)
+	}
+	glb := out.Bytes()
+
+	word := func(offset int) uint32 { return binary.LittleEndian.Uint32(glb[offset:]) }
+	if word(0) != glbMagic || word(4) != 2 || int(word(8)) != len(glb) {
+		t.Fatalf("wrong header. got=% x", glb[:12])
+	}
+	jsonLength := int(word(12))
+	if jsonLength%4 != 0 || word(16) != glbJSONChunk {
+		t.Fatalf("wrong JSON chunk header. got=% x", glb[12:20])
+	}
+	doc := decodeGLTF(t, glb[20:20+jsonLength])
+
+	chunk := 20 + jsonLength
+	if word(chunk+4) != glbBinaryChunk || int(word(chunk)) < doc.Buffers[0].ByteLength || chunk+8+int(word(chunk)) != len(glb) {
+		t.Errorf("wrong binary chunk. got=% x for a buffer of %d bytes", glb[chunk:chunk+8], doc.Buffers[0].ByteLength)
+	}
+	if doc.Buffers[0].URI != "" {
+		t.Errorf("the buffer should be in the file. got=%q", doc.Buffers[0].URI)
+	}
+}
+
+func TestGLTFErrors(t *testing.T) {
+	tests := []struct {
+		values   evaluator.EvaluatedValues
+		options  GLTFOptions
+		expected string
+	}{
+		{evaluate(t, "NUMBER n = 1"), GLTFOptions{Tessellation: Tessellation{Segments: 3, Rings: 1}}, "a sphere needs at least 3 segments and 2 rings, got 3 and 1"},
+		{evaluate(t, "SPHERE s = {}"), GLTFOptions{}, "SPHERE s: radius: missing required property"},
+	}
+
+	for _, tt := range tests {
+		err := GLTF(&bytes.Buffer{}, tt.values, tt.options)
+		if err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("wrong error. got=%v, want %q", err, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package export
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+
+	"github.com/kacperkrolak/scene-description-language/ast"
+	"github.com/kacperkrolak/scene-description-language/format"
+	"github.com/kacperkrolak/scene-description-language/scene"
+	"github.com/kacperkrolak/scene-description-language/token"
+)
+
+// Import reads a scene in the JSON format written by JSON and returns the
+// statements defining its objects, in the same order, so that evaluating
+// them and exporting the result gives the same document again.
+//
+// The CAMERA is set with MODIFY CAMERA. References written as {"ref": name}
+// become names again, and so do materials equal to the value of a MATERIAL
+// object. Transforms relative to the origin are left out, as they follow
+// from the values of the objects and their groups.
+func Import(r io.Reader) (*ast.File, error) {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	value, err := decodeValue(decoder)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("invalid JSON: unexpected data after the document")
+	}
+
+	doc, ok := value.(*object)
+	if !ok {
+		return nil, fmt.Errorf("expected the document to be an object")
+	}
+	objects, ok := doc.values["objects"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf(`expected "objects" to be an array`)
+	}
+
+	im := importer{}
+	file := &ast.File{Statements: []ast.Statement{}}
+	for i, o := range objects {
+		statement, err := im.statement(o)
+		if err != nil {
+			return nil, fmt.Errorf("objects[%d]: %w", i, err)
+		}
+		file.Statements = append(file.Statements, statement)
+	}
+	return file, nil
+}
+
+// ImportSource reads a scene in the JSON format and returns it as formatted SDL source.
+func ImportSource(r io.Reader) ([]byte, error) {
+	file, err := Import(r)
+	if err != nil {
+		return nil, err
+	}
+	return format.Node(file)
+}
+
+// decodeValue reads the next JSON value, keeping the order of object members.
+func decodeValue(decoder *json.Decoder) (interface{}, error) {
+	tok, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok := tok.(type) {
+	case json.Delim:
+		switch tok {
+		case '{':
+			o := newObject()
+			for decoder.More() {
+				key, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				value, err := decodeValue(decoder)
+				if err != nil {
+					return nil, err
+				}
+				if _, ok := o.values[key.(string)]; ok {
+					return nil, fmt.Errorf("duplicate member %q", key)
+				}
+				o.set(key.(string), value)
+			}
+			_, err := decoder.Token()
+			return o, err
+		case '[':
+			elements := []interface{}{}
+			for decoder.More() {
+				value, err := decodeValue(decoder)
+				if err != nil {
+					return nil, err
+				}
+				elements = append(elements, value)
+			}
+			_, err := decoder.Token()
+			return elements, err
+		}
+	case json.Number:
+		return tok.Float64()
+	case string:
+		return tok, nil
+	}
+	return nil, fmt.Errorf("unsupported value %v", tok)
+}
+
+type importer struct {
+	materials []*object // values of the MATERIAL objects read so far
+	names     []string  // names of the materials
+}
+
+func (im *importer) statement(value interface{}) (ast.Statement, error) {
+	o, ok := value.(*object)
+	if !ok {
+		return nil, errors.New("expected an object")
+	}
+
+	name, ok := o.values["name"].(string)
+	if !ok {
+		return nil, errors.New(`expected "name" to be a string`)
+	}
+	if !isIdentifier(name) {
+		return nil, fmt.Errorf("%q is not a valid name", name)
+	}
+	className, ok := o.values["class"].(string)
+	if !ok {
+		return nil, fmt.Errorf(`%s: expected "class" to be a string`, name)
+	}
+	class, ok := scene.LookupClass(className)
+	if !ok {
+		return nil, fmt.Errorf("%s: unknown class %q", name, className)
+	}
+	v, ok := o.values["value"]
+	if !ok {
+		return nil, fmt.Errorf(`%s: missing "value"`, name)
+	}
+
+	expression, err := im.expression(v, class.Value, class)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	if class.Name == token.MATERIAL {
+		if properties, ok := v.(*object); ok {
+			im.materials = append(im.materials, properties)
+			im.names = append(im.names, name)
+		}
+	}
+
+	identifier := &ast.Identifier{Token: token.Token{Type: token.LookupIdent(name), Literal: name}, Value: name}
+	if class.Name == token.CAMERA {
+		if name != token.CAMERA {
+			return nil, fmt.Errorf("%s: the camera must be named CAMERA", name)
+		}
+		return &ast.ModifyStatement{Token: token.Token{Type: token.MODIFY, Literal: "MODIFY"}, Name: identifier, Value: expression}, nil
+	}
+
+	return &ast.AssignStatement{
+		Token: token.Token{Type: token.LookupIdent(class.Name), Literal: class.Name},
+		Name:  identifier,
+		Value: expression,
+	}, nil
+}
+
+// expression converts a value of the given type to the expression writing it.
+func (im *importer) expression(value interface{}, valueType scene.ValueType, class scene.Class) (ast.Expression, error) {
+	if valueType == scene.MaterialValue {
+		if name, ok := im.material(value); ok {
+			return &ast.Identifier{Token: token.Token{Type: token.IDENT, Literal: name}, Value: name}, nil
+		}
+		material, _ := scene.LookupClass(token.MATERIAL)
+		return im.expression(value, scene.PropertiesValue, material)
+	}
+
+	switch value := value.(type) {
+	case float64:
+		return number(value)
+	case string:
+		return &ast.StringLiteral{Token: token.Token{Type: token.STRING, Literal: value}, Value: value}, nil
+	case []interface{}:
+		array := &ast.ArrayExpression{Token: token.Token{Type: token.LBRACKET, Literal: "["}}
+		for i, element := range value {
+			e, err := im.expression(element, "", scene.Class{})
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
+			}
+			array.Elements = append(array.Elements, e)
+		}
+		return array, nil
+	case *object:
+		if name, ok := reference(value); ok {
+			return &ast.Identifier{Token: token.Token{Type: token.LookupIdent(name), Literal: name}, Value: name}, nil
+		}
+
+		properties := &ast.PropertiesExpression{
+			Token:      token.Token{Type: token.LBRACE, Literal: "{"},
+			Properties: make(map[string]ast.Expression),
+		}
+		for _, key := range value.keys {
+			if !isName(key) || token.LookupIdent(key) != token.IDENT {
+				return nil, fmt.Errorf("%q is not a valid property name", key)
+			}
+
+			// Properties of the class are converted knowing their type, so materials
+			// become references. Other values keep their JSON structure.
+			var propertyType scene.ValueType
+			if valueType == scene.PropertiesValue {
+				property, _ := class.Property(key)
+				propertyType = property.Type
+			}
+
+			e, err := im.expression(value.values[key], propertyType, scene.Class{})
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			properties.Keys = append(properties.Keys, &ast.Identifier{Token: token.Token{Type: token.IDENT, Literal: key}, Value: key})
+			properties.Properties[key] = e
+		}
+		return properties, nil
+	default:
+		return nil, fmt.Errorf("unsupported value %v", value)
+	}
+}
+
+// material returns the name of the first MATERIAL object with the given value.
+func (im *importer) material(value interface{}) (string, bool) {
+	for i, material := range im.materials {
+		if reflect.DeepEqual(material, value) {
+			return im.names[i], true
+		}
+	}
+	return "", false
+}
+
+// number returns the literal of the number, negated if it is negative,
+// as literals in SDL have no sign. Negative zero is kept, as -0.
+func number(value float64) (ast.Expression, error) {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return nil, fmt.Errorf("cannot import %v", value)
+	}
+
+	literal := format.Number(math.Abs(value))
+	e := &ast.FloatLiteral{Token: token.Token{Type: token.FLOAT, Literal: literal}, Value: math.Abs(value)}
+	if math.Signbit(value) {
+		return &ast.PrefixExpression{Token: token.Token{Type: token.MINUS, Literal: "-"}, Operator: "-", Right: e}, nil
+	}
+	return e, nil
+}
+
+// reference returns the name of the object a {"ref": name} object refers to.
+func reference(o *object) (string, bool) {
+	if len(o.keys) != 1 || o.keys[0] != "ref" {
+		return "", false
+	}
+	name, ok := o.values["ref"].(string)
+	return name, ok && isIdentifier(name)
+}
+
+// isIdentifier reports whether the name can be written as an identifier.
+func isIdentifier(name string) bool {
+	if !isName(name) {
+		return false
+	}
+	tokenType := token.LookupIdent(name)
+	return tokenType == token.IDENT || tokenType == token.CAMERA
+}
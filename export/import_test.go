@@ -0,0 +1,121 @@
+package export
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestImportRoundTrip(t *testing.T) {
+	scenes := []string{
+		input,
+		`MODIFY CAMERA {position: [0, 1.5, -10], fov: 45}
+NUMBER pi = 3.14159265359
+COLOR red = [1, 0, 0]
+MATERIAL shiny = {color: red, specularIntensity: 1}
+MATERIAL copy EXTENDS shiny = {}
+SPHERE a = {radius: pi / 2, material: shiny, position: [-1, -0, 0.0000001], rotation: [0, 0, 0.7071, 0.7071]}
+SPHERE b = {radius: 1, material: {color: [0, 0, 1]}, scale: 2, rotation: [0, 90, 0], rotationOrder: "ZYX"}
+LIGHT l = {position: [0, 10, 0], color: [1, 1, 1]}
+GROUP inner = {children: [b], matrix: [1, 0, 0, 1, 0, 1, 0, 2, 0, 0, 1, 3, 0, 0, 0, 1]}
+GROUP outer = {children: [a, inner, l], position: [0, -5, 0]}
+`,
+		"",
+	}
+
+	for _, scene := range scenes {
+		var exported bytes.Buffer
+		if err := JSON(&exported, evaluate(t, scene)); err != nil {
+			t.Fatalf("error exporting %q: %v", scene, err)
+		}
+
+		src, err := ImportSource(bytes.NewReader(exported.Bytes()))
+		if err != nil {
+			t.Fatalf("error importing %s: %v", exported.String(), err)
+		}
+
+		var again bytes.Buffer
+		if err := JSON(&again, evaluate(t, string(src))); err != nil {
+			t.Fatalf("error exporting the imported source %s: %v", src, err)
+		}
+		if again.String() != exported.String() {
+			t.Errorf("export of the imported scene differs.\nsource:\n%s\ngot=%s\nwant=%s", src, again.String(), exported.String())
+		}
+	}
+}
+
+func TestImportSource(t *testing.T) {
+	doc := `{"objects": [
+		{"name": "CAMERA", "class": "CAMERA", "value": {"position": [0, 0, -5]}, "world": []},
+		{"name": "m", "class": "MATERIAL", "value": {"color": [1, 0.5, 0]}},
+		{"name": "s", "class": "SPHERE", "value": {"radius": 1e-3, "material": {"color": [1, 0.5, 0]}, "position": [-1, -0, 2]}},
+		{"name": "t", "class": "SPHERE", "value": {"radius": 1, "material": {"color": [1, 1, 1]}}},
+		{"name": "g", "class": "GROUP", "value": {"children": [{"ref": "s"}, {"ref": "t"}]}}
+	]}`
+
+	expected := `MODIFY CAMERA {
+    position: [0, 0, -5],
+}
+MATERIAL m = {
+    color: [1, 0.5, 0],
+}
+SPHERE s = {
+    radius: 0.001,
+    material: m,
+    position: [-1, -0, 2],
+}
+SPHERE t = {
+    radius: 1,
+    material: {
+        color: [1, 1, 1],
+    },
+}
+GROUP g = {
+    children: [s, t],
+}
+`
+
+	src, err := ImportSource(strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("error importing: %v", err)
+	}
+	if string(src) != expected {
+		t.Errorf("wrong source.\ngot=%s\nwant=%s", src, expected)
+	}
+}
+
+func TestImportErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`[]`, "expected the document to be an object"},
+		{`{"objects": {}}`, `expected "objects" to be an array`},
+		{`{"objects": []} {}`, "invalid JSON: unexpected data after the document"},
+		{`{"objects": [`, "invalid JSON: unexpected end of JSON input"},
+		{`{"objects": [1]}`, "objects[0]: expected an object"},
+		{`{"objects": [{"name": "a b", "class": "NUMBER", "value": 1}]}`, `objects[0]: "a b" is not a valid name`},
+		{`{"objects": [{"name": "AT", "class": "NUMBER", "value": 1}]}`, `objects[0]: "AT" is not a valid name`},
+		{`{"objects": [{"name": "a", "class": "CUBE", "value": 1}]}`, `objects[0]: a: unknown class "CUBE"`},
+		{`{"objects": [{"name": "a", "class": "NUMBER"}]}`, `objects[0]: a: missing "value"`},
+		{`{"objects": [{"name": "a", "class": "NUMBER", "value": true}]}`, "invalid JSON: unsupported value true"},
+		{`{"objects": [{"name": "a", "class": "CAMERA", "value": {}}]}`, "objects[0]: a: the camera must be named CAMERA"},
+		{`{"objects": [{"name": "a", "class": "SPHERE", "value": {"my-radius": 1}}]}`, `objects[0]: a: "my-radius" is not a valid property name`},
+		{`{"objects": [{"name": "a", "class": "SPHERE", "value": {"radius": 1, "radius": 2}}]}`, `invalid JSON: duplicate member "radius"`},
+	}
+
+	for _, tt := range tests {
+		_, err := Import(strings.NewReader(tt.input))
+		if err == nil {
+			t.Errorf("expected an error for %s", tt.input)
+			continue
+		}
+		if err.Error() != tt.expected {
+			t.Errorf("wrong error for %s.\ngot=%q\nwant=%q", tt.input, err.Error(), tt.expected)
+		}
+	}
+
+	if _, err := ImportSource(strings.NewReader(`{"objects": [{"name": "a", "class": "NUMBER", "value": "\""}]}`)); err == nil {
+		t.Errorf("expected an error for a string which cannot be written in SDL")
+	}
+}
@@ -0,0 +1,70 @@
+package export
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/kacperkrolak/scene-description-language/transform"
+)
+
+// Tessellation controls how finely curved surfaces are approximated by triangles.
+type Tessellation struct {
+	Segments int // Number of divisions around the vertical axis of a sphere, at least 3.
+	Rings    int // Number of divisions from pole to pole of a sphere, at least 2.
+}
+
+// DefaultTessellation is fine enough for spheres to look round at usual sizes.
+var DefaultTessellation = Tessellation{Segments: 32, Rings: 16}
+
+func (t Tessellation) validate() error {
+	if t.Segments < 3 || t.Rings < 2 {
+		return fmt.Errorf("a sphere needs at least 3 segments and 2 rings, got %d and %d", t.Segments, t.Rings)
+	}
+	return nil
+}
+
+// Mesh is a surface made of triangles. Triangles list the indices of their
+// vertices counter-clockwise, as seen from outside in a right-handed system.
+type Mesh struct {
+	Positions []transform.Vec3
+	Normals   []transform.Vec3
+	Indices   []uint32 // three per triangle
+}
+
+// UnitSphere returns a sphere of radius 1 centered at the origin,
+// divided into rings of latitude and segments of longitude.
+// Vertices along the seam are repeated, so every ring is a closed strip.
+func UnitSphere(t Tessellation) Mesh {
+	var m Mesh
+	for ring := 0; ring <= t.Rings; ring++ {
+		theta := math.Pi * float64(ring) / float64(t.Rings)
+		for segment := 0; segment <= t.Segments; segment++ {
+			phi := 2 * math.Pi * float64(segment) / float64(t.Segments)
+			p := transform.Vec3{
+				X: math.Sin(theta) * math.Cos(phi),
+				Y: math.Cos(theta),
+				Z: -math.Sin(theta) * math.Sin(phi),
+			}
+			m.Positions = append(m.Positions, p)
+			m.Normals = append(m.Normals, p)
+		}
+	}
+
+	vertex := func(ring, segment int) uint32 {
+		return uint32(ring*(t.Segments+1) + segment)
+	}
+	for ring := 0; ring < t.Rings; ring++ {
+		for segment := 0; segment < t.Segments; segment++ {
+			a, b := vertex(ring, segment), vertex(ring, segment+1)
+			c, d := vertex(ring+1, segment), vertex(ring+1, segment+1)
+			// The triangles touching a pole would have no area.
+			if ring > 0 {
+				m.Indices = append(m.Indices, a, c, b)
+			}
+			if ring < t.Rings-1 {
+				m.Indices = append(m.Indices, b, c, d)
+			}
+		}
+	}
+	return m
+}
@@ -0,0 +1,176 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/kacperkrolak/scene-description-language/evaluator"
+	"github.com/kacperkrolak/scene-description-language/scene"
+	"github.com/kacperkrolak/scene-description-language/transform"
+)
+
+// OBJOptions controls the conversion of a scene to Wavefront OBJ.
+type OBJOptions struct {
+	Tessellation    Tessellation // Zero uses DefaultTessellation.
+	MaterialLibrary string       // Name of the MTL file the OBJ file refers to, none if empty.
+}
+
+// OBJ writes the surfaces of the scene as a Wavefront OBJ file, with one
+// object per sphere named after it. Vertices are in world space, with the
+// Z axis flipped to the right-handed coordinates most tools expect.
+// Lights and the camera cannot be written in OBJ and are left out.
+// The materials the objects use are written by MTL.
+func OBJ(w io.Writer, values evaluator.EvaluatedValues, options OBJOptions) error {
+	if options.Tessellation == (Tessellation{}) {
+		options.Tessellation = DefaultTessellation
+	}
+	if err := options.Tessellation.validate(); err != nil {
+		return err
+	}
+	s, err := scene.New(values)
+	if err != nil {
+		return err
+	}
+
+	var out bytes.Buffer
+	out.WriteString("# Exported by sdl.\n")
+	if options.MaterialLibrary != "" {
+		fmt.Fprintf(&out, "mtllib %s\n", options.MaterialLibrary)
+	}
+
+	unit := UnitSphere(options.Tessellation)
+	names, _ := objMaterials(s)
+	vertices := 0
+	for i, sphere := range s.Spheres {
+		m := flipZ(sphere.Transform).Mul(transform.Scaling(transform.Vec3{X: sphere.Radius, Y: sphere.Radius, Z: sphere.Radius}))
+		inverse, ok := m.Inverse()
+		if !ok {
+			return fmt.Errorf("sphere %s has a transform which cannot be inverted", sphere.Name)
+		}
+		normal := inverse.Transpose()
+
+		fmt.Fprintf(&out, "\no %s\nusemtl %s\n", sphere.Name, names[i])
+		for _, p := range unit.Positions {
+			fmt.Fprintf(&out, "v %s\n", objVector(m.MulPoint(p)))
+		}
+		for _, n := range unit.Normals {
+			fmt.Fprintf(&out, "vn %s\n", objVector(normal.MulDirection(n).Normalize()))
+		}
+
+		// A mirroring transform turns the triangles inside out.
+		mirrored := m.MulDirection(transform.Vec3{X: 1}).Dot(
+			m.MulDirection(transform.Vec3{Y: 1}).Cross(m.MulDirection(transform.Vec3{Z: 1}))) < 0
+		for j := 0; j < len(unit.Indices); j += 3 {
+			a, b, c := unit.Indices[j], unit.Indices[j+1], unit.Indices[j+2]
+			if mirrored {
+				b, c = c, b
+			}
+			fmt.Fprintf(&out, "f %s %s %s\n", objVertex(vertices, a), objVertex(vertices, b), objVertex(vertices, c))
+		}
+		vertices += len(unit.Positions)
+	}
+
+	_, err = w.Write(out.Bytes())
+	return err
+}
+
+// MTL writes the materials used by the objects written by OBJ. The colors
+// scaled by the ambient and diffuse intensities become Ka and Kd, and the
+// specular intensity a white Ks, with the shininess as Ns. Transparency and
+// the index of refraction become d and Ni, and the emission Ke.
+func MTL(w io.Writer, values evaluator.EvaluatedValues) error {
+	s, err := scene.New(values)
+	if err != nil {
+		return err
+	}
+
+	var out bytes.Buffer
+	out.WriteString("# Exported by sdl.\n")
+	_, materials := objMaterials(s)
+	for _, m := range materials {
+		fmt.Fprintf(&out, "\nnewmtl %s\n", m.name)
+		material := m.material
+		fmt.Fprintf(&out, "Ka %s\n", objColor(material.Color.Scale(material.AmbientIntensity)))
+		fmt.Fprintf(&out, "Kd %s\n", objColor(material.Color.Scale(material.DiffuseIntensity)))
+		fmt.Fprintf(&out, "Ks %s\n", objColor(scene.White.Scale(material.SpecularIntensity)))
+		fmt.Fprintf(&out, "Ns %s\n", objNumber(material.Shininess))
+		if material.Transparency > 0 {
+			fmt.Fprintf(&out, "d %s\n", objNumber(1-material.Transparency))
+		}
+		if material.IOR != 1 {
+			fmt.Fprintf(&out, "Ni %s\n", objNumber(material.IOR))
+		}
+		if material.Emission != (scene.Color{}) {
+			fmt.Fprintf(&out, "Ke %s\n", objColor(material.Emission))
+		}
+		fmt.Fprintf(&out, "illum %d\n", illumination(material))
+	}
+
+	_, err = w.Write(out.Bytes())
+	return err
+}
+
+// illumination returns the MTL illumination model closest to the material.
+func illumination(m scene.Material) int {
+	switch {
+	case m.Transparency > 0:
+		return 7 // refraction and Fresnel reflection
+	case m.Reflectivity > 0:
+		return 3 // reflection
+	case m.SpecularIntensity > 0:
+		return 2 // highlights
+	default:
+		return 1
+	}
+}
+
+type objMaterial struct {
+	name     string
+	material scene.Material
+}
+
+// objMaterials returns the name of the material of every sphere and the
+// distinct materials in the order of their first use. Materials given
+// inline are named after the first sphere using them.
+func objMaterials(s *scene.Scene) ([]string, []objMaterial) {
+	names := make([]string, len(s.Spheres))
+	var materials []objMaterial
+	named := make(map[scene.Material]string)
+	for i, sphere := range s.Spheres {
+		name, ok := named[sphere.Material]
+		if !ok {
+			switch {
+			case sphere.Material.Name != "":
+				name = sphere.Material.Name
+			case sphere.Material == scene.DefaultMaterial:
+				name = "default"
+			default:
+				name = sphere.Name + "_material"
+			}
+			named[sphere.Material] = name
+			materials = append(materials, objMaterial{name, sphere.Material})
+		}
+		names[i] = name
+	}
+	return names, materials
+}
+
+// objVertex returns the position and normal indices of a vertex, which start at 1.
+func objVertex(offset int, index uint32) string {
+	i := strconv.Itoa(offset + int(index) + 1)
+	return i + "//" + i
+}
+
+func objVector(v transform.Vec3) string {
+	return objNumber(v.X) + " " + objNumber(v.Y) + " " + objNumber(v.Z)
+}
+
+func objColor(c scene.Color) string {
+	return objNumber(c.R) + " " + objNumber(c.G) + " " + objNumber(c.B)
+}
+
+func objNumber(x float64) string {
+	return strconv.FormatFloat(x, 'g', 6, 64)
+}
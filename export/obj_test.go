@@ -0,0 +1,181 @@
+package export
+
+import (
+	"bytes"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/kacperkrolak/scene-description-language/transform"
+)
+
+// objFile holds the parts of an OBJ file checked by the tests.
+type objFile struct {
+	lines     []string
+	positions []transform.Vec3
+	normals   []transform.Vec3
+	faces     [][3]int // indices of the positions, starting at 0
+}
+
+func parseOBJ(t *testing.T, source string) objFile {
+	t.Helper()
+	var f objFile
+	for _, line := range strings.Split(strings.TrimSuffix(source, "\n"), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || fields[0] == "#" {
+			continue
+		}
+		f.lines = append(f.lines, line)
+
+		switch fields[0] {
+		case "v", "vn":
+			var v [3]float64
+			for i := range v {
+				x, err := strconv.ParseFloat(fields[i+1], 64)
+				if err != nil {
+					t.Fatalf("invalid line %q: %v", line, err)
+				}
+				v[i] = x
+			}
+			if fields[0] == "v" {
+				f.positions = append(f.positions, transform.Vec3{X: v[0], Y: v[1], Z: v[2]})
+			} else {
+				f.normals = append(f.normals, transform.Vec3{X: v[0], Y: v[1], Z: v[2]})
+			}
+		case "f":
+			var face [3]int
+			for i := range face {
+				parts := strings.Split(fields[i+1], "//")
+				index, err := strconv.Atoi(parts[0])
+				if err != nil || len(parts) != 2 || parts[1] != parts[0] || index < 1 || index > len(f.positions) {
+					t.Fatalf("invalid face %q", line)
+				}
+				face[i] = index - 1
+			}
+			f.faces = append(f.faces, face)
+		}
+	}
+	return f
+}
+
+func TestOBJ(t *testing.T) {
+	values := evaluate(t, `MATERIAL red = {color: [1, 0, 0], diffuseIntensity: 0.5, specularIntensity: 0.25}
+SPHERE ball = {radius: 2, material: red, position: [1, 2, 3]}
+SPHERE mirrored = {radius: 1, scale: [-1, 1, 1], position: [0, 0, -5]}
+LIGHT lamp = {}
+`)
+	var out bytes.Buffer
+	if err := OBJ(&out, values, OBJOptions{Tessellation: Tessellation{Segments: 6, Rings: 3}, MaterialLibrary: "scene.mtl"}); err != nil {
+		t.Fatalf("error exporting: %v", err)
+	}
+	f := parseOBJ(t, out.String())
+
+	vertices := 7 * 4
+	expectedLines := []string{"mtllib scene.mtl", "o ball", "usemtl red"}
+	if !strings.HasPrefix(strings.Join(f.lines, "\n"), strings.Join(expectedLines, "\n")) ||
+		f.lines[2+2*vertices+2*6*2+1] != "o mirrored" || f.lines[2+2*vertices+2*6*2+2] != "usemtl default" {
+		t.Errorf("wrong objects. got:\n%s", out.String())
+	}
+	if len(f.positions) != 2*vertices || len(f.normals) != 2*vertices || len(f.faces) != 2*2*6*2 {
+		t.Fatalf("wrong counts. got=%d positions, %d normals, %d faces", len(f.positions), len(f.normals), len(f.faces))
+	}
+
+	// Z is flipped and every face of both spheres, even the mirrored one, faces outwards.
+	centers := []transform.Vec3{{X: 1, Y: 2, Z: -3}, {Z: 5}}
+	radii := []float64{2, 1}
+	for i, p := range f.positions {
+		sphere := i / vertices
+		if d := p.Sub(centers[sphere]).Length(); math.Abs(d-radii[sphere]) > 1e-4 {
+			t.Errorf("vertex %d at %v is %v from the center of its sphere", i, p, d)
+		}
+	}
+	for i, face := range f.faces {
+		a, b, c := f.positions[face[0]], f.positions[face[1]], f.positions[face[2]]
+		center := centers[face[0]/vertices]
+		normal := b.Sub(a).Cross(c.Sub(a))
+		if normal.Dot(a.Add(b).Add(c).Scale(1.0/3).Sub(center)) <= 0 {
+			t.Errorf("face %d faces inwards: %v", i, face)
+		}
+		if f.normals[face[0]].Dot(a.Sub(center)) <= 0 {
+			t.Errorf("normal of face %d points inwards", i)
+		}
+	}
+}
+
+func TestMTL(t *testing.T) {
+	values := evaluate(t, `MATERIAL red = {color: [1, 0, 0], diffuseIntensity: 0.5, specularIntensity: 0.25}
+SPHERE a = {radius: 1, material: red}
+SPHERE b = {radius: 1, material: {color: [0, 1, 0]}}
+SPHERE c = {radius: 1}
+SPHERE d = {radius: 1, material: red}
+SPHERE e = {radius: 1, material: {transparency: 0.75, ior: 1.5}}
+SPHERE f = {radius: 1, material: {reflectivity: 1}}
+SPHERE g = {radius: 1, material: {color: [0, 0, 0], emission: [2, 1, 0]}}
+`)
+	var out bytes.Buffer
+	if err := MTL(&out, values); err != nil {
+		t.Fatalf("error exporting: %v", err)
+	}
+
+	expected := `# Exported by sdl.
+
+newmtl red
+Ka 0.1 0 0
+Kd 0.5 0 0
+Ks 0.25 0.25 0.25
+Ns 32
+illum 2
+
+newmtl b_material
+Ka 0 0.1 0
+Kd 0 0.9 0
+Ks 0 0 0
+Ns 32
+illum 1
+
+newmtl default
+Ka 0.1 0.1 0.1
+Kd 0.9 0.9 0.9
+Ks 0 0 0
+Ns 32
+illum 1
+
+newmtl e_material
+Ka 0.1 0.1 0.1
+Kd 0.9 0.9 0.9
+Ks 0 0 0
+Ns 32
+d 0.25
+Ni 1.5
+illum 7
+
+newmtl f_material
+Ka 0.1 0.1 0.1
+Kd 0.9 0.9 0.9
+Ks 0 0 0
+Ns 32
+illum 3
+
+newmtl g_material
+Ka 0 0 0
+Kd 0 0 0
+Ks 0 0 0
+Ns 32
+Ke 2 1 0
+illum 1
+`
+	if out.String() != expected {
+		t.Errorf("wrong materials.\ngot:\n%s\nwant:\n%s", out.String(), expected)
+	}
+}
+
+func TestOBJErrors(t *testing.T) {
+	values := evaluate(t, "SPHERE flat = {radius: 1, scale: [1, 0, 1]}")
+	if err := OBJ(&bytes.Buffer{}, values, OBJOptions{}); err == nil || err.Error() != "sphere flat has a transform which cannot be inverted" {
+		t.Errorf("wrong error. got=%v", err)
+	}
+	if err := OBJ(&bytes.Buffer{}, values, OBJOptions{Tessellation: Tessellation{Segments: 2, Rings: 2}}); err == nil {
+		t.Errorf("expected an error for too few segments")
+	}
+}
@@ -0,0 +1,210 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	"github.com/kacperkrolak/scene-description-language/evaluator"
+	"github.com/kacperkrolak/scene-description-language/scene"
+	"github.com/kacperkrolak/scene-description-language/transform"
+)
+
+// POVRay writes the scene as POV-Ray 3.7 source. POV-Ray uses the same
+// left-handed coordinates as SDL, so positions and transforms are kept.
+//
+// Groups are flattened into the transforms of their objects. Named materials
+// are declared once as textures called T_ followed by their name, with the
+// intensities in the finish; the CAMERA ambient intensity becomes the ambient
+// light. The color of a light is scaled by its diffuse intensity, as POV-Ray
+// has no separate intensity for highlights. Spot lights become spotlights,
+// directional lights parallel lights placed far behind the scene, and lights
+// with a shape area lights, which are circular for disks and also oriented
+// towards every surface for spheres.
+func POVRay(w io.Writer, values evaluator.EvaluatedValues) error {
+	s, err := scene.New(values)
+	if err != nil {
+		return err
+	}
+
+	p := &povPrinter{}
+	p.printf("// Exported by sdl.\n#version 3.7;\n\n")
+	ambient := s.Camera.AmbientIntensity
+	p.printf("global_settings {\n    assumed_gamma 1.0\n    ambient_light rgb %s\n}\n", p.vector(transform.Vec3{X: ambient, Y: ambient, Z: ambient}))
+	p.camera(s.Camera)
+
+	declared := make(map[string]bool)
+	for _, sphere := range s.Spheres {
+		if name := sphere.Material.Name; name != "" && !declared[name] {
+			declared[name] = true
+			p.printf("\n#declare T_%s = texture {\n", name)
+			p.texture(sphere.Material, "    ")
+			p.printf("};\n")
+		}
+	}
+
+	for _, light := range s.Lights {
+		p.light(light)
+	}
+
+	for _, sphere := range s.Spheres {
+		p.printf("\n// %s\nsphere {\n    <0, 0, 0>, %s\n", sphere.Name, p.number(sphere.Radius))
+		if sphere.Material.Name != "" {
+			p.printf("    texture { T_%s }\n", sphere.Material.Name)
+		} else {
+			p.printf("    texture {\n")
+			p.texture(sphere.Material, "        ")
+			p.printf("    }\n")
+		}
+		if sphere.Material.IOR != 1 {
+			p.printf("    interior { ior %s }\n", p.number(sphere.Material.IOR))
+		}
+		p.matrix(sphere.Transform)
+		p.printf("}\n")
+	}
+
+	if p.err != nil {
+		return p.err
+	}
+	_, err = w.Write(p.out.Bytes())
+	return err
+}
+
+// povPrinter writes POV-Ray source, remembering the first number which cannot be written.
+type povPrinter struct {
+	out bytes.Buffer
+	err error
+}
+
+func (p *povPrinter) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&p.out, format, args...)
+}
+
+// camera writes a perspective camera looking along Z with the vertical field
+// of view of the scene, and the width of the image following its aspect ratio.
+// A camera with an aperture and a focal distance has focal blur.
+func (p *povPrinter) camera(c scene.Camera) {
+	direction := 0.5 / math.Tan(transform.Radians(c.FieldOfView)/2)
+	p.printf("\ncamera {\n    perspective\n    location <0, 0, 0>\n")
+	p.printf("    direction %s\n", p.vector(transform.Vec3{Z: direction}))
+	p.printf("    up <0, 1, 0>\n    right x*image_width/image_height\n")
+	if c.FocalDistance != 0 {
+		p.printf("    focal_point %s\n", p.vector(transform.Vec3{Z: c.FocalDistance}))
+		if c.Aperture > 0 {
+			p.printf("    aperture %s\n    blur_samples %d\n", p.number(c.Aperture), povBlurSamples)
+		}
+	}
+	p.matrix(c.Transform)
+	p.printf("}\n")
+}
+
+// light writes a light source.
+func (p *povPrinter) light(light scene.Light) {
+	position := light.Position
+	if light.Kind == "directional" {
+		position = light.Direction.Scale(-povSunDistance)
+	}
+	p.printf("\n// %s\nlight_source {\n    %s\n    color rgb %s\n", light.Name, p.vector(position), p.color(light.Color.Scale(light.DiffuseIntensity)))
+
+	switch light.Kind {
+	case "directional":
+		p.printf("    parallel\n    point_at <0, 0, 0>\n")
+	case "spot":
+		p.printf("    spotlight\n    radius %s\n    falloff %s\n    tightness 0\n", p.number(math.Max(0, light.ConeAngle-light.Falloff)), p.number(light.ConeAngle))
+		p.printf("    point_at %s\n", p.vector(light.Position.Add(light.Direction)))
+	}
+
+	// The lights of an area light lie on a grid along both axes. Directional
+	// lights have no shape.
+	if shaped := light.Shape == "sphere" || light.Shape == "disk" || light.Shape == "rectangle"; shaped && light.Kind != "directional" {
+		n := int(math.Max(1, math.Ceil(math.Sqrt(float64(light.Samples)))))
+		x, y := transform.Vec3{X: 2 * light.Radius}, transform.Vec3{Y: 2 * light.Radius}
+		if light.Shape == "rectangle" {
+			x, y = transform.Vec3{X: light.Width}, transform.Vec3{Y: light.Height}
+		}
+		p.printf("    area_light %s, %s, %d, %d\n    adaptive 1\n    jitter\n",
+			p.vector(light.Transform.MulDirection(x)), p.vector(light.Transform.MulDirection(y)), n, n)
+		if light.Shape != "rectangle" {
+			p.printf("    circular\n")
+		}
+		if light.Shape == "sphere" {
+			p.printf("    orient\n")
+		}
+	}
+	p.printf("}\n")
+}
+
+// povSunDistance is how far behind the scene directional lights are placed.
+const povSunDistance = 10000
+
+// povBlurSamples is the number of rays POV-Ray traces through the lens for each pixel.
+const povBlurSamples = 16
+
+// texture writes the color and the finish of the material. The size of
+// highlights in POV-Ray is given by a roughness, the inverse of the shininess.
+func (p *povPrinter) texture(m scene.Material, indent string) {
+	if m.Transparency > 0 {
+		c := m.Color
+		p.printf("%spigment { color rgbt <%s, %s, %s, %s> }\n", indent, p.number(c.R), p.number(c.G), p.number(c.B), p.number(m.Transparency))
+	} else {
+		p.printf("%spigment { color rgb %s }\n", indent, p.color(m.Color))
+	}
+
+	p.printf("%sfinish { ambient %s diffuse %s specular %s", indent,
+		p.number(m.AmbientIntensity), p.number(m.DiffuseIntensity), p.number(m.SpecularIntensity))
+	if m.Shininess > 0 {
+		p.printf(" roughness %s", p.number(1/m.Shininess))
+	}
+	// Transparent surfaces reflect more at grazing angles, up to all of the light they let through.
+	if m.Transparency > 0 {
+		p.printf(" reflection { %s, %s fresnel on } conserve_energy", p.number(m.Reflectivity), p.number(m.Reflectivity+m.Transparency))
+	} else if m.Reflectivity > 0 {
+		p.printf(" reflection %s", p.number(m.Reflectivity))
+	}
+	if m.Emission != (scene.Color{}) {
+		p.printf(" emission rgb %s", p.color(m.Emission))
+	}
+	p.printf(" }\n")
+}
+
+// matrix writes the transform, unless it is the identity. POV-Ray multiplies
+// row vectors by matrices, so the rotation and scale are transposed and the
+// translation comes last. Transforms which only move the object are written
+// as translations.
+func (p *povPrinter) matrix(m transform.Mat4) {
+	if m == transform.Identity() {
+		return
+	}
+	if m == transform.Translation(m.Position()) {
+		p.printf("    translate %s\n", p.vector(m.Position()))
+		return
+	}
+
+	p.printf("    matrix <")
+	for row := 0; row < 4; row++ {
+		for column := 0; column < 3; column++ {
+			if row > 0 || column > 0 {
+				p.printf(", ")
+			}
+			p.printf("%s", p.number(m[column][row]))
+		}
+	}
+	p.printf(">\n")
+}
+
+func (p *povPrinter) vector(v transform.Vec3) string {
+	return "<" + p.number(v.X) + ", " + p.number(v.Y) + ", " + p.number(v.Z) + ">"
+}
+
+func (p *povPrinter) color(c scene.Color) string {
+	return p.vector(transform.Vec3{X: c.R, Y: c.G, Z: c.B})
+}
+
+func (p *povPrinter) number(x float64) string {
+	if (math.IsNaN(x) || math.IsInf(x, 0)) && p.err == nil {
+		p.err = fmt.Errorf("cannot export %v", x)
+	}
+	return strconv.FormatFloat(x, 'g', -1, 64)
+}
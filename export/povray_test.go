@@ -0,0 +1,61 @@
+package export
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kacperkrolak/scene-description-language/evaluator"
+)
+
+// TestPOVRay compares the export of every scene in testdata/povray
+// with the .pov file next to it. Run the tests with -update to rewrite them.
+func TestPOVRay(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "povray", "*.sdl"))
+	if err != nil || len(inputs) == 0 {
+		t.Fatalf("no scenes found: %v", err)
+	}
+
+	for _, input := range inputs {
+		t.Run(filepath.Base(input), func(t *testing.T) {
+			source, err := os.ReadFile(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var out bytes.Buffer
+			if err := POVRay(&out, evaluate(t, string(source))); err != nil {
+				t.Fatalf("error exporting: %v", err)
+			}
+
+			golden := strings.TrimSuffix(input, ".sdl") + ".pov"
+			if *update {
+				if err := os.WriteFile(golden, out.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			expected, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != string(expected) {
+				t.Errorf("wrong export of %s.\ngot:\n%s\nwant:\n%s", input, out.String(), expected)
+			}
+		})
+	}
+}
+
+func TestPOVRayErrors(t *testing.T) {
+	if err := POVRay(&bytes.Buffer{}, evaluate(t, "SPHERE s = {}")); err == nil || err.Error() != "SPHERE s: radius: missing required property" {
+		t.Errorf("wrong error. got=%v", err)
+	}
+
+	values := evaluate(t, "SPHERE s = {radius: 1}")
+	values.Nodes[0].Entity.Value.(*evaluator.Dictionary).Properties["radius"] = &evaluator.Number{Value: math.NaN()}
+	if err := POVRay(&bytes.Buffer{}, values); err == nil || err.Error() != "cannot export NaN" {
+		t.Errorf("wrong error. got=%v", err)
+	}
+}
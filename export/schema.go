@@ -0,0 +1,171 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/kacperkrolak/scene-description-language/scene"
+)
+
+// SchemaPath is where the schema is published in the repository.
+const SchemaPath = "export/scene.schema.json"
+
+// Schema returns the JSON Schema of the JSON export, built from the
+// descriptions of the classes in scene.Classes. The YAML export
+// holds the same document, so it can be checked with it too.
+func Schema() []byte {
+	defs := newObject()
+	defs.set("vector", numbers(3, "[x, y, z], or [r, g, b] for colors."))
+	defs.set("quaternion", numbers(4, "A rotation as a quaternion [x, y, z, w]."))
+	defs.set("matrix", schema(
+		"description", "4 rows of 4 numbers, or the 16 numbers row by row.",
+		"oneOf", []interface{}{
+			schema("type", "array", "items", numbers(4, ""), "minItems", 4.0, "maxItems", 4.0),
+			numbers(16, ""),
+		},
+	))
+	defs.set("reference", schema(
+		"description", "The name of another object of the scene.",
+		"type", "object",
+		"properties", schema("ref", schema("type", "string")),
+		"required", []interface{}{"ref"},
+		"additionalProperties", false,
+	))
+	for _, class := range scene.Classes {
+		defs.set(class.Name, classValue(class))
+	}
+
+	var classes []interface{}
+	for _, class := range scene.Classes {
+		classes = append(classes, classObject(class))
+	}
+
+	root := schema(
+		"$schema", "https://json-schema.org/draft/2020-12/schema",
+		"title", "Evaluated SDL scene",
+		"description", "The objects of a scene in the order they were defined, as written by sdl export.",
+		"type", "object",
+		"properties", schema("objects", schema("type", "array", "items", schema("oneOf", classes))),
+		"required", []interface{}{"objects"},
+		"additionalProperties", false,
+		"$defs", defs,
+	)
+
+	data, _ := json.Marshal(root)
+	var out bytes.Buffer
+	json.Indent(&out, data, "", "  ")
+	out.WriteByte('\n')
+	return out.Bytes()
+}
+
+// schema returns an object with the given keys and values, in order.
+func schema(members ...interface{}) *object {
+	o := newObject()
+	for i := 0; i < len(members); i += 2 {
+		o.set(members[i].(string), members[i+1])
+	}
+	return o
+}
+
+// numbers describes an array of n numbers.
+func numbers(n int, description string) *object {
+	o := newObject()
+	if description != "" {
+		o.set("description", description)
+	}
+	o.set("type", "array")
+	o.set("items", schema("type", "number"))
+	o.set("minItems", float64(n))
+	o.set("maxItems", float64(n))
+	return o
+}
+
+func ref(name string) *object {
+	return schema("$ref", "#/$defs/"+name)
+}
+
+// classObject describes an object of the class in the list of objects.
+func classObject(class scene.Class) *object {
+	properties := schema(
+		"name", schema("type", "string"),
+		"class", schema("const", class.Name),
+		"value", ref(class.Name),
+	)
+	required := []interface{}{"name", "class", "value"}
+
+	// Objects with a transform are placed in the scene.
+	if _, ok := class.Property("matrix"); ok {
+		properties.set("world", schema("description", "Transform relative to the origin of the scene.", "$ref", "#/$defs/matrix"))
+		required = append(required, "world")
+	}
+
+	return schema(
+		"description", class.Description,
+		"type", "object",
+		"properties", properties,
+		"required", required,
+		"additionalProperties", false,
+	)
+}
+
+// classValue describes the value of objects of the class.
+func classValue(class scene.Class) *object {
+	if class.Value != scene.PropertiesValue {
+		value := valueSchema(class.Value)
+		value.set("description", class.Description)
+		return value
+	}
+
+	properties := newObject()
+	var required []interface{}
+	for _, property := range class.Properties {
+		value := valueSchema(property.Type)
+		value.set("description", property.Description)
+		if len(property.Values) > 0 {
+			var values []interface{}
+			for _, v := range property.Values {
+				values = append(values, v)
+			}
+			value.set("enum", values)
+		}
+		if property.Minimum != nil {
+			value.set("minimum", *property.Minimum)
+		}
+		properties.set(property.Name, value)
+		if property.Required {
+			required = append(required, property.Name)
+		}
+	}
+
+	o := schema("description", class.Description, "type", "object", "properties", properties)
+	if len(required) > 0 {
+		o.set("required", required)
+	}
+	o.set("additionalProperties", false)
+	return o
+}
+
+func valueSchema(valueType scene.ValueType) *object {
+	switch valueType {
+	case scene.NumberValue:
+		return schema("type", "number")
+	case scene.IntegerValue:
+		return schema("type", "integer")
+	case scene.StringValue:
+		return schema("type", "string")
+	case scene.ColorValue, scene.VectorValue:
+		return ref("vector")
+	case scene.RotationValue:
+		return schema("oneOf", []interface{}{ref("vector"), ref("quaternion")})
+	case scene.ScaleValue:
+		return schema("oneOf", []interface{}{schema("type", "number"), ref("vector")})
+	case scene.MatrixValue:
+		return ref("matrix")
+	case scene.ReferencesValue:
+		return schema("type", "array", "items", ref("reference"))
+	case scene.MaterialValue:
+		return ref(string(scene.MaterialValue))
+	default:
+		return schema()
+	}
+}
@@ -0,0 +1,268 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kacperkrolak/scene-description-language/evaluator"
+	"github.com/kacperkrolak/scene-description-language/scene"
+	"github.com/kacperkrolak/scene-description-language/token"
+	"github.com/kacperkrolak/scene-description-language/transform"
+)
+
+// View is a plane onto which an SVG drawing projects the scene.
+type View string
+
+const (
+	TopView   View = "top"   // Looking down, with X to the right and Z up the page.
+	FrontView View = "front" // Looking along Z, with X to the right and Y up.
+	SideView  View = "side"  // Looking from the right, with Z to the right and Y up.
+)
+
+// ParseView returns the view with the given name.
+func ParseView(s string) (View, error) {
+	switch View(s) {
+	case TopView, FrontView, SideView:
+		return View(s), nil
+	}
+	return "", fmt.Errorf("unknown view %q, expected top, front or side", s)
+}
+
+// axes returns the directions of the scene shown to the right and up the page,
+// and the direction the view looks in.
+func (v View) axes() (right, up, forward transform.Vec3) {
+	switch v {
+	case TopView:
+		return transform.Vec3{X: 1}, transform.Vec3{Z: 1}, transform.Vec3{Y: -1}
+	case SideView:
+		return transform.Vec3{Z: 1}, transform.Vec3{Y: 1}, transform.Vec3{X: -1}
+	default:
+		return transform.Vec3{X: 1}, transform.Vec3{Y: 1}, transform.Vec3{Z: 1}
+	}
+}
+
+// SVGOptions controls the drawing of a scene as SVG.
+type SVGOptions struct {
+	View        View    // Empty uses FrontView.
+	Size        float64 // Length in pixels of the longer side of the area with the objects, zero for 800.
+	AspectRatio float64 // Width divided by height of the camera image, zero for 4/3.
+}
+
+// SVG draws an orthographic projection of the scene: spheres as their
+// outlines filled with their color, lights as dots and the camera as the
+// edges of its field of view, each labelled with its name. The drawing is
+// fitted to the objects. The frustum of the camera reaches to its focal
+// distance, or else to the farthest object.
+func SVG(w io.Writer, values evaluator.EvaluatedValues, options SVGOptions) error {
+	if options.View == "" {
+		options.View = FrontView
+	}
+	if _, err := ParseView(string(options.View)); err != nil {
+		return err
+	}
+	if options.Size == 0 {
+		options.Size = 800
+	}
+	if options.AspectRatio == 0 {
+		options.AspectRatio = 4.0 / 3
+	}
+
+	s, err := scene.New(values)
+	if err != nil {
+		return err
+	}
+
+	d := newDrawing(s, options)
+	_, err = w.Write(d.svg())
+	return err
+}
+
+// point is a position on the plane of a view, in scene units.
+type point struct{ x, y float64 }
+
+// ellipse is the outline of a sphere on the plane of a view.
+type ellipse struct {
+	name          string
+	center        point
+	rx, ry        float64
+	angle         float64 // Angle of the rx axis from the horizontal, counter-clockwise in degrees.
+	width, height float64 // Half of the size of the bounding box.
+	depth         float64 // Distance along the direction of the view.
+	color         scene.Color
+}
+
+type marker struct {
+	name  string
+	at    point
+	color scene.Color
+}
+
+type drawing struct {
+	options  SVGOptions
+	spheres  []ellipse
+	lights   []marker
+	camera   point
+	corners  [4]point // Far corners of the field of view.
+	min, max point    // Bounds of the drawing in scene units.
+}
+
+func newDrawing(s *scene.Scene, options SVGOptions) *drawing {
+	right, up, forward := options.View.axes()
+	project := func(p transform.Vec3) point { return point{p.Dot(right), p.Dot(up)} }
+	d := &drawing{options: options}
+
+	for _, sphere := range s.Spheres {
+		// The projection of the sphere is an ellipse whose axes follow
+		// from the eigenvectors of the projected transform times its transpose.
+		var a, b, c float64
+		for _, axis := range []transform.Vec3{{X: 1}, {Y: 1}, {Z: 1}} {
+			column := sphere.Transform.MulDirection(axis).Scale(sphere.Radius)
+			u, v := column.Dot(right), column.Dot(up)
+			a, b, c = a+u*u, b+u*v, c+v*v
+		}
+		mean := (a + c) / 2
+		spread := math.Hypot((a-c)/2, b)
+		d.spheres = append(d.spheres, ellipse{
+			name:   sphere.Name,
+			center: project(sphere.Center()),
+			rx:     math.Sqrt(mean + spread),
+			ry:     math.Sqrt(math.Max(0, mean-spread)),
+			angle:  transform.Degrees(math.Atan2(2*b, a-c) / 2),
+			width:  math.Sqrt(a),
+			height: math.Sqrt(c),
+			depth:  sphere.Center().Dot(forward),
+			color:  sphere.Material.Color,
+		})
+	}
+	// Farther spheres are drawn first, so nearer ones cover them.
+	sort.SliceStable(d.spheres, func(i, j int) bool { return d.spheres[i].depth > d.spheres[j].depth })
+
+	for _, light := range s.Lights {
+		d.lights = append(d.lights, marker{name: light.Name, at: project(light.Position), color: light.Color})
+	}
+
+	camera := s.Camera.Transform.Position()
+	length := s.Camera.FocalDistance
+	if length == 0 {
+		for _, sphere := range s.Spheres {
+			length = math.Max(length, sphere.Center().Sub(camera).Length())
+		}
+		for _, light := range s.Lights {
+			length = math.Max(length, light.Position.Sub(camera).Length())
+		}
+	}
+	if length == 0 {
+		length = 1
+	}
+	height := math.Tan(transform.Radians(s.Camera.FieldOfView)/2) * length
+	width := height * options.AspectRatio
+	d.camera = project(camera)
+	for i, corner := range [4][2]float64{{-1, -1}, {1, -1}, {1, 1}, {-1, 1}} {
+		local := transform.Vec3{X: corner[0] * width, Y: corner[1] * height, Z: length}
+		d.corners[i] = project(s.Camera.Transform.MulPoint(local))
+	}
+
+	d.min, d.max = d.camera, d.camera
+	d.include(d.corners[:]...)
+	for _, e := range d.spheres {
+		d.include(point{e.center.x - e.width, e.center.y - e.height}, point{e.center.x + e.width, e.center.y + e.height})
+	}
+	for _, light := range d.lights {
+		d.include(light.at)
+	}
+	return d
+}
+
+func (d *drawing) include(points ...point) {
+	for _, p := range points {
+		d.min = point{math.Min(d.min.x, p.x), math.Min(d.min.y, p.y)}
+		d.max = point{math.Max(d.max.x, p.x), math.Max(d.max.y, p.y)}
+	}
+}
+
+// svgMargin is the space around the objects, in pixels, left for labels.
+const svgMargin = 40
+
+func (d *drawing) svg() []byte {
+	extent := math.Max(d.max.x-d.min.x, d.max.y-d.min.y)
+	if extent == 0 {
+		extent = 1
+	}
+	scale := d.options.Size / extent
+	// The page grows downwards, so the vertical axis is flipped.
+	page := func(p point) point {
+		return point{(p.x-d.min.x)*scale + svgMargin, (d.max.y-p.y)*scale + svgMargin}
+	}
+	width := (d.max.x-d.min.x)*scale + 2*svgMargin
+	height := (d.max.y-d.min.y)*scale + 2*svgMargin
+
+	var out bytes.Buffer
+	out.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	fmt.Fprintf(&out, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%s\" height=\"%s\" viewBox=\"0 0 %s %s\" font-family=\"sans-serif\" font-size=\"12\">\n",
+		svgNumber(width), svgNumber(height), svgNumber(width), svgNumber(height))
+	fmt.Fprintf(&out, "  <title>%s view</title>\n", d.options.View)
+
+	out.WriteString("  <g id=\"spheres\" stroke=\"black\" fill-opacity=\"0.5\">\n")
+	for _, e := range d.spheres {
+		c := page(e.center)
+		fmt.Fprintf(&out, "    <ellipse cx=\"%s\" cy=\"%s\" rx=\"%s\" ry=\"%s\"", svgNumber(c.x), svgNumber(c.y), svgNumber(e.rx*scale), svgNumber(e.ry*scale))
+		if angle := svgNumber(-e.angle); angle != "0" {
+			fmt.Fprintf(&out, " transform=\"rotate(%s %s %s)\"", angle, svgNumber(c.x), svgNumber(c.y))
+		}
+		fmt.Fprintf(&out, " fill=\"%s\"/>\n", svgColor(e.color))
+	}
+	out.WriteString("  </g>\n")
+
+	out.WriteString("  <g id=\"lights\" stroke=\"black\">\n")
+	for _, light := range d.lights {
+		p := page(light.at)
+		fmt.Fprintf(&out, "    <circle cx=\"%s\" cy=\"%s\" r=\"5\" fill=\"%s\"/>\n", svgNumber(p.x), svgNumber(p.y), svgColor(light.color))
+	}
+	out.WriteString("  </g>\n")
+
+	camera := page(d.camera)
+	var corners []string
+	out.WriteString("  <g id=\"camera\" stroke=\"gray\" fill=\"none\">\n")
+	for _, corner := range d.corners {
+		p := page(corner)
+		fmt.Fprintf(&out, "    <line x1=\"%s\" y1=\"%s\" x2=\"%s\" y2=\"%s\"/>\n", svgNumber(camera.x), svgNumber(camera.y), svgNumber(p.x), svgNumber(p.y))
+		corners = append(corners, svgNumber(p.x)+","+svgNumber(p.y))
+	}
+	fmt.Fprintf(&out, "    <polygon points=\"%s\"/>\n", strings.Join(corners, " "))
+	out.WriteString("  </g>\n")
+
+	out.WriteString("  <g id=\"labels\">\n")
+	for _, e := range d.spheres {
+		c := page(e.center)
+		fmt.Fprintf(&out, "    <text x=\"%s\" y=\"%s\" text-anchor=\"middle\">%s</text>\n", svgNumber(c.x), svgNumber(c.y), html.EscapeString(e.name))
+	}
+	for _, light := range d.lights {
+		p := page(light.at)
+		fmt.Fprintf(&out, "    <text x=\"%s\" y=\"%s\">%s</text>\n", svgNumber(p.x+8), svgNumber(p.y-8), html.EscapeString(light.name))
+	}
+	fmt.Fprintf(&out, "    <text x=\"%s\" y=\"%s\">%s</text>\n", svgNumber(camera.x+8), svgNumber(camera.y-8), token.CAMERA)
+	out.WriteString("  </g>\n</svg>\n")
+	return out.Bytes()
+}
+
+// svgColor returns the color in the rgb() syntax of CSS.
+func svgColor(c scene.Color) string {
+	component := func(x float64) string { return strconv.Itoa(int(math.Round(clamp(x) * 255))) }
+	return "rgb(" + component(c.R) + "," + component(c.G) + "," + component(c.B) + ")"
+}
+
+// svgNumber writes a coordinate rounded to a hundredth of a pixel.
+func svgNumber(x float64) string {
+	s := strconv.FormatFloat(x, 'f', 2, 64)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
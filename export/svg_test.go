@@ -0,0 +1,120 @@
+package export
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+)
+
+type svgDocument struct {
+	Width    string `xml:"width,attr"`
+	Height   string `xml:"height,attr"`
+	Title    string `xml:"title"`
+	Ellipses []struct {
+		CX        string `xml:"cx,attr"`
+		CY        string `xml:"cy,attr"`
+		RX        string `xml:"rx,attr"`
+		RY        string `xml:"ry,attr"`
+		Transform string `xml:"transform,attr"`
+		Fill      string `xml:"fill,attr"`
+	} `xml:"g>ellipse"`
+	Circles []struct {
+		CX string `xml:"cx,attr"`
+		CY string `xml:"cy,attr"`
+	} `xml:"g>circle"`
+	Lines []struct {
+		X1 string `xml:"x1,attr"`
+		Y1 string `xml:"y1,attr"`
+	} `xml:"g>line"`
+	Texts []string `xml:"g>text"`
+}
+
+const svgInput = `MATERIAL red = {color: [1, 0, 0]}
+SPHERE near = {radius: 1, material: red}
+SPHERE egg = {radius: 1, scale: [2, 1, 1], rotation: [0, 0, 90], position: [0, 0, 4]}
+LIGHT lamp = {position: [0, 4, 2], color: [1, 1, 0]}
+MODIFY CAMERA {position: [0, 0, -4], fov: 90, focalDistance: 4}
+`
+
+func drawSVG(t *testing.T, options SVGOptions) svgDocument {
+	t.Helper()
+	var out bytes.Buffer
+	if err := SVG(&out, evaluate(t, svgInput), options); err != nil {
+		t.Fatalf("error exporting: %v", err)
+	}
+
+	var doc svgDocument
+	if err := xml.Unmarshal(out.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid SVG: %v\n%s", err, out.String())
+	}
+	return doc
+}
+
+func TestSVGFront(t *testing.T) {
+	// The frustum spans 8 units both ways, from -4 to 4, so a unit is
+	// 100 pixels, with a margin of 40. The light is at its top edge.
+	doc := drawSVG(t, SVGOptions{View: FrontView, AspectRatio: 1})
+
+	if doc.Title != "front view" || doc.Width != "880" || doc.Height != "880" {
+		t.Errorf("wrong size. got=%s %sx%s", doc.Title, doc.Width, doc.Height)
+	}
+	if len(doc.Ellipses) != 2 {
+		t.Fatalf("wrong number of spheres. got=%d", len(doc.Ellipses))
+	}
+
+	// The egg is farther from the front, so it is drawn first. Its longer
+	// axis is turned upright.
+	egg, near := doc.Ellipses[0], doc.Ellipses[1]
+	if egg.CX != "440" || egg.CY != "440" || egg.RX != "200" || egg.RY != "100" || egg.Transform != "rotate(-90 440 440)" || egg.Fill != "rgb(255,255,255)" {
+		t.Errorf("wrong egg. got=%+v", egg)
+	}
+	if near.RX != "100" || near.RY != "100" || near.Transform != "" || near.Fill != "rgb(255,0,0)" {
+		t.Errorf("wrong sphere. got=%+v", near)
+	}
+
+	if len(doc.Circles) != 1 || doc.Circles[0].CX != "440" || doc.Circles[0].CY != "40" {
+		t.Errorf("wrong lights. got=%+v", doc.Circles)
+	}
+	if len(doc.Lines) != 4 || doc.Lines[0].X1 != "440" || doc.Lines[0].Y1 != "440" {
+		t.Errorf("wrong camera. got=%+v", doc.Lines)
+	}
+
+	expected := []string{"egg", "near", "lamp", "CAMERA"}
+	if len(doc.Texts) != len(expected) {
+		t.Fatalf("wrong labels. got=%v", doc.Texts)
+	}
+	for i, text := range expected {
+		if doc.Texts[i] != text {
+			t.Errorf("wrong label %d. got=%q, want=%q", i, doc.Texts[i], text)
+		}
+	}
+}
+
+func TestSVGTop(t *testing.T) {
+	// Seen from above, Z goes up the page: the camera at -4 is at the bottom,
+	// and the egg, whose long axis is vertical, is a circle at the top.
+	// The objects span 9 units, from -4 to the top of the egg at 5.
+	doc := drawSVG(t, SVGOptions{View: TopView, Size: 90, AspectRatio: 1})
+
+	if doc.Width != "160" || doc.Height != "170" {
+		t.Errorf("wrong size. got=%sx%s", doc.Width, doc.Height)
+	}
+	egg := doc.Ellipses[1]
+	if egg.RX != "10" || egg.RY != "10" || egg.CY != "50" {
+		t.Errorf("wrong egg. got=%+v", egg)
+	}
+	if doc.Lines[0].Y1 != "130" {
+		t.Errorf("wrong camera. got=%+v", doc.Lines[0])
+	}
+}
+
+func TestParseView(t *testing.T) {
+	for _, name := range []string{"top", "front", "side"} {
+		if view, err := ParseView(name); err != nil || string(view) != name {
+			t.Errorf("wrong view for %q. got=%q, %v", name, view, err)
+		}
+	}
+	if _, err := ParseView("back"); err == nil || err.Error() != `unknown view "back", expected top, front or side` {
+		t.Errorf("wrong error. got=%v", err)
+	}
+}
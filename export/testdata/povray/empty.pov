@@ -0,0 +1,15 @@
+// Exported by sdl.
+#version 3.7;
+
+global_settings {
+    assumed_gamma 1.0
+    ambient_light rgb <1, 1, 1>
+}
+
+camera {
+    perspective
+    location <0, 0, 0>
+    direction <0, 0, 0.8660254037844387>
+    up <0, 1, 0>
+    right x*image_width/image_height
+}
@@ -0,0 +1,132 @@
+// Exported by sdl.
+#version 3.7;
+
+global_settings {
+    assumed_gamma 1.0
+    ambient_light rgb <0.5, 0.5, 0.5>
+}
+
+camera {
+    perspective
+    location <0, 0, 0>
+    direction <0, 0, 1.2071067811865475>
+    up <0, 1, 0>
+    right x*image_width/image_height
+    focal_point <0, 0, 7>
+    aperture 0.2
+    blur_samples 16
+    translate <0, 1, -2>
+}
+
+#declare T_red = texture {
+    pigment { color rgb <1, 0, 0> }
+    finish { ambient 0.1 diffuse 0.7 specular 0.4 roughness 0.02 }
+};
+
+#declare T_blue = texture {
+    pigment { color rgb <0, 0, 1> }
+    finish { ambient 0.2 diffuse 0.9 specular 0 roughness 0.03125 }
+};
+
+// sun
+light_source {
+    <10, 10, -10>
+    color rgb <0.5, 0.45, 0.4>
+}
+
+// fill
+light_source {
+    <-5, 2, 0>
+    color rgb <1, 1, 1>
+}
+
+// daylight
+light_source {
+    <-2500, 8660.254037844386, -4330.127018922195>
+    color rgb <0.3, 0.3, 0.27>
+    parallel
+    point_at <0, 0, 0>
+}
+
+// torch
+light_source {
+    <0, 5, 0>
+    color rgb <1, 1, 1>
+    spotlight
+    radius 20
+    falloff 25
+    tightness 0
+    point_at <0, 4, 6.123233995736757e-17>
+}
+
+// panel
+light_source {
+    <0, 6, 5>
+    color rgb <1, 1, 1>
+    area_light <2, 0, 0>, <0, 6.123233995736757e-17, 1>, 3, 3
+    adaptive 1
+    jitter
+}
+
+// bulb
+light_source {
+    <3, 3, 3>
+    color rgb <1, 1, 1>
+    area_light <0.6, 0, 0>, <0, 0.6, 0>, 4, 4
+    adaptive 1
+    jitter
+    circular
+    orient
+}
+
+// ball
+sphere {
+    <0, 0, 0>, 1
+    texture { T_red }
+    translate <0, 1, 5>
+}
+
+// other
+sphere {
+    <0, 0, 0>, 0.5
+    texture { T_red }
+    translate <2, 0.5, 6>
+}
+
+// sky
+sphere {
+    <0, 0, 0>, 3
+    texture { T_blue }
+    translate <-4, 3, 10>
+}
+
+// glass
+sphere {
+    <0, 0, 0>, 1
+    texture {
+        pigment { color rgbt <1, 1, 1, 0.9> }
+        finish { ambient 0.1 diffuse 0.9 specular 0 roughness 0.03125 reflection { 0.1, 1 fresnel on } conserve_energy }
+    }
+    interior { ior 1.5 }
+    translate <-2, 1, 4>
+}
+
+// mirror
+sphere {
+    <0, 0, 0>, 1
+    texture {
+        pigment { color rgb <0.9, 0.9, 0.9> }
+        finish { ambient 0.1 diffuse 0.9 specular 0 roughness 0.03125 reflection 0.8 }
+    }
+    translate <3, 1, 8>
+}
+
+// lamp
+sphere {
+    <0, 0, 0>, 0.25
+    texture {
+        pigment { color rgb <0, 0, 0> }
+        finish { ambient 0.1 diffuse 0.9 specular 0 roughness 0.03125 emission rgb <4, 3, 2> }
+    }
+    translate <0, 4, 6>
+}
@@ -0,0 +1,36 @@
+// Exported by sdl.
+#version 3.7;
+
+global_settings {
+    assumed_gamma 1.0
+    ambient_light rgb <1, 1, 1>
+}
+
+camera {
+    perspective
+    location <0, 0, 0>
+    direction <0, 0, 0.8660254037844387>
+    up <0, 1, 0>
+    right x*image_width/image_height
+    matrix <1, 0, 0, 0, 0.9848077530122081, 0.17364817766693033, 0, -0.17364817766693033, 0.9848077530122081, 0, 0, 0>
+}
+
+// plain
+sphere {
+    <0, 0, 0>, 2
+    texture {
+        pigment { color rgb <1, 1, 1> }
+        finish { ambient 0.1 diffuse 0.9 specular 0 roughness 0.03125 }
+    }
+    matrix <1, 0, 0, 0, 0.5, 0, 0, 0, 1, 0, 0, 0>
+}
+
+// tinted
+sphere {
+    <0, 0, 0>, 1
+    texture {
+        pigment { color rgb <0, 1, 0> }
+        finish { ambient 0.1 diffuse 0.9 specular 1 roughness 0.03125 }
+    }
+    matrix <6.123233995736757e-17, 0, -1, 0, 1, 0, 1, 0, 6.123233995736757e-17, 0, 0, 4>
+}
@@ -0,0 +1,160 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/kacperkrolak/scene-description-language/evaluator"
+)
+
+// YAML writes the evaluated scene as a YAML document holding the same
+// values as the JSON export. Objects are written in block style, arrays
+// of numbers and references in flow style, e.g. color: [1, 0, 0].
+// Strings are double-quoted, so they are never read as other types.
+func YAML(w io.Writer, values evaluator.EvaluatedValues) error {
+	doc, err := document(values)
+	if err != nil {
+		return err
+	}
+
+	var y yamlWriter
+	y.members(doc, "", "")
+	if y.err != nil {
+		return y.err
+	}
+	_, err = y.buf.WriteTo(w)
+	return err
+}
+
+type yamlWriter struct {
+	buf bytes.Buffer
+	err error
+}
+
+// members writes one member of the object per line. The first line
+// starts with first instead of indent, so it can follow a "- ".
+func (y *yamlWriter) members(o *object, indent, first string) {
+	for i, key := range o.keys {
+		prefix := indent
+		if i == 0 {
+			prefix = first
+		}
+		y.buf.WriteString(prefix + yamlKey(key) + ":")
+		y.value(o.values[key], indent)
+	}
+}
+
+// value writes the value of a member on the same line if it fits in flow
+// style, or else on the following lines, indented more than its key.
+func (y *yamlWriter) value(v interface{}, indent string) {
+	if flow, ok := y.flow(v, false); ok {
+		y.buf.WriteString(" " + flow + "\n")
+		return
+	}
+
+	y.buf.WriteString("\n")
+	switch v := v.(type) {
+	case *object:
+		y.members(v, indent+"  ", indent+"  ")
+	case []interface{}:
+		y.items(v, indent+"  ")
+	}
+}
+
+func (y *yamlWriter) items(a []interface{}, indent string) {
+	for _, element := range a {
+		if flow, ok := y.flow(element, false); ok {
+			y.buf.WriteString(indent + "- " + flow + "\n")
+			continue
+		}
+
+		switch element := element.(type) {
+		case *object:
+			y.members(element, indent+"  ", indent+"- ")
+		case []interface{}:
+			y.buf.WriteString(indent + "-\n")
+			y.items(element, indent+"  ")
+		}
+	}
+}
+
+// flow returns the value in flow style, if it is a scalar, an empty object,
+// or an array of such values and of objects with a single scalar member,
+// like references. Those objects are written in flow style only inside arrays.
+func (y *yamlWriter) flow(v interface{}, inFlow bool) (string, bool) {
+	switch v := v.(type) {
+	case float64:
+		return yamlNumber(v), true
+	case string:
+		return y.quote(v), true
+	case *object:
+		if len(v.keys) == 0 {
+			return "{}", true
+		}
+		if !inFlow || len(v.keys) > 1 {
+			return "", false
+		}
+		members := make([]string, len(v.keys))
+		for i, key := range v.keys {
+			value := v.values[key]
+			if _, ok := value.(*object); ok {
+				return "", false
+			}
+			if _, ok := value.([]interface{}); ok {
+				return "", false
+			}
+			flow, _ := y.flow(value, true)
+			members[i] = yamlKey(key) + ": " + flow
+		}
+		return "{" + strings.Join(members, ", ") + "}", true
+	case []interface{}:
+		elements := make([]string, len(v))
+		for i, element := range v {
+			flow, ok := y.flow(element, true)
+			if !ok {
+				return "", false
+			}
+			elements[i] = flow
+		}
+		return "[" + strings.Join(elements, ", ") + "]", true
+	default:
+		return "", false
+	}
+}
+
+// quote writes a string as a double-quoted scalar, which has the escapes of JSON.
+func (y *yamlWriter) quote(s string) string {
+	data, err := json.Marshal(s)
+	if err != nil && y.err == nil {
+		y.err = err
+	}
+	return string(data)
+}
+
+// yamlNumber formats a number like JSON does, with a fraction before an
+// exponent, which YAML 1.1 parsers need to read it as a float.
+func yamlNumber(v float64) string {
+	data, _ := json.Marshal(v)
+	s := string(data)
+	if i := strings.IndexAny(s, "eE"); i >= 0 && !strings.Contains(s[:i], ".") {
+		s = s[:i] + ".0" + s[i:]
+	}
+	return s
+}
+
+// yamlReserved are the plain scalars YAML 1.1 parsers read as booleans or null.
+var yamlReserved = map[string]bool{
+	"y": true, "yes": true, "n": true, "no": true, "true": true, "false": true,
+	"on": true, "off": true, "null": true, "~": true,
+}
+
+// yamlKey quotes the keys which would not be read back as the same string.
+func yamlKey(key string) string {
+	if !isName(key) || yamlReserved[strings.ToLower(key)] {
+		data, _ := json.Marshal(key)
+		return string(data)
+	}
+	return key
+}
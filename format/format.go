@@ -0,0 +1,310 @@
+// Package format prints SDL source in its canonical layout.
+package format
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+
+	"github.com/kacperkrolak/scene-description-language/lexer"
+	"github.com/kacperkrolak/scene-description-language/parser"
+	"github.com/kacperkrolak/scene-description-language/token"
+)
+
+// indentation is written once for every level of nesting.
+const indentation = "    "
+
+// SyntaxError is returned for sources which cannot be parsed.
+// Such sources are not formatted, as their structure is not known.
+type SyntaxError struct {
+	Errors []parser.Error
+}
+
+func (e *SyntaxError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.String()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// statementKeywords start a new statement when they appear at the top level.
+var statementKeywords = map[token.TokenType]bool{
+	token.MODIFY:   true,
+	token.INCLUDE:  true,
+	token.NUMBER:   true,
+	token.COLOR:    true,
+	token.MATERIAL: true,
+	token.SPHERE:   true,
+	token.LIGHT:    true,
+	token.GROUP:    true,
+	token.RENDER:   true,
+}
+
+// valueEnds are tokens after which a minus is a binary operator.
+var valueEnds = map[token.TokenType]bool{
+	token.IDENT:    true,
+	token.CAMERA:   true,
+	token.FLOAT:    true,
+	token.STRING:   true,
+	token.RPAREN:   true,
+	token.RBRACKET: true,
+	token.RBRACE:   true,
+}
+
+// Source formats a whole SDL file:
+//   - every statement starts on a new line, blank lines between them are kept but not repeated,
+//   - non-empty properties are written one per line, indented, with a trailing comma,
+//   - arrays stay on one line unless they contain properties or comments,
+//   - binary operators are surrounded by single spaces,
+//   - numbers are written in their shortest form, e.g. 1.50 becomes 1.5 and 2.0 becomes 2,
+//   - comments are kept on the line they were on, either after code or on their own.
+//
+// The order of statements and properties is not changed, and formatting
+// formatted source returns it unchanged.
+func Source(src []byte) ([]byte, error) {
+	p := parser.New(lexer.New(string(src)))
+	p.ParseFile()
+	if errors := p.SyntaxErrors(); len(errors) > 0 {
+		return nil, &SyntaxError{Errors: errors}
+	}
+
+	var tokens []token.Token
+	l := lexer.New(string(src))
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		tokens = append(tokens, tok)
+	}
+
+	pr := &printer{tokens: tokens, prev: -1}
+	pr.print()
+	return pr.buf.Bytes(), nil
+}
+
+// block is a pair of brackets or braces the printer is inside of.
+type block struct {
+	multiline bool
+}
+
+type printer struct {
+	buf    bytes.Buffer
+	tokens []token.Token
+	blocks []block
+	prev   int  // index of the last printed token, -1 before the first one
+	comma  bool // a comma was read but not printed yet
+	// open is set when the last element of a multiline block
+	// was printed without the comma which ends it.
+	open bool
+	line int // line of the last token read, printed or not
+}
+
+func (p *printer) print() {
+	for i := 0; i < len(p.tokens); i++ {
+		tok := p.tokens[i]
+
+		switch tok.Type {
+		case token.COMMA:
+			// Commas are printed with the next element, so trailing commas can be normalized.
+			// A comma already printed before a comment is not repeated.
+			if p.open || !p.inMultiline() {
+				p.comma = true
+			}
+			p.open = false
+			p.line = tok.Pos.Line
+			continue
+		case token.COMMENT:
+			p.printComment(i)
+		case token.RBRACE, token.RBRACKET:
+			p.closeBlock(tok)
+		case token.LBRACE, token.LBRACKET:
+			p.separate(tok)
+			p.buf.WriteString(tok.Literal)
+			if i+1 < len(p.tokens) && isClosing(p.tokens[i+1].Type) {
+				// Empty blocks stay on one line.
+				i++
+				p.buf.WriteString(p.tokens[i].Literal)
+				p.prev = i
+				p.line = p.tokens[i].Pos.Line
+				p.open = p.inMultiline()
+				continue
+			}
+			p.blocks = append(p.blocks, block{multiline: tok.Type == token.LBRACE || p.isMultilineArray(i)})
+			p.open = false
+		case token.STRING:
+			p.separate(tok)
+			p.buf.WriteString(`"` + tok.Literal + `"`)
+		case token.FLOAT:
+			p.separate(tok)
+			p.buf.WriteString(formatNumber(tok.Literal))
+		default:
+			p.separate(tok)
+			p.buf.WriteString(tok.Literal)
+		}
+
+		p.prev = i
+		p.line = tok.Pos.Line
+	}
+
+	if p.prev >= 0 {
+		p.buf.WriteByte('\n')
+	}
+}
+
+func isClosing(tokenType token.TokenType) bool {
+	return tokenType == token.RBRACE || tokenType == token.RBRACKET
+}
+
+// formatNumber rewrites a number literal in its canonical form.
+func formatNumber(literal string) string {
+	value, err := strconv.ParseFloat(literal, 64)
+	if err != nil {
+		return literal
+	}
+	return Number(value)
+}
+
+// Number writes the number in the shortest form which reads back as the same value.
+func Number(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// isMultilineArray reports whether the array opened by the token at index
+// start contains properties or comments, which make it span several lines.
+func (p *printer) isMultilineArray(start int) bool {
+	depth := 0
+	for _, tok := range p.tokens[start:] {
+		switch tok.Type {
+		case token.LBRACKET:
+			depth++
+		case token.RBRACKET:
+			depth--
+			if depth == 0 {
+				return false
+			}
+		case token.LBRACE, token.COMMENT:
+			return true
+		}
+	}
+	return false
+}
+
+// printComment prints the comment at index i, after the code on its line
+// or on a line of its own.
+func (p *printer) printComment(i int) {
+	tok := p.tokens[i]
+	if p.prev < 0 {
+		p.buf.WriteString(tok.Literal)
+		return
+	}
+
+	// The comma ending the element goes before the comment, not inside it.
+	if p.comma || p.open && p.elementEnds(i) {
+		p.buf.WriteByte(',')
+		p.comma = false
+		p.open = false
+	}
+
+	if tok.Pos.Line == p.line {
+		p.buf.WriteString(" " + tok.Literal)
+	} else {
+		p.newline(tok)
+		p.buf.WriteString(tok.Literal)
+	}
+}
+
+// elementEnds reports whether the next token other than a comment,
+// after the one at index i, ends the current element of a block.
+func (p *printer) elementEnds(i int) bool {
+	for _, tok := range p.tokens[i+1:] {
+		if tok.Type != token.COMMENT {
+			return tok.Type == token.COMMA || isClosing(tok.Type)
+		}
+	}
+	return false
+}
+
+func (p *printer) closeBlock(tok token.Token) {
+	current := p.blocks[len(p.blocks)-1]
+	p.blocks = p.blocks[:len(p.blocks)-1]
+
+	if current.multiline {
+		if p.open || p.comma {
+			p.buf.WriteByte(',')
+		}
+		p.newline(tok)
+	}
+	p.buf.WriteString(tok.Literal)
+
+	p.comma = false
+	p.open = p.inMultiline()
+}
+
+// separate writes what goes between the previous token and tok.
+func (p *printer) separate(tok token.Token) {
+	if p.prev < 0 {
+		return
+	}
+	prev := p.tokens[p.prev]
+
+	if p.comma {
+		p.comma = false
+		p.buf.WriteByte(',')
+		if p.inMultiline() {
+			p.newline(tok)
+		} else {
+			p.buf.WriteByte(' ')
+		}
+		p.open = p.inMultiline()
+		return
+	}
+
+	// A comment in the middle of an element or a statement moves the rest of it
+	// to the next line, which is indented to show that it continues.
+	continued := p.open || len(p.blocks) == 0 && !statementKeywords[tok.Type]
+	p.open = p.inMultiline()
+
+	switch {
+	case prev.Type == token.COMMENT:
+		p.newline(tok)
+		if continued {
+			p.buf.WriteString(indentation)
+		}
+	case len(p.blocks) == 0 && statementKeywords[tok.Type]:
+		p.newline(tok)
+	case prev.Type == token.LBRACE || prev.Type == token.LBRACKET && p.inMultiline():
+		p.newline(tok)
+	case prev.Type == token.LBRACKET, prev.Type == token.LPAREN, prev.Type == token.DOT:
+	case tok.Type == token.DOT && prev.Type == token.FLOAT:
+		// Without the space the dot would become part of the number.
+		p.buf.WriteByte(' ')
+	case tok.Type == token.RPAREN, tok.Type == token.COLON, tok.Type == token.DOT:
+	case tok.Type == token.LBRACKET && valueEnds[prev.Type]:
+		// An index expression.
+	case prev.Type == token.MINUS && p.unary(p.prev):
+	default:
+		p.buf.WriteByte(' ')
+	}
+}
+
+// newline starts a new line indented by the current nesting,
+// keeping one blank line if there was any in the source before tok.
+func (p *printer) newline(tok token.Token) {
+	prev := p.tokens[p.prev]
+	p.buf.WriteByte('\n')
+	if tok.Pos.Line > p.line+1 && !isClosing(tok.Type) && prev.Type != token.LBRACE && prev.Type != token.LBRACKET {
+		p.buf.WriteByte('\n')
+	}
+
+	depth := len(p.blocks)
+	p.buf.WriteString(strings.Repeat(indentation, depth))
+}
+
+func (p *printer) inMultiline() bool {
+	return len(p.blocks) > 0 && p.blocks[len(p.blocks)-1].multiline
+}
+
+// unary reports whether the minus at index i negates the value after it.
+func (p *printer) unary(i int) bool {
+	for i--; i >= 0 && p.tokens[i].Type == token.COMMENT; i-- {
+	}
+	return i < 0 || !valueEnds[p.tokens[i].Type]
+}
@@ -0,0 +1,122 @@
+package format
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"MODIFY CAMERA {position:[0,1.5,-10],rotation:[0,0,0]}",
+			"MODIFY CAMERA {\n    position: [0, 1.5, -10],\n    rotation: [0, 0, 0],\n}\n",
+		},
+		{
+			"NUMBER x=-pi*2-(1+ -3)",
+			"NUMBER x = -pi * 2 - (1 + -3)\n",
+		},
+		{
+			"COLOR red = [1, 0, 0,] NUMBER a = red.r NUMBER b = red[0]",
+			"COLOR red = [1, 0, 0]\nNUMBER a = red.r\nNUMBER b = red[0]\n",
+		},
+		{
+			"NUMBER a = 1\n\n\n\nNUMBER b = 2\nNUMBER c = 3",
+			"NUMBER a = 1\n\nNUMBER b = 2\nNUMBER c = 3\n",
+		},
+		{
+			"SPHERE s AT [1, 2, 3] = {material: {color: red}, e: {}}",
+			"SPHERE s AT [1, 2, 3] = {\n    material: {\n        color: red,\n    },\n    e: {},\n}\n",
+		},
+		{
+			"GROUP g = {children: [{a: 1}, []]}",
+			"GROUP g = {\n    children: [\n        {\n            a: 1,\n        },\n        [],\n    ],\n}\n",
+		},
+		{
+			"SPHERE b EXTENDS a = {\n\n  radius: 1,\n\n  material: m\n\n}",
+			"SPHERE b EXTENDS a = {\n    radius: 1,\n\n    material: m,\n}\n",
+		},
+		{
+			`INCLUDE "materials.sdl"`,
+			"INCLUDE \"materials.sdl\"\n",
+		},
+		{
+			"NUMBER a = 1.50 NUMBER b = 002.0 NUMBER c = 0.25 NUMBER d = 10",
+			"NUMBER a = 1.5\nNUMBER b = 2\nNUMBER c = 0.25\nNUMBER d = 10\n",
+		},
+		{
+			"// Header\n\nNUMBER a = 1 // trailing\nNUMBER b = 1 + // middle\n2",
+			"// Header\n\nNUMBER a = 1 // trailing\nNUMBER b = 1 + // middle\n    2\n",
+		},
+		{
+			"SPHERE s = { // open\n radius: 1 // no comma\n ,\n // own line\n material: m\n // last\n}",
+			"SPHERE s = { // open\n    radius: 1, // no comma\n    // own line\n    material: m,\n    // last\n}\n",
+		},
+		{
+			"COLOR c = [1, // red\n0, 0]",
+			"COLOR c = [\n    1, // red\n    0,\n    0,\n]\n",
+		},
+		{
+			"GROUP g = {children: [a, b] // all\n}",
+			"GROUP g = {\n    children: [a, b], // all\n}\n",
+		},
+		{
+			"MATERIAL m = { // nothing yet\n}",
+			"MATERIAL m = { // nothing yet\n}\n",
+		},
+		{"", ""},
+		{"// only a comment", "// only a comment\n"},
+	}
+
+	for _, tt := range tests {
+		formatted, err := Source([]byte(tt.input))
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %s", tt.input, err)
+		}
+
+		if string(formatted) != tt.expected {
+			t.Errorf("wrong output for %q.\ngot=%q\nwant=%q", tt.input, formatted, tt.expected)
+		}
+
+		again, err := Source(formatted)
+		if err != nil || string(again) != string(formatted) {
+			t.Errorf("formatting is not idempotent for %q. got=%q", tt.input, again)
+		}
+	}
+}
+
+func TestSourceSyntaxError(t *testing.T) {
+	_, err := Source([]byte("SPHERE = 1"))
+
+	var syntaxError *SyntaxError
+	if !errors.As(err, &syntaxError) {
+		t.Fatalf("expected a SyntaxError. got=%T (%v)", err, err)
+	}
+	if syntaxError.Errors[0].Pos.Line != 1 {
+		t.Errorf("wrong position. got=%v", syntaxError.Errors[0].Pos)
+	}
+}
+
+func FuzzSource(f *testing.F) {
+	f.Add("MODIFY CAMERA {position:[0,1.5,-10]} // camera")
+	f.Add("SPHERE s = { // open\n radius: 1 // no comma\n ,\n material: {color: [1, 0, 0]}\n}")
+	f.Add("NUMBER a = -(1 + 2) * -b.x[0]\n\n\nCOLOR c = [1, // red\n0, 0,]")
+	f.Add("GROUP g = {children: [{a: 1}, []]}\nINCLUDE \"a.sdl\"")
+
+	f.Fuzz(func(t *testing.T, src string) {
+		formatted, err := Source([]byte(src))
+		if err != nil {
+			return
+		}
+
+		again, err := Source(formatted)
+		if err != nil {
+			t.Fatalf("formatted source cannot be parsed: %s\n%s", err, formatted)
+		}
+		if string(again) != string(formatted) {
+			t.Errorf("formatting is not idempotent.\nfirst=%q\nsecond=%q", formatted, again)
+		}
+	})
+}
@@ -0,0 +1,198 @@
+package format
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kacperkrolak/scene-description-language/ast"
+)
+
+// Node prints a syntax tree, e.g. one built by a program rather than parsed,
+// as SDL source in the canonical layout. Parentheses are added only where
+// the precedence of operators needs them. Number literals are written as
+// they are, so FloatLiteral tokens should hold the number written by Number.
+func Node(node ast.Node) ([]byte, error) {
+	var out strings.Builder
+	if err := writeNode(&out, node); err != nil {
+		return nil, err
+	}
+	return Source([]byte(out.String()))
+}
+
+func writeNode(out *strings.Builder, node ast.Node) error {
+	switch node := node.(type) {
+	case *ast.File:
+		for _, statement := range node.Statements {
+			if err := writeNode(out, statement); err != nil {
+				return err
+			}
+			out.WriteString("\n")
+		}
+		return nil
+	case *ast.AssignStatement:
+		fmt.Fprintf(out, "%s %s", node.Token.Literal, node.Name.Value)
+		if node.Parent != nil {
+			fmt.Fprintf(out, " EXTENDS %s", node.Parent.Value)
+		}
+		if node.Position != nil {
+			position, err := expression(node.Position, lowest)
+			if err != nil {
+				return err
+			}
+			out.WriteString(" AT " + position)
+		}
+		value, err := expression(node.Value, lowest)
+		if err != nil {
+			return err
+		}
+		out.WriteString(" = " + value)
+		return nil
+	case *ast.ModifyStatement:
+		value, err := expression(node.Value, lowest)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "MODIFY %s %s", node.Name.Value, value)
+		return nil
+	case *ast.IncludeStatement:
+		path, err := expression(node.Path, lowest)
+		if err != nil {
+			return err
+		}
+		out.WriteString("INCLUDE " + path)
+		return nil
+	case *ast.ExpressionStatement:
+		value, err := expression(node.Expression, lowest)
+		if err != nil {
+			return err
+		}
+		out.WriteString(value)
+		return nil
+	case ast.Expression:
+		value, err := expression(node, lowest)
+		if err != nil {
+			return err
+		}
+		out.WriteString(value)
+		return nil
+	default:
+		return fmt.Errorf("cannot print %T", node)
+	}
+}
+
+// Precedences of the expressions, from the loosest.
+const (
+	lowest = iota
+	sum
+	product
+	prefix
+	postfix // index and member expressions
+)
+
+var infixPrecedences = map[string]int{
+	"+": sum,
+	"-": sum,
+	"*": product,
+	"/": product,
+}
+
+// expression writes the expression, in parentheses if it binds looser than precedence.
+func expression(e ast.Expression, precedence int) (string, error) {
+	s, own, err := writeExpression(e)
+	if err != nil {
+		return "", err
+	}
+	if own < precedence {
+		return "(" + s + ")", nil
+	}
+	return s, nil
+}
+
+// writeExpression returns the expression and the precedence of its outermost operator.
+func writeExpression(e ast.Expression) (string, int, error) {
+	atom := postfix + 1
+
+	switch e := e.(type) {
+	case nil:
+		return "", 0, fmt.Errorf("missing expression")
+	case *ast.FloatLiteral:
+		return e.Token.Literal, atom, nil
+	case *ast.StringLiteral:
+		if strings.ContainsAny(e.Value, "\"\n") {
+			return "", 0, fmt.Errorf("string %q cannot be written in SDL", e.Value)
+		}
+		return `"` + e.Value + `"`, atom, nil
+	case *ast.Identifier:
+		return e.Value, atom, nil
+	case *ast.PrefixExpression:
+		right, err := expression(e.Right, prefix)
+		if err != nil {
+			return "", 0, err
+		}
+		return e.Operator + right, prefix, nil
+	case *ast.InfixExpression:
+		precedence, ok := infixPrecedences[e.Operator]
+		if !ok {
+			return "", 0, fmt.Errorf("unknown operator %q", e.Operator)
+		}
+		left, err := expression(e.Left, precedence)
+		if err != nil {
+			return "", 0, err
+		}
+		// Operators are left-associative, so a right operand of
+		// the same precedence needs parentheses.
+		right, err := expression(e.Right, precedence+1)
+		if err != nil {
+			return "", 0, err
+		}
+		return left + " " + e.Operator + " " + right, precedence, nil
+	case *ast.IndexExpression:
+		left, err := postfixOperand(e.Left)
+		if err != nil {
+			return "", 0, err
+		}
+		index, err := expression(e.Index, lowest)
+		if err != nil {
+			return "", 0, err
+		}
+		return left + "[" + index + "]", postfix, nil
+	case *ast.MemberExpression:
+		object, err := postfixOperand(e.Object)
+		if err != nil {
+			return "", 0, err
+		}
+		return object + "." + e.Property.Value, postfix, nil
+	case *ast.ArrayExpression:
+		elements := make([]string, len(e.Elements))
+		for i, element := range e.Elements {
+			s, err := expression(element, lowest)
+			if err != nil {
+				return "", 0, err
+			}
+			elements[i] = s
+		}
+		return "[" + strings.Join(elements, ", ") + "]", atom, nil
+	case *ast.PropertiesExpression:
+		properties := make([]string, len(e.Keys))
+		for i, key := range e.Keys {
+			s, err := expression(e.Properties[key.Value], lowest)
+			if err != nil {
+				return "", 0, err
+			}
+			properties[i] = key.Value + ": " + s
+		}
+		return "{" + strings.Join(properties, ", ") + "}", atom, nil
+	default:
+		return "", 0, fmt.Errorf("cannot print %T", e)
+	}
+}
+
+// postfixOperand writes the operand of an index or member expression.
+// Numbers are put in parentheses, so their dot is not read as a member.
+func postfixOperand(e ast.Expression) (string, error) {
+	if _, ok := e.(*ast.FloatLiteral); ok {
+		s, _, err := writeExpression(e)
+		return "(" + s + ")", err
+	}
+	return expression(e, postfix)
+}
@@ -0,0 +1,57 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/kacperkrolak/scene-description-language/ast"
+	"github.com/kacperkrolak/scene-description-language/lexer"
+	"github.com/kacperkrolak/scene-description-language/parser"
+	"github.com/kacperkrolak/scene-description-language/token"
+)
+
+func TestNode(t *testing.T) {
+	// Without comments and redundant parentheses, printing the syntax
+	// tree gives the same source as formatting the text.
+	inputs := []string{
+		"MODIFY CAMERA {position:[0,1.5,-10],rotation:[0,0,0]}",
+		"NUMBER x=-pi*2-(1+ -3)",
+		"NUMBER y = a - (b - c) / (d * e) + -(f + g)",
+		"NUMBER z = m.color[0] + (1).x + [1, 2][k]",
+		`INCLUDE "materials.sdl"` + "\nSPHERE s EXTENDS base AT [0, 0, 5] = {radius: 1, material: {color: [1, 0, 0]}}",
+		"GROUP g = {children: []}\nMATERIAL m = {}",
+		"(a + b).x",
+	}
+
+	for _, input := range inputs {
+		p := parser.New(lexer.New(input))
+		file := p.ParseFile()
+		if len(p.Errors()) > 0 {
+			t.Fatalf("syntax errors in %q: %v", input, p.Errors())
+		}
+
+		got, err := Node(file)
+		if err != nil {
+			t.Errorf("error printing %q: %v", input, err)
+			continue
+		}
+		expected, err := Source([]byte(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != string(expected) {
+			t.Errorf("wrong source for %q.\ngot=%q\nwant=%q", input, got, expected)
+		}
+	}
+}
+
+func TestNodeErrors(t *testing.T) {
+	str := &ast.StringLiteral{Token: token.Token{Type: token.STRING}, Value: `say "hi"`}
+	if _, err := Node(str); err == nil || err.Error() != `string "say \"hi\"" cannot be written in SDL` {
+		t.Errorf("wrong error. got=%v", err)
+	}
+
+	missing := &ast.File{Statements: []ast.Statement{&ast.ExpressionStatement{}}}
+	if _, err := Node(missing); err == nil || err.Error() != "missing expression" {
+		t.Errorf("wrong error. got=%v", err)
+	}
+}
@@ -1,6 +1,8 @@
 package lexer
 
 import (
+	"strings"
+
 	"github.com/kacperkrolak/scene-description-language/token"
 )
 
@@ -9,15 +11,23 @@ type Lexer struct {
 	position     int  // current position in input (points to current char)
 	readPosition int  // current reading position in input (after current char)
 	ch           byte // current char under examination
+	line         int  // line of the current char
+	column       int  // column of the current char
 }
 
 func New(input string) *Lexer {
-	l := &Lexer{input: input}
+	l := &Lexer{input: input, line: 1}
 	l.readChar()
 	return l
 }
 
 func (l *Lexer) readChar() {
+	if l.ch == '\n' {
+		l.line++
+		l.column = 0
+	}
+	l.column++
+
 	if l.readPosition >= len(l.input) {
 		l.ch = 0
 	} else {
@@ -29,10 +39,18 @@ func (l *Lexer) readChar() {
 }
 
 func (l *Lexer) NextToken() token.Token {
-	var tok token.Token
-
 	l.skipWhitespace()
 
+	pos := token.Position{Line: l.line, Column: l.column}
+	tok := l.readToken()
+	tok.Pos = pos
+
+	return tok
+}
+
+func (l *Lexer) readToken() token.Token {
+	var tok token.Token
+
 	switch l.ch {
 	case '=':
 		tok = token.NewToken(token.ASSIGN, l.ch)
@@ -41,11 +59,18 @@ func (l *Lexer) NextToken() token.Token {
 	case '+':
 		tok = token.NewToken(token.PLUS, l.ch)
 	case '/':
+		if l.peakChar() == '/' {
+			tok.Type = token.COMMENT
+			tok.Literal = l.readComment()
+			return tok
+		}
 		tok = token.NewToken(token.DIVIDE, l.ch)
 	case '*':
 		tok = token.NewToken(token.MULTIPLY, l.ch)
 	case ':':
 		tok = token.NewToken(token.COLON, l.ch)
+	case '.':
+		tok = token.NewToken(token.DOT, l.ch)
 	case '(':
 		tok = token.NewToken(token.LPAREN, l.ch)
 	case ')':
@@ -60,6 +85,15 @@ func (l *Lexer) NextToken() token.Token {
 		tok = token.NewToken(token.LBRACKET, l.ch)
 	case ']':
 		tok = token.NewToken(token.RBRACKET, l.ch)
+	case '"':
+		literal, ok := l.readString()
+		if !ok {
+			tok.Type = token.ILLEGAL
+			tok.Literal = literal
+			return tok
+		}
+		tok.Type = token.STRING
+		tok.Literal = literal
 	case 0:
 		tok.Literal = ""
 		tok.Type = token.EOF
@@ -98,6 +132,30 @@ func (l *Lexer) readIdentifier() string {
 	return l.input[position:l.position]
 }
 
+// readString reads a string enclosed in double quotes and returns it without the quotes.
+// If the input ends before the closing quote, it returns false.
+func (l *Lexer) readString() (string, bool) {
+	position := l.position + 1
+	for {
+		l.readChar()
+		if l.ch == '"' {
+			return l.input[position:l.position], true
+		}
+		if l.ch == 0 || l.ch == '\n' {
+			return l.input[position-1 : l.position], false
+		}
+	}
+}
+
+// readComment reads a comment up to the end of the line, without the line break.
+func (l *Lexer) readComment() string {
+	position := l.position
+	for l.ch != '\n' && l.ch != 0 {
+		l.readChar()
+	}
+	return strings.TrimRight(l.input[position:l.position], " \t\r")
+}
+
 func (l *Lexer) skipWhitespace() {
 	for l.ch == ' ' || l.ch == '\t' || l.ch == '\n' || l.ch == '\r' {
 		l.readChar()
@@ -153,3 +153,128 @@ LIGHT light1 AT (0, 1.5, 0) {
 		}
 	}
 }
+
+func TestMemberAccessTokens(t *testing.T) {
+	input := `sphere1.radius pos[0].x 1.5`
+	tokens := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.IDENT, "sphere1"},
+		{token.DOT, "."},
+		{token.IDENT, "radius"},
+		{token.IDENT, "pos"},
+		{token.LBRACKET, "["},
+		{token.FLOAT, "0"},
+		{token.RBRACKET, "]"},
+		{token.DOT, "."},
+		{token.IDENT, "x"},
+		{token.FLOAT, "1.5"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tokens {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestStringTokens(t *testing.T) {
+	input := `INCLUDE "materials/shiny.sdl"
+"unterminated
+`
+	tokens := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.INCLUDE, "INCLUDE"},
+		{token.STRING, "materials/shiny.sdl"},
+		{token.ILLEGAL, `"unterminated`},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tokens {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestTokenPositions(t *testing.T) {
+	input := "NUMBER a = 1\n\tCOLOR c = [a, 2.5]\n"
+	positions := []struct {
+		literal string
+		line    int
+		column  int
+	}{
+		{"NUMBER", 1, 1},
+		{"a", 1, 8},
+		{"=", 1, 10},
+		{"1", 1, 12},
+		{"COLOR", 2, 2},
+		{"c", 2, 8},
+		{"=", 2, 10},
+		{"[", 2, 12},
+		{"a", 2, 13},
+		{",", 2, 14},
+		{"2.5", 2, 16},
+		{"]", 2, 19},
+		{"", 3, 1},
+	}
+
+	l := New(input)
+
+	for i, tt := range positions {
+		tok := l.NextToken()
+		if tok.Literal != tt.literal {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.literal, tok.Literal)
+		}
+		if tok.Pos.Line != tt.line || tok.Pos.Column != tt.column {
+			t.Errorf("tests[%d] - position of %q wrong. expected=%d:%d, got=%s", i, tt.literal, tt.line, tt.column, tok.Pos)
+		}
+	}
+}
+
+func TestCommentTokens(t *testing.T) {
+	input := "// header  \nNUMBER a = 1 / 2 // half\n//"
+	tokens := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.COMMENT, "// header"},
+		{token.NUMBER, "NUMBER"},
+		{token.IDENT, "a"},
+		{token.ASSIGN, "="},
+		{token.FLOAT, "1"},
+		{token.DIVIDE, "/"},
+		{token.FLOAT, "2"},
+		{token.COMMENT, "// half"},
+		{token.COMMENT, "//"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tokens {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
@@ -0,0 +1,336 @@
+package lsp
+
+import (
+	"errors"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/kacperkrolak/scene-description-language/ast"
+	"github.com/kacperkrolak/scene-description-language/evaluator"
+	"github.com/kacperkrolak/scene-description-language/lexer"
+	"github.com/kacperkrolak/scene-description-language/parser"
+	"github.com/kacperkrolak/scene-description-language/scene"
+	"github.com/kacperkrolak/scene-description-language/token"
+)
+
+// document is an open file analyzed after every change.
+type document struct {
+	uri     string
+	path    string // path of the file, used to resolve INCLUDE statements
+	version int
+	text    string
+	lines   []string
+
+	tokens      []token.Token // all tokens including comments, without EOF
+	file        *ast.File
+	evaluator   *evaluator.Evaluator // holds the objects evaluated before the first error, if any
+	diagnostics []Diagnostic
+}
+
+func newDocument(uri string, version int, text string) *document {
+	d := &document{
+		uri:       uri,
+		path:      uriToPath(uri),
+		version:   version,
+		text:      text,
+		lines:     strings.Split(text, "\n"),
+		evaluator: evaluator.NewEvaluator(),
+	}
+
+	l := lexer.New(text)
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		d.tokens = append(d.tokens, tok)
+	}
+
+	p := parser.New(lexer.New(text))
+	d.file = p.ParseFile()
+	if errors := p.SyntaxErrors(); len(errors) > 0 {
+		for _, err := range errors {
+			d.addDiagnostic(evaluator.Diagnostic{File: d.path, Pos: err.Pos, Message: err.Message})
+		}
+		return d
+	}
+
+	err := d.evaluator.EvaluateSource(strings.NewReader(text), d.path)
+	var diagnostics evaluator.Diagnostics
+	if errors.As(err, &diagnostics) {
+		for _, diagnostic := range diagnostics {
+			d.addDiagnostic(diagnostic)
+		}
+		return d
+	}
+	if err != nil {
+		d.diagnostics = append(d.diagnostics, Diagnostic{Severity: SeverityError, Source: "sdl", Message: err.Error()})
+		return d
+	}
+
+	for _, problem := range scene.Validate(d.evaluator.ExportValues()) {
+		location, _ := d.evaluator.Definition(problem.Entity)
+		d.addDiagnostic(evaluator.Diagnostic{File: location.File, Pos: location.Pos, Message: problem.String()})
+	}
+
+	return d
+}
+
+// addDiagnostic converts the diagnostic for the client. Problems found in
+// included files are shown at the start of the document, with their location.
+func (d *document) addDiagnostic(diagnostic evaluator.Diagnostic) {
+	r := Range{}
+	message := diagnostic.Message
+	if diagnostic.File == d.path && diagnostic.Pos.IsValid() {
+		r = d.tokenRange(diagnostic.Pos)
+	} else {
+		message = diagnostic.String()
+	}
+
+	d.diagnostics = append(d.diagnostics, Diagnostic{Range: r, Severity: SeverityError, Source: "sdl", Message: message})
+}
+
+// uriToPath returns the path of a file URI. Other URIs are used as they are.
+func uriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	return filepath.FromSlash(u.Path)
+}
+
+func pathToURI(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
+
+// toLSP converts a position in the source to a position for the client.
+func (d *document) toLSP(pos token.Position) Position {
+	line := pos.Line - 1
+	if line < 0 {
+		return Position{}
+	}
+	if line >= len(d.lines) {
+		return Position{Line: line}
+	}
+
+	text := d.lines[line]
+	column := pos.Column - 1
+	if column > len(text) {
+		column = len(text)
+	}
+	return Position{Line: line, Character: utf16Length(text[:column])}
+}
+
+// fromLSP converts a position sent by the client to a position in the source.
+func (d *document) fromLSP(p Position) token.Position {
+	if p.Line < 0 || p.Line >= len(d.lines) {
+		return token.Position{Line: p.Line + 1, Column: 1}
+	}
+
+	text := d.lines[p.Line]
+	units := 0
+	for offset, r := range text {
+		if units >= p.Character {
+			return token.Position{Line: p.Line + 1, Column: offset + 1}
+		}
+		units += len(utf16.Encode([]rune{r}))
+	}
+	return token.Position{Line: p.Line + 1, Column: len(text) + 1}
+}
+
+func utf16Length(s string) int {
+	n := 0
+	for len(s) > 0 {
+		r, size := utf8.DecodeRuneInString(s)
+		s = s[size:]
+		n += len(utf16.Encode([]rune{r}))
+	}
+	return n
+}
+
+// tokenLength returns the number of bytes the token takes in the source.
+func tokenLength(tok token.Token) int {
+	if tok.Type == token.STRING {
+		return len(tok.Literal) + 2
+	}
+	return len(tok.Literal)
+}
+
+// end returns the position just after the token.
+func end(tok token.Token) token.Position {
+	return token.Position{Line: tok.Pos.Line, Column: tok.Pos.Column + tokenLength(tok)}
+}
+
+// tokenRange returns the range of the token starting at pos,
+// or an empty range if no token starts there.
+func (d *document) tokenRange(pos token.Position) Range {
+	for _, tok := range d.tokens {
+		if tok.Pos == pos {
+			return Range{Start: d.toLSP(pos), End: d.toLSP(end(tok))}
+		}
+	}
+	return Range{Start: d.toLSP(pos), End: d.toLSP(pos)}
+}
+
+// tokenAt returns the index of the token under the cursor. A cursor just after
+// an identifier or a keyword also points at it, as it does while typing.
+func (d *document) tokenAt(pos token.Position) (int, bool) {
+	for i, tok := range d.tokens {
+		if tok.Pos.Line != pos.Line || tok.Pos.Column > pos.Column {
+			continue
+		}
+
+		e := end(tok)
+		if pos.Column < e.Column || pos.Column == e.Column && isWord(tok) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// isWord reports whether the token is an identifier or a keyword.
+func isWord(tok token.Token) bool {
+	return tok.Literal != "" && token.LookupIdent(tok.Literal) == tok.Type
+}
+
+// previous returns the index of the last token before index i which is not a comment, or -1.
+func (d *document) previous(i int) int {
+	for i--; i >= 0 && d.tokens[i].Type == token.COMMENT; i-- {
+	}
+	return i
+}
+
+// next returns the index of the first token after index i which is not a comment, or -1.
+func (d *document) next(i int) int {
+	for i++; i < len(d.tokens); i++ {
+		if d.tokens[i].Type != token.COMMENT {
+			return i
+		}
+	}
+	return -1
+}
+
+// isPropertyKey reports whether the identifier at index i names a property.
+func (d *document) isPropertyKey(i int) bool {
+	n := d.next(i)
+	_, inProperties := d.enclosingClass(i)
+	return n >= 0 && d.tokens[n].Type == token.COLON && inProperties
+}
+
+// isMember reports whether the identifier at index i follows a dot.
+func (d *document) isMember(i int) bool {
+	p := d.previous(i)
+	return p >= 0 && d.tokens[p].Type == token.DOT
+}
+
+// enclosingClass returns the class whose properties are written around the token at index i,
+// including nested properties with a known class, such as a material of a sphere.
+// It returns false outside of properties and in properties of an unknown class.
+func (d *document) enclosingClass(i int) (scene.Class, bool) {
+	var statementClass string
+	var blocks []*scene.Class // nil for arrays and properties of unknown classes
+
+	for j := 0; j < i && j < len(d.tokens); j++ {
+		tok := d.tokens[j]
+		switch tok.Type {
+		case token.NUMBER, token.COLOR, token.MATERIAL, token.SPHERE, token.LIGHT, token.GROUP, token.RENDER:
+			if len(blocks) == 0 {
+				statementClass = string(tok.Type)
+			}
+		case token.MODIFY:
+			if n := d.next(j); len(blocks) == 0 && n >= 0 {
+				statementClass = d.classOf(d.tokens[n].Literal)
+			}
+		case token.LBRACE:
+			blocks = append(blocks, d.blockClass(j, statementClass, blocks))
+		case token.LBRACKET:
+			blocks = append(blocks, nil)
+		case token.RBRACE, token.RBRACKET:
+			if len(blocks) > 0 {
+				blocks = blocks[:len(blocks)-1]
+			}
+		}
+	}
+
+	if len(blocks) == 0 || blocks[len(blocks)-1] == nil {
+		return scene.Class{}, false
+	}
+	return *blocks[len(blocks)-1], true
+}
+
+// blockClass returns the class of the properties opened by the brace at index i.
+func (d *document) blockClass(i int, statementClass string, blocks []*scene.Class) *scene.Class {
+	if len(blocks) == 0 {
+		class, ok := scene.LookupClass(statementClass)
+		if !ok {
+			return nil
+		}
+		return &class
+	}
+
+	parent := blocks[len(blocks)-1]
+	colon := d.previous(i)
+	key := d.previous(colon)
+	if parent == nil || colon < 0 || key < 0 || d.tokens[colon].Type != token.COLON {
+		return nil
+	}
+
+	property, ok := parent.Property(d.tokens[key].Literal)
+	if !ok || property.Type != scene.MaterialValue {
+		return nil
+	}
+	class, _ := scene.LookupClass(token.MATERIAL)
+	return &class
+}
+
+// classOf returns the class of the object with the given name, if it is known.
+func (d *document) classOf(name string) string {
+	if name == token.CAMERA {
+		return token.CAMERA
+	}
+	if entity, ok := d.evaluator.Lookup(name); ok {
+		return entity.Class
+	}
+	for _, statement := range d.file.Statements {
+		if assign, ok := statement.(*ast.AssignStatement); ok && assign.Name != nil && assign.Name.Value == name {
+			return assign.Token.Literal
+		}
+	}
+	return ""
+}
+
+// location returns where the object with the given name is defined.
+func (d *document) location(name string) (Location, bool) {
+	definition, ok := d.evaluator.Definition(name)
+	if !ok {
+		return Location{}, false
+	}
+
+	if definition.File != d.path {
+		// Other files are not open, so columns are assumed to count single code units.
+		start := Position{Line: definition.Pos.Line - 1, Character: definition.Pos.Column - 1}
+		return Location{URI: pathToURI(definition.File), Range: Range{Start: start, End: start}}, true
+	}
+
+	// Point at the name rather than at the start of the statement.
+	for _, statement := range d.file.Statements {
+		if statement.Pos() != definition.Pos {
+			continue
+		}
+		switch statement := statement.(type) {
+		case *ast.AssignStatement:
+			return Location{URI: d.uri, Range: d.tokenRange(statement.Name.Pos())}, true
+		case *ast.ModifyStatement:
+			return Location{URI: d.uri, Range: d.tokenRange(statement.Name.Pos())}, true
+		}
+	}
+	return Location{URI: d.uri, Range: d.tokenRange(definition.Pos)}, true
+}
+
+// fullRange covers the whole text.
+func (d *document) fullRange() Range {
+	last := len(d.lines) - 1
+	return Range{End: Position{Line: last, Character: utf16Length(d.lines[last])}}
+}
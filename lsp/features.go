@@ -0,0 +1,273 @@
+package lsp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kacperkrolak/scene-description-language/ast"
+	"github.com/kacperkrolak/scene-description-language/format"
+	"github.com/kacperkrolak/scene-description-language/scene"
+	"github.com/kacperkrolak/scene-description-language/token"
+)
+
+// hover describes the class, property or object under the cursor.
+// Objects are shown with their evaluated values.
+func (d *document) hover(pos token.Position) *Hover {
+	i, ok := d.tokenAt(pos)
+	if !ok {
+		return nil
+	}
+	tok := d.tokens[i]
+
+	var contents string
+	switch {
+	case tok.Type == token.IDENT && d.isPropertyKey(i):
+		class, _ := d.enclosingClass(i)
+		property, ok := class.Property(tok.Literal)
+		if !ok {
+			return nil
+		}
+		contents = describeProperty(class, property)
+	case tok.Type == token.IDENT && d.isMember(i):
+		return nil
+	case tok.Type == token.IDENT || tok.Type == token.CAMERA && d.isModified(i):
+		entity, ok := d.evaluator.Lookup(tok.Literal)
+		if !ok {
+			return nil
+		}
+		contents = fmt.Sprintf("```sdl\n%s\n```", entity.Inspect())
+	default:
+		class, ok := scene.LookupClass(string(tok.Type))
+		if !ok {
+			return nil
+		}
+		contents = describeClass(class)
+	}
+
+	r := Range{Start: d.toLSP(tok.Pos), End: d.toLSP(end(tok))}
+	return &Hover{Contents: MarkupContent{Kind: "markdown", Value: contents}, Range: &r}
+}
+
+// isModified reports whether the token at index i is the target of a MODIFY statement.
+func (d *document) isModified(i int) bool {
+	p := d.previous(i)
+	return p >= 0 && d.tokens[p].Type == token.MODIFY
+}
+
+func describeClass(class scene.Class) string {
+	var out strings.Builder
+	fmt.Fprintf(&out, "**%s**\n\n%s", class.Name, class.Description)
+	if len(class.Properties) > 0 {
+		out.WriteString("\n\nProperties:\n")
+		for _, property := range class.Properties {
+			fmt.Fprintf(&out, "- `%s`: %s\n", property.Name, property.Type)
+		}
+	}
+	return out.String()
+}
+
+func describeProperty(class scene.Class, property scene.Property) string {
+	required := ""
+	if property.Required {
+		required = ", required"
+	}
+	return fmt.Sprintf("**%s.%s**: %s%s\n\n%s", class.Name, property.Name, property.Type, required, property.Description)
+}
+
+// definition finds where the object under the cursor is defined.
+func (d *document) definition(pos token.Position) (Location, bool) {
+	i, ok := d.tokenAt(pos)
+	if !ok {
+		return Location{}, false
+	}
+
+	tok := d.tokens[i]
+	if tok.Type != token.IDENT && tok.Type != token.CAMERA || d.isMember(i) || d.isPropertyKey(i) {
+		return Location{}, false
+	}
+	return d.location(tok.Literal)
+}
+
+// statementKeywords are offered where a statement can start.
+var statementKeywords = []token.TokenType{
+	token.NUMBER, token.COLOR, token.MATERIAL, token.SPHERE, token.LIGHT, token.GROUP, token.RENDER, token.MODIFY, token.INCLUDE,
+}
+
+// complete suggests statement keywords at the top level, property names
+// where a property of a known class can be written, and object names elsewhere.
+func (d *document) complete(pos token.Position) []CompletionItem {
+	// The word being typed is replaced by the completion, so the context is what precedes it.
+	i := len(d.tokens)
+	if current, ok := d.tokenAt(pos); ok {
+		i = current
+	} else {
+		for j, tok := range d.tokens {
+			if tok.Pos.Line > pos.Line || tok.Pos.Line == pos.Line && tok.Pos.Column >= pos.Column {
+				i = j
+				break
+			}
+		}
+	}
+	prev := d.previous(i)
+
+	if class, ok := d.enclosingClass(i); ok && prev >= 0 && (d.tokens[prev].Type == token.LBRACE || d.tokens[prev].Type == token.COMMA) {
+		items := make([]CompletionItem, len(class.Properties))
+		for j, property := range class.Properties {
+			items[j] = CompletionItem{
+				Label:         property.Name,
+				Kind:          CompletionKindProperty,
+				Detail:        string(property.Type),
+				Documentation: property.Description,
+				InsertText:    property.Name + ": ",
+			}
+		}
+		return items
+	}
+
+	if d.atStatementStart(prev, pos) {
+		items := make([]CompletionItem, len(statementKeywords))
+		for j, keyword := range statementKeywords {
+			items[j] = CompletionItem{Label: string(keyword), Kind: CompletionKindKeyword}
+			if class, ok := scene.LookupClass(string(keyword)); ok {
+				items[j].Kind = CompletionKindClass
+				items[j].Documentation = class.Description
+			}
+		}
+		return items
+	}
+
+	var items []CompletionItem
+	if prev >= 0 && d.tokens[prev].Type == token.MODIFY {
+		items = append(items, CompletionItem{Label: token.CAMERA, Kind: CompletionKindVariable, Detail: token.CAMERA})
+	}
+	for _, name := range d.names() {
+		items = append(items, CompletionItem{Label: name, Kind: CompletionKindVariable, Detail: d.classOf(name)})
+	}
+	return items
+}
+
+// names returns the sorted names of the objects defined in the document and in the files it includes.
+// Objects declared in the document are known even if it cannot be evaluated.
+func (d *document) names() []string {
+	seen := make(map[string]bool)
+	var names []string
+	add := func(name string) {
+		if !seen[name] && name != token.CAMERA {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	for _, name := range d.evaluator.Names() {
+		add(name)
+	}
+	for _, statement := range d.file.Statements {
+		if assign, ok := statement.(*ast.AssignStatement); ok && assign.Name != nil {
+			add(assign.Name.Value)
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// atStatementStart reports whether a statement can start at the cursor,
+// given the index of the token before it: at the top level, on a line
+// after the end of the previous statement.
+func (d *document) atStatementStart(prev int, pos token.Position) bool {
+	if prev < 0 {
+		return true
+	}
+
+	depth := 0
+	for _, tok := range d.tokens[:prev+1] {
+		switch tok.Type {
+		case token.LBRACE, token.LBRACKET, token.LPAREN:
+			depth++
+		case token.RBRACE, token.RBRACKET, token.RPAREN:
+			depth--
+		}
+	}
+
+	switch d.tokens[prev].Type {
+	case token.ASSIGN, token.AT, token.EXTENDS, token.MODIFY, token.INCLUDE, token.PLUS, token.MINUS, token.MULTIPLY, token.DIVIDE, token.DOT, token.COMMA, token.COLON:
+		return false
+	}
+	return depth == 0 && d.tokens[prev].Pos.Line < pos.Line
+}
+
+// symbols lists the statements of the document with their properties.
+func (d *document) symbols() []DocumentSymbol {
+	symbols := []DocumentSymbol{}
+	for k, statement := range d.file.Statements {
+		r := Range{Start: d.toLSP(statement.Pos()), End: d.toLSP(d.statementEnd(k))}
+
+		var symbol DocumentSymbol
+		var value ast.Expression
+		switch statement := statement.(type) {
+		case *ast.AssignStatement:
+			kind := SymbolKindObject
+			if statement.Token.Type == token.NUMBER || statement.Token.Type == token.COLOR {
+				kind = SymbolKindConstant
+			}
+			symbol = DocumentSymbol{Name: statement.Name.Value, Detail: statement.Token.Literal, Kind: kind}
+			symbol.SelectionRange = d.tokenRange(statement.Name.Pos())
+			value = statement.Value
+		case *ast.ModifyStatement:
+			symbol = DocumentSymbol{Name: statement.Name.Value, Detail: "MODIFY", Kind: SymbolKindObject}
+			symbol.SelectionRange = d.tokenRange(statement.Name.Pos())
+			value = statement.Value
+		case *ast.IncludeStatement:
+			symbol = DocumentSymbol{Name: statement.Path.Value, Detail: "INCLUDE", Kind: SymbolKindFile}
+			symbol.SelectionRange = d.tokenRange(statement.Path.Pos())
+		default:
+			continue
+		}
+		symbol.Range = r
+
+		if properties, ok := value.(*ast.PropertiesExpression); ok {
+			for _, key := range properties.Keys {
+				keyRange := d.tokenRange(key.Pos())
+				symbol.Children = append(symbol.Children, DocumentSymbol{
+					Name:           key.Value,
+					Kind:           SymbolKindProperty,
+					Range:          keyRange,
+					SelectionRange: keyRange,
+				})
+			}
+		}
+
+		symbols = append(symbols, symbol)
+	}
+	return symbols
+}
+
+// statementEnd returns the position after the last token of the statement at index k.
+func (d *document) statementEnd(k int) token.Position {
+	statements := d.file.Statements
+	last := token.Token{Pos: statements[k].Pos()}
+	for _, tok := range d.tokens {
+		if k+1 < len(statements) && !before(tok.Pos, statements[k+1].Pos()) {
+			break
+		}
+		if tok.Type != token.COMMENT && !before(tok.Pos, statements[k].Pos()) {
+			last = tok
+		}
+	}
+	return end(last)
+}
+
+func before(a, b token.Position) bool {
+	return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+}
+
+// formatting returns the edit which formats the whole document,
+// or no edits if it is formatted or cannot be parsed.
+func (d *document) formatting() []TextEdit {
+	formatted, err := format.Source([]byte(d.text))
+	if err != nil || string(formatted) == d.text {
+		return []TextEdit{}
+	}
+	return []TextEdit{{Range: d.fullRange(), NewText: string(formatted)}}
+}
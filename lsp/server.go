@@ -0,0 +1,229 @@
+// Package lsp implements a language server for SDL files, speaking
+// the Language Server Protocol over a pair of streams such as stdin and stdout.
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrExitWithoutShutdown is returned by Run when the client exits without shutting the server down first.
+var ErrExitWithoutShutdown = errors.New("exit without shutdown")
+
+type Server struct {
+	in        *bufio.Reader
+	out       io.Writer
+	documents map[string]*document
+
+	initialized bool
+	shutdown    bool
+}
+
+func NewServer(in io.Reader, out io.Writer) *Server {
+	return &Server{in: bufio.NewReader(in), out: out, documents: make(map[string]*document)}
+}
+
+// Run handles messages until the client sends the exit notification or closes the input.
+func (s *Server) Run() error {
+	for {
+		body, err := readMessage(s.in)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			if err := s.respond(nil, nil, &responseError{Code: codeParseError, Message: err.Error()}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if req.Method == "exit" {
+			if !s.shutdown {
+				return ErrExitWithoutShutdown
+			}
+			return nil
+		}
+
+		result, respErr := s.handle(req)
+		if req.ID == nil {
+			// Notifications are not answered, even when they fail.
+			continue
+		}
+		if err := s.respond(req.ID, result, respErr); err != nil {
+			return err
+		}
+	}
+}
+
+// handle answers a request or applies a notification. The result of a notification is ignored.
+func (s *Server) handle(req request) (interface{}, *responseError) {
+	if !s.initialized && req.Method != "initialize" {
+		return nil, &responseError{Code: codeServerNotInitialized, Message: "server is not initialized"}
+	}
+	if s.shutdown && req.ID != nil {
+		return nil, &responseError{Code: codeInvalidRequest, Message: "server is shut down"}
+	}
+
+	switch req.Method {
+	case "initialize":
+		s.initialized = true
+		return InitializeResult{
+			Capabilities: ServerCapabilities{
+				TextDocumentSync:           syncFull,
+				HoverProvider:              true,
+				DefinitionProvider:         true,
+				CompletionProvider:         CompletionOptions{TriggerCharacters: []string{"{", ","}},
+				DocumentSymbolProvider:     true,
+				DocumentFormattingProvider: true,
+			},
+			ServerInfo: ServerInfo{Name: "sdl"},
+		}, nil
+	case "initialized":
+		return nil, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		var params DidOpenTextDocumentParams
+		if err := decode(req.Params, &params); err != nil {
+			return nil, err
+		}
+		s.open(newDocument(params.TextDocument.URI, params.TextDocument.Version, params.TextDocument.Text))
+		return nil, nil
+	case "textDocument/didChange":
+		var params DidChangeTextDocumentParams
+		if err := decode(req.Params, &params); err != nil {
+			return nil, err
+		}
+		if len(params.ContentChanges) == 0 {
+			return nil, nil
+		}
+		text := params.ContentChanges[len(params.ContentChanges)-1].Text
+		s.open(newDocument(params.TextDocument.URI, params.TextDocument.Version, text))
+		return nil, nil
+	case "textDocument/didClose":
+		var params DidCloseTextDocumentParams
+		if err := decode(req.Params, &params); err != nil {
+			return nil, err
+		}
+		delete(s.documents, params.TextDocument.URI)
+		s.publishDiagnostics(params.TextDocument.URI, []Diagnostic{})
+		return nil, nil
+	case "textDocument/hover":
+		d, pos, err := s.position(req.Params)
+		if err != nil {
+			return nil, err
+		}
+		if hover := d.hover(d.fromLSP(pos)); hover != nil {
+			return hover, nil
+		}
+		return nil, nil
+	case "textDocument/definition":
+		d, pos, err := s.position(req.Params)
+		if err != nil {
+			return nil, err
+		}
+		if location, ok := d.definition(d.fromLSP(pos)); ok {
+			return location, nil
+		}
+		return nil, nil
+	case "textDocument/completion":
+		d, pos, err := s.position(req.Params)
+		if err != nil {
+			return nil, err
+		}
+		items := d.complete(d.fromLSP(pos))
+		if items == nil {
+			items = []CompletionItem{}
+		}
+		return CompletionList{Items: items}, nil
+	case "textDocument/documentSymbol":
+		var params DocumentSymbolParams
+		if err := decode(req.Params, &params); err != nil {
+			return nil, err
+		}
+		d, err := s.document(params.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		return d.symbols(), nil
+	case "textDocument/formatting":
+		var params DocumentFormattingParams
+		if err := decode(req.Params, &params); err != nil {
+			return nil, err
+		}
+		d, err := s.document(params.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		return d.formatting(), nil
+	default:
+		return nil, &responseError{Code: codeMethodNotFound, Message: fmt.Sprintf("method not supported: %s", req.Method)}
+	}
+}
+
+// open replaces the analyzed document and reports its problems.
+func (s *Server) open(d *document) {
+	s.documents[d.uri] = d
+	diagnostics := d.diagnostics
+	if diagnostics == nil {
+		diagnostics = []Diagnostic{}
+	}
+	s.publishDiagnostics(d.uri, diagnostics)
+}
+
+func (s *Server) publishDiagnostics(uri string, diagnostics []Diagnostic) {
+	// A client which cannot be written to will also fail to receive the next response,
+	// which ends the server.
+	_ = writeMessage(s.out, notification{
+		JSONRPC: "2.0",
+		Method:  "textDocument/publishDiagnostics",
+		Params:  PublishDiagnosticsParams{URI: uri, Diagnostics: diagnostics},
+	})
+}
+
+func (s *Server) document(uri string) (*document, *responseError) {
+	d, ok := s.documents[uri]
+	if !ok {
+		return nil, &responseError{Code: codeInvalidParams, Message: fmt.Sprintf("document is not open: %s", uri)}
+	}
+	return d, nil
+}
+
+// position decodes the parameters of requests about a position in a document.
+func (s *Server) position(raw json.RawMessage) (*document, Position, *responseError) {
+	var params TextDocumentPositionParams
+	if err := decode(raw, &params); err != nil {
+		return nil, Position{}, err
+	}
+
+	d, err := s.document(params.TextDocument.URI)
+	return d, params.Position, err
+}
+
+func decode(raw json.RawMessage, params interface{}) *responseError {
+	if err := json.Unmarshal(raw, params); err != nil {
+		return &responseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+func (s *Server) respond(id *json.RawMessage, result interface{}, respErr *responseError) error {
+	resp := response{JSONRPC: "2.0", ID: id, Error: respErr}
+	if respErr == nil {
+		encoded, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		resp.Result = encoded
+	}
+	return writeMessage(s.out, resp)
+}
@@ -0,0 +1,485 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kacperkrolak/scene-description-language/token"
+)
+
+// testClient collects the messages sent to the server and reads its answers after it exits.
+type testClient struct {
+	input  bytes.Buffer
+	nextID int
+}
+
+func (c *testClient) request(method string, params interface{}) int {
+	c.nextID++
+	id := json.RawMessage(mustMarshal(c.nextID))
+	writeMessage(&c.input, request{JSONRPC: "2.0", ID: &id, Method: method, Params: mustMarshal(params)})
+	return c.nextID
+}
+
+func (c *testClient) notify(method string, params interface{}) {
+	writeMessage(&c.input, notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+func mustMarshal(v interface{}) []byte {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+type serverOutput struct {
+	responses     map[int]response
+	notifications []request
+}
+
+// run sends everything the client wrote to a new server, followed by shutdown and exit.
+func (c *testClient) run(t *testing.T) serverOutput {
+	t.Helper()
+	c.request("shutdown", nil)
+	c.notify("exit", nil)
+
+	var out bytes.Buffer
+	if err := NewServer(&c.input, &out).Run(); err != nil {
+		t.Fatalf("server failed: %s", err)
+	}
+
+	output := serverOutput{responses: make(map[int]response)}
+	r := bufio.NewReader(&out)
+	for {
+		body, err := readMessage(r)
+		if errors.Is(err, io.EOF) {
+			return output
+		}
+		if err != nil {
+			t.Fatalf("cannot read message: %s", err)
+		}
+
+		var resp response
+		if err := json.Unmarshal(body, &resp); err == nil && resp.ID != nil {
+			var id int
+			json.Unmarshal(*resp.ID, &id)
+			output.responses[id] = resp
+			continue
+		}
+
+		var n request
+		if err := json.Unmarshal(body, &n); err != nil {
+			t.Fatalf("invalid message %s: %s", body, err)
+		}
+		output.notifications = append(output.notifications, n)
+	}
+}
+
+func (o serverOutput) result(t *testing.T, id int, v interface{}) {
+	t.Helper()
+	resp, ok := o.responses[id]
+	if !ok {
+		t.Fatalf("no response to request %d", id)
+	}
+	if resp.Error != nil {
+		t.Fatalf("request %d failed: %s", id, resp.Error.Message)
+	}
+	if err := json.Unmarshal(resp.Result, v); err != nil {
+		t.Fatalf("cannot decode result %s: %s", resp.Result, err)
+	}
+}
+
+func (o serverOutput) diagnostics(t *testing.T, uri string) []Diagnostic {
+	t.Helper()
+	var last *PublishDiagnosticsParams
+	for _, n := range o.notifications {
+		if n.Method != "textDocument/publishDiagnostics" {
+			continue
+		}
+		var params PublishDiagnosticsParams
+		if err := json.Unmarshal(n.Params, &params); err != nil {
+			t.Fatal(err)
+		}
+		if params.URI == uri {
+			last = &params
+		}
+	}
+	if last == nil {
+		t.Fatalf("no diagnostics published for %s", uri)
+	}
+	return last.Diagnostics
+}
+
+func newClient(documents map[string]string) *testClient {
+	c := &testClient{}
+	c.request("initialize", map[string]interface{}{})
+	c.notify("initialized", map[string]interface{}{})
+	for uri, text := range documents {
+		c.notify("textDocument/didOpen", DidOpenTextDocumentParams{TextDocument: TextDocumentItem{URI: uri, Version: 1, Text: text}})
+	}
+	return c
+}
+
+func position(uri string, line, character int) TextDocumentPositionParams {
+	return TextDocumentPositionParams{TextDocument: TextDocumentIdentifier{URI: uri}, Position: Position{Line: line, Character: character}}
+}
+
+const mainSource = `INCLUDE "materials.sdl"
+// a red ball
+NUMBER size = 2
+SPHERE ball = {
+    radius: size,
+    material: shiny,
+}
+MODIFY CAMERA {position: [0, 0, -5]}
+`
+
+// writeProject creates a scene including a file of materials and returns the URI of the scene.
+func writeProject(t *testing.T) string {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "materials.sdl"), []byte("MATERIAL shiny = {color: [1, 0, 0]}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return pathToURI(filepath.Join(dir, "main.sdl"))
+}
+
+func TestInitialize(t *testing.T) {
+	c := &testClient{}
+	early := c.request("textDocument/hover", position("file:///a.sdl", 0, 0))
+	initialize := c.request("initialize", map[string]interface{}{})
+	unknown := c.request("textDocument/rename", map[string]interface{}{})
+	output := c.run(t)
+
+	if resp := output.responses[early]; resp.Error == nil || resp.Error.Code != codeServerNotInitialized {
+		t.Errorf("requests before initialize should fail. got=%+v", resp)
+	}
+
+	var result InitializeResult
+	output.result(t, initialize, &result)
+	capabilities := result.Capabilities
+	if capabilities.TextDocumentSync != syncFull || !capabilities.HoverProvider || !capabilities.DefinitionProvider ||
+		!capabilities.DocumentSymbolProvider || !capabilities.DocumentFormattingProvider {
+		t.Errorf("wrong capabilities. got=%+v", capabilities)
+	}
+
+	if resp := output.responses[unknown]; resp.Error == nil || resp.Error.Code != codeMethodNotFound {
+		t.Errorf("unknown methods should fail. got=%+v", resp)
+	}
+}
+
+func TestExitWithoutShutdown(t *testing.T) {
+	c := &testClient{}
+	c.notify("exit", nil)
+
+	err := NewServer(&c.input, io.Discard).Run()
+	if !errors.Is(err, ErrExitWithoutShutdown) {
+		t.Errorf("expected ErrExitWithoutShutdown. got=%v", err)
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	uri := writeProject(t)
+	tests := []struct {
+		text     string
+		expected []Diagnostic
+	}{
+		{mainSource, []Diagnostic{}},
+		{
+			"NUMBER a = b",
+			[]Diagnostic{{Range: Range{Start: Position{0, 11}, End: Position{0, 12}}, Severity: SeverityError, Source: "sdl", Message: "undefined identifier: b"}},
+		},
+		{
+			"NUMBER = 1",
+			[]Diagnostic{
+				{Range: Range{Start: Position{0, 7}, End: Position{0, 8}}, Severity: SeverityError, Source: "sdl", Message: "expected next token to be IDENT, got = instead"},
+				{Range: Range{Start: Position{0, 7}, End: Position{0, 8}}, Severity: SeverityError, Source: "sdl", Message: "no prefix parse function for = found"},
+			},
+		},
+		{
+			"\nSPHERE s = {radius: 1, size: 2}",
+			[]Diagnostic{{Range: Range{Start: Position{1, 0}, End: Position{1, 6}}, Severity: SeverityError, Source: "sdl", Message: "SPHERE s: size: unknown property of SPHERE"}},
+		},
+	}
+
+	for _, tt := range tests {
+		c := newClient(map[string]string{uri: tt.text})
+		diagnostics := c.run(t).diagnostics(t, uri)
+
+		if string(mustMarshal(diagnostics)) != string(mustMarshal(tt.expected)) {
+			t.Errorf("wrong diagnostics for %q.\ngot=%+v\nwant=%+v", tt.text, diagnostics, tt.expected)
+		}
+	}
+}
+
+func TestDidChangeAndClose(t *testing.T) {
+	uri := "file:///tmp/scene.sdl"
+	c := newClient(map[string]string{uri: "NUMBER a = b"})
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   VersionedTextDocumentIdentifier{URI: uri, Version: 2},
+		ContentChanges: []TextDocumentContentChangeEvent{{Text: "NUMBER b = 1\nNUMBER a = b"}},
+	})
+	hover := c.request("textDocument/hover", position(uri, 1, 7))
+	c.notify("textDocument/didClose", DidCloseTextDocumentParams{TextDocument: TextDocumentIdentifier{URI: uri}})
+	closed := c.request("textDocument/hover", position(uri, 1, 7))
+	output := c.run(t)
+
+	var result Hover
+	output.result(t, hover, &result)
+	if !strings.Contains(result.Contents.Value, "NUMBER a = 1") {
+		t.Errorf("hover should show the changed document. got=%q", result.Contents.Value)
+	}
+
+	if len(output.diagnostics(t, uri)) != 0 {
+		t.Errorf("diagnostics of a closed document should be cleared")
+	}
+	if resp := output.responses[closed]; resp.Error == nil {
+		t.Errorf("requests about closed documents should fail")
+	}
+}
+
+func TestHover(t *testing.T) {
+	uri := writeProject(t)
+	tests := []struct {
+		line, character int
+		expected        string
+	}{
+		{3, 9, "```sdl\nSPHERE ball = {\n    radius: 2,\n    material: {\n        color: [1, 0, 0],\n    },\n}\n```"},
+		{4, 13, "```sdl\nNUMBER size = 2\n```"},
+		{4, 16, "```sdl\nNUMBER size = 2\n```"}, // just after the name
+		{4, 6, "**SPHERE.radius**: NUMBER, required\n\nRadius of the sphere."},
+		{7, 16, "**CAMERA.position**: VECTOR\n\nPosition relative to the parent group."},
+		{7, 9, "```sdl\nCAMERA CAMERA = {\n    position: [0, 0, -5],\n}\n```"},
+		{2, 2, "**NUMBER**\n\nA number constant."},
+		{1, 5, ""},  // comment
+		{4, 11, ""}, // whitespace
+	}
+
+	c := newClient(map[string]string{uri: mainSource})
+	ids := make([]int, len(tests))
+	for i, tt := range tests {
+		ids[i] = c.request("textDocument/hover", position(uri, tt.line, tt.character))
+	}
+	output := c.run(t)
+
+	for i, tt := range tests {
+		var result *Hover
+		output.result(t, ids[i], &result)
+
+		got := ""
+		if result != nil {
+			got = result.Contents.Value
+		}
+		if got != tt.expected {
+			t.Errorf("wrong hover at %d:%d.\ngot=%q\nwant=%q", tt.line, tt.character, got, tt.expected)
+		}
+	}
+}
+
+func TestDefinition(t *testing.T) {
+	uri := writeProject(t)
+	materialsURI := strings.TrimSuffix(uri, "main.sdl") + "materials.sdl"
+	tests := []struct {
+		line, character int
+		expected        *Location
+	}{
+		{4, 14, &Location{URI: uri, Range: Range{Start: Position{2, 7}, End: Position{2, 11}}}},
+		{5, 16, &Location{URI: materialsURI, Range: Range{Start: Position{0, 0}, End: Position{0, 0}}}},
+		{7, 9, &Location{URI: uri, Range: Range{Start: Position{7, 7}, End: Position{7, 13}}}},
+		{4, 6, nil}, // property name
+	}
+
+	c := newClient(map[string]string{uri: mainSource})
+	ids := make([]int, len(tests))
+	for i, tt := range tests {
+		ids[i] = c.request("textDocument/definition", position(uri, tt.line, tt.character))
+	}
+	output := c.run(t)
+
+	for i, tt := range tests {
+		var result *Location
+		output.result(t, ids[i], &result)
+		if string(mustMarshal(result)) != string(mustMarshal(tt.expected)) {
+			t.Errorf("wrong definition at %d:%d.\ngot=%+v\nwant=%+v", tt.line, tt.character, result, tt.expected)
+		}
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	uri := "file:///tmp/scene.sdl"
+	text := `NUMBER size = 2
+SPHERE s = {
+    radius: size,
+    ra
+    material: {
+
+    },
+}
+
+`
+	tests := []struct {
+		line, character int
+		contains        []string
+		excludes        []string
+	}{
+		{3, 6, []string{"radius", "material", "position"}, []string{"color", "SPHERE"}},
+		{5, 8, []string{"color", "ambientIntensity"}, []string{"radius"}},
+		{2, 12, []string{"size", "s"}, []string{"radius", "SPHERE"}},
+		{9, 0, []string{"SPHERE", "MODIFY", "INCLUDE"}, []string{"size"}},
+	}
+
+	c := newClient(map[string]string{uri: text})
+	ids := make([]int, len(tests))
+	for i, tt := range tests {
+		ids[i] = c.request("textDocument/completion", position(uri, tt.line, tt.character))
+	}
+	output := c.run(t)
+
+	for i, tt := range tests {
+		var result CompletionList
+		output.result(t, ids[i], &result)
+
+		labels := make(map[string]bool)
+		for _, item := range result.Items {
+			labels[item.Label] = true
+		}
+		for _, label := range tt.contains {
+			if !labels[label] {
+				t.Errorf("completion at %d:%d should offer %q. got=%v", tt.line, tt.character, label, labels)
+			}
+		}
+		for _, label := range tt.excludes {
+			if labels[label] {
+				t.Errorf("completion at %d:%d should not offer %q", tt.line, tt.character, label)
+			}
+		}
+	}
+}
+
+func TestDocumentSymbols(t *testing.T) {
+	uri := writeProject(t)
+	c := newClient(map[string]string{uri: mainSource})
+	id := c.request("textDocument/documentSymbol", DocumentSymbolParams{TextDocument: TextDocumentIdentifier{URI: uri}})
+	output := c.run(t)
+
+	var symbols []DocumentSymbol
+	output.result(t, id, &symbols)
+
+	var names []string
+	for _, symbol := range symbols {
+		names = append(names, symbol.Detail+" "+symbol.Name)
+	}
+	expected := "INCLUDE materials.sdl,NUMBER size,SPHERE ball,MODIFY CAMERA"
+	if strings.Join(names, ",") != expected {
+		t.Fatalf("wrong symbols. got=%v", names)
+	}
+
+	ball := symbols[2]
+	if ball.Range != (Range{Start: Position{3, 0}, End: Position{6, 1}}) {
+		t.Errorf("wrong range of ball. got=%+v", ball.Range)
+	}
+	if ball.SelectionRange != (Range{Start: Position{3, 7}, End: Position{3, 11}}) {
+		t.Errorf("wrong selection range of ball. got=%+v", ball.SelectionRange)
+	}
+	if len(ball.Children) != 2 || ball.Children[0].Name != "radius" || ball.Children[1].Name != "material" {
+		t.Errorf("wrong properties of ball. got=%+v", ball.Children)
+	}
+}
+
+func TestSyntaxErrors(t *testing.T) {
+	// Editors ask for symbols and completions while the document is being typed.
+	documents := map[string]string{
+		"file:///tmp/unnamed.sdl":    "SPHERE = {",
+		"file:///tmp/unfinished.sdl": "NUMBER a = 1\nSPHERE\nNUMBER c = a + ",
+		"file:///tmp/modify.sdl":     "NUMBER a = 1\nMODIFY {radius: a}\nINCLUDE\nSPHERE s EXTENDS = {radius: a}",
+		"file:///tmp/value.sdl":      "SPHERE s = {radius: }\nLIGHT l AT = {}",
+	}
+
+	c := newClient(documents)
+	var ids []int
+	for uri, text := range documents {
+		ids = append(ids, c.request("textDocument/documentSymbol", DocumentSymbolParams{TextDocument: TextDocumentIdentifier{URI: uri}}))
+		lines := strings.Split(text, "\n")
+		for line, contents := range lines {
+			for character := 0; character <= len(contents); character++ {
+				for _, method := range []string{"textDocument/completion", "textDocument/hover", "textDocument/definition"} {
+					ids = append(ids, c.request(method, position(uri, line, character)))
+				}
+			}
+		}
+	}
+	output := c.run(t)
+
+	for _, id := range ids {
+		var result interface{}
+		output.result(t, id, &result)
+	}
+
+	// What can be parsed is still offered.
+	c = newClient(map[string]string{"file:///tmp/unfinished.sdl": documents["file:///tmp/unfinished.sdl"]})
+	symbolsID := c.request("textDocument/documentSymbol", DocumentSymbolParams{TextDocument: TextDocumentIdentifier{URI: "file:///tmp/unfinished.sdl"}})
+	completionID := c.request("textDocument/completion", position("file:///tmp/unfinished.sdl", 2, 15))
+	output = c.run(t)
+
+	var symbols []DocumentSymbol
+	output.result(t, symbolsID, &symbols)
+	if len(symbols) == 0 || symbols[0].Name != "a" {
+		t.Errorf("wrong symbols. got=%+v", symbols)
+	}
+	var completions CompletionList
+	output.result(t, completionID, &completions)
+	found := false
+	for _, item := range completions.Items {
+		found = found || item.Label == "a"
+	}
+	if !found {
+		t.Errorf("completion should offer a. got=%+v", completions.Items)
+	}
+}
+
+func TestFormatting(t *testing.T) {
+	uri := "file:///tmp/scene.sdl"
+	c := newClient(map[string]string{uri: "NUMBER a=1.0 // one\nSPHERE s={radius:a}"})
+	id := c.request("textDocument/formatting", DocumentFormattingParams{TextDocument: TextDocumentIdentifier{URI: uri}})
+	output := c.run(t)
+
+	var edits []TextEdit
+	output.result(t, id, &edits)
+	expected := []TextEdit{{
+		Range:   Range{End: Position{1, 19}},
+		NewText: "NUMBER a = 1 // one\nSPHERE s = {\n    radius: a,\n}\n",
+	}}
+	if string(mustMarshal(edits)) != string(mustMarshal(expected)) {
+		t.Errorf("wrong edits.\ngot=%+v\nwant=%+v", edits, expected)
+	}
+}
+
+func TestPositionConversion(t *testing.T) {
+	d := newDocument("file:///tmp/a.sdl", 1, "NUMBER a = 1 // é😀 x\n")
+
+	tests := []struct {
+		pos token.Position
+		lsp Position
+	}{
+		{token.Position{Line: 1, Column: 1}, Position{0, 0}},
+		{token.Position{Line: 1, Column: 17}, Position{0, 16}},
+		{token.Position{Line: 1, Column: 19}, Position{0, 17}}, // after é, 2 bytes and 1 code unit
+		{token.Position{Line: 1, Column: 23}, Position{0, 19}}, // after 😀, 4 bytes and 2 code units
+		{token.Position{Line: 2, Column: 1}, Position{1, 0}},
+	}
+
+	for _, tt := range tests {
+		if got := d.toLSP(tt.pos); got != tt.lsp {
+			t.Errorf("toLSP(%s) wrong. got=%+v, want=%+v", tt.pos, got, tt.lsp)
+		}
+		if got := d.fromLSP(tt.lsp); got != tt.pos {
+			t.Errorf("fromLSP(%+v) wrong. got=%s, want=%s", tt.lsp, got, tt.pos)
+		}
+	}
+}
@@ -18,6 +18,7 @@ const (
 	PRODUCT     //*
 	PREFIX      //-Xor!X
 	CALL        // myFunction(X)
+	INDEX       // array[index] or object.property
 )
 
 var precedences = map[token.TokenType]int{
@@ -25,6 +26,8 @@ var precedences = map[token.TokenType]int{
 	token.PLUS:     SUM,
 	token.DIVIDE:   PRODUCT,
 	token.MULTIPLY: PRODUCT,
+	token.LBRACKET: INDEX,
+	token.DOT:      INDEX,
 }
 
 var objectTypes = map[token.TokenType]bool{
@@ -33,6 +36,8 @@ var objectTypes = map[token.TokenType]bool{
 	token.MATERIAL: true,
 	token.SPHERE:   true,
 	token.LIGHT:    true,
+	token.GROUP:    true,
+	token.RENDER:   true,
 }
 
 type Parser struct {
@@ -41,7 +46,7 @@ type Parser struct {
 	curToken  token.Token
 	peekToken token.Token
 
-	errors []string
+	errors []Error
 
 	prefixParseFns map[token.TokenType]prefixParseFn
 	infixParseFns  map[token.TokenType]infixParseFn
@@ -50,12 +55,14 @@ type Parser struct {
 func New(l *lexer.Lexer) *Parser {
 	p := &Parser{
 		l:      l,
-		errors: []string{},
+		errors: []Error{},
 	}
 
 	p.prefixParseFns = make(map[token.TokenType]prefixParseFn)
 	p.registerPrefix(token.FLOAT, p.parseFloatLiteral)
 	p.registerPrefix(token.IDENT, p.parseIdentifier)
+	p.registerPrefix(token.CAMERA, p.parseIdentifier)
+	p.registerPrefix(token.STRING, p.parseStringLiteral)
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
 	p.registerPrefix(token.LPAREN, p.parseGroupedExpression)
 	p.registerPrefix(token.LBRACKET, p.parseArrayExpression)
@@ -66,6 +73,8 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.PLUS, p.parseInfixExpression)
 	p.registerInfix(token.DIVIDE, p.parseInfixExpression)
 	p.registerInfix(token.MULTIPLY, p.parseInfixExpression)
+	p.registerInfix(token.LBRACKET, p.parseIndexExpression)
+	p.registerInfix(token.DOT, p.parseMemberExpression)
 
 	// Read two tokens, so curToken and peekToken are both set
 	p.nextToken()
@@ -74,13 +83,37 @@ func New(l *lexer.Lexer) *Parser {
 	return p
 }
 
+// Error is a syntax error found at a position in the source.
+type Error struct {
+	Pos     token.Position
+	Message string
+}
+
+func (e Error) String() string {
+	return fmt.Sprintf("%s: %s", e.Pos, e.Message)
+}
+
+// Errors returns the syntax errors as messages prefixed with their positions.
 func (p *Parser) Errors() []string {
+	messages := make([]string, len(p.errors))
+	for i, err := range p.errors {
+		messages[i] = err.String()
+	}
+	return messages
+}
+
+// SyntaxErrors returns the syntax errors found while parsing.
+func (p *Parser) SyntaxErrors() []Error {
 	return p.errors
 }
 
 func (p *Parser) nextToken() {
 	p.curToken = p.peekToken
 	p.peekToken = p.l.NextToken()
+	// Comments only matter to the formatter.
+	for p.peekToken.Type == token.COMMENT {
+		p.peekToken = p.l.NextToken()
+	}
 }
 
 func (p *Parser) ParseFile() *ast.File {
@@ -130,6 +163,7 @@ func (p *Parser) parseArrayExpression() ast.Expression {
 	if !p.expectPeek(token.RBRACKET) {
 		return nil
 	}
+	arrayExp.Rbracket = p.curToken.Pos
 
 	return arrayExp
 }
@@ -152,9 +186,6 @@ func (p *Parser) parsePropertiesExpression() ast.Expression {
 			return nil
 		}
 
-		// In this statement, we treat the identifier (key) as a string
-		key := indentifier.Value
-
 		if !p.expectPeek(token.COLON) {
 			return nil
 		}
@@ -166,10 +197,17 @@ func (p *Parser) parsePropertiesExpression() ast.Expression {
 			return nil
 		}
 
-		propertiesExp.Properties[key] = exp
+		// A later duplicate would silently replace the earlier value.
+		if first := propertyKey(propertiesExp, indentifier.Value); first != nil {
+			p.addError(indentifier.Pos(), fmt.Sprintf("duplicate property %q, first defined at %s", indentifier.Value, first.Pos()))
+		} else {
+			propertiesExp.Keys = append(propertiesExp.Keys, indentifier)
+			propertiesExp.Properties[indentifier.Value] = exp
+		}
 
 		if p.peekTokenIs(token.RBRACE) {
 			p.nextToken()
+			propertiesExp.Rbrace = p.curToken.Pos
 			return propertiesExp
 		}
 
@@ -181,23 +219,48 @@ func (p *Parser) parsePropertiesExpression() ast.Expression {
 	if !p.expectPeek(token.RBRACE) {
 		return nil
 	}
+	propertiesExp.Rbrace = p.curToken.Pos
 
 	return propertiesExp
 }
 
+// propertyKey returns the key with the given name, or nil if there is none.
+func propertyKey(properties *ast.PropertiesExpression, name string) *ast.Identifier {
+	for _, key := range properties.Keys {
+		if key.Value == name {
+			return key
+		}
+	}
+	return nil
+}
+
 func isObjectType(tokenType token.TokenType) bool {
 	return objectTypes[tokenType]
 }
 
+// parseStatement returns the statement at the current token, or nil if
+// it cannot be parsed. The statement parsers return nil pointers on errors,
+// which must not be returned as non-nil statements.
 func (p *Parser) parseStatement() ast.Statement {
 	switch {
 	case isObjectType(p.curToken.Type):
-		return p.parseAssignStatement()
+		if stmt := p.parseAssignStatement(); stmt != nil {
+			return stmt
+		}
 	case p.curTokenIs(token.MODIFY):
-		return p.parseModifyStatement()
+		if stmt := p.parseModifyStatement(); stmt != nil {
+			return stmt
+		}
+	case p.curTokenIs(token.INCLUDE):
+		if stmt := p.parseIncludeStatement(); stmt != nil {
+			return stmt
+		}
 	default:
-		return p.parseExpressionStatement()
+		if stmt := p.parseExpressionStatement(); stmt != nil {
+			return stmt
+		}
 	}
+	return nil
 }
 
 func (p *Parser) parseAssignStatement() *ast.AssignStatement {
@@ -206,6 +269,21 @@ func (p *Parser) parseAssignStatement() *ast.AssignStatement {
 		return nil
 	}
 	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	if p.peekTokenIs(token.EXTENDS) {
+		p.nextToken()
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		stmt.Parent = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	}
+	if p.peekTokenIs(token.AT) {
+		p.nextToken()
+		p.nextToken()
+		stmt.Position = p.parseExpression(LOWEST)
+		if stmt.Position == nil {
+			return nil
+		}
+	}
 	if !p.expectPeek(token.ASSIGN) {
 		return nil
 	}
@@ -217,7 +295,10 @@ func (p *Parser) parseAssignStatement() *ast.AssignStatement {
 
 func (p *Parser) parseModifyStatement() *ast.ModifyStatement {
 	stmt := &ast.ModifyStatement{Token: p.curToken}
-	if !p.expectPeek(token.IDENT) {
+	// The camera is a keyword, but it is modified like any other object.
+	if p.peekTokenIs(token.CAMERA) {
+		p.nextToken()
+	} else if !p.expectPeek(token.IDENT) {
 		return nil
 	}
 	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
@@ -228,6 +309,16 @@ func (p *Parser) parseModifyStatement() *ast.ModifyStatement {
 	return stmt
 }
 
+func (p *Parser) parseIncludeStatement() *ast.IncludeStatement {
+	stmt := &ast.IncludeStatement{Token: p.curToken}
+	if !p.expectPeek(token.STRING) {
+		return nil
+	}
+	stmt.Path = &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
+
+	return stmt
+}
+
 func (p *Parser) curTokenIs(t token.TokenType) bool {
 	return p.curToken.Type == t
 }
@@ -244,13 +335,13 @@ func (p *Parser) expectPeek(t token.TokenType) bool {
 	}
 }
 
-func (p *Parser) addErrorMessage(message string) {
-	p.errors = append(p.errors, message)
+func (p *Parser) addError(pos token.Position, message string) {
+	p.errors = append(p.errors, Error{Pos: pos, Message: message})
 }
 
 func (p *Parser) peekError(t token.TokenType) {
 	msg := fmt.Sprintf("expected next token to be %s, got %s instead", t, p.peekToken.Type)
-	p.errors = append(p.errors, msg)
+	p.addError(p.peekToken.Pos, msg)
 }
 
 type (
@@ -267,7 +358,7 @@ func (p *Parser) registerInfix(tokenType token.TokenType, fn infixParseFn) {
 
 func (p *Parser) noPrefixParseFnError(t token.TokenType) {
 	msg := fmt.Sprintf("no prefix parse function for %s found", t)
-	p.errors = append(p.errors, msg)
+	p.addError(p.curToken.Pos, msg)
 }
 
 func (p *Parser) parseExpression(precedence int) ast.Expression {
@@ -299,12 +390,16 @@ func (p *Parser) parseIdentifier() ast.Expression {
 	return &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 }
 
+func (p *Parser) parseStringLiteral() ast.Expression {
+	return &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
+}
+
 func (p *Parser) parseFloatLiteral() ast.Expression {
 	lit := &ast.FloatLiteral{Token: p.curToken}
 	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
 	if err != nil {
 		msg := fmt.Sprintf("could not parse %q as integer", p.curToken.Literal)
-		p.errors = append(p.errors, msg)
+		p.addError(p.curToken.Pos, msg)
 		return nil
 	}
 	lit.Value = value
@@ -346,3 +441,30 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	expression.Right = p.parseExpression(precedence)
 	return expression
 }
+
+func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
+	expression := &ast.IndexExpression{Token: p.curToken, Left: left}
+	p.nextToken()
+	expression.Index = p.parseExpression(LOWEST)
+	if expression.Index == nil {
+		return nil
+	}
+
+	if !p.expectPeek(token.RBRACKET) {
+		return nil
+	}
+	expression.Rbracket = p.curToken.Pos
+
+	return expression
+}
+
+func (p *Parser) parseMemberExpression(object ast.Expression) ast.Expression {
+	expression := &ast.MemberExpression{Token: p.curToken, Object: object}
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+
+	expression.Property = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	return expression
+}
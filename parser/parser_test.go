@@ -254,6 +254,22 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"(-a + b) * (c / d)",
 			"(((-a) + b) * (c / d))\n",
 		},
+		{
+			"a.b.c",
+			"((a.b).c)\n",
+		},
+		{
+			"-pos.x * 2",
+			"((-(pos.x)) * 2)\n",
+		},
+		{
+			"a[1 + 1].xz",
+			"((a[(1 + 1)]).xz)\n",
+		},
+		{
+			"[1, 2][0]",
+			"([1, 2][0])\n",
+		},
 	}
 	for _, tt := range tests {
 		l := lexer.New(tt.input)
@@ -390,6 +406,14 @@ prop_two: 2.0,
 				{"prop_two", 2.0},
 			},
 		},
+		{
+			input: `{zeta: 3, alpha: 1, mid: 2}`,
+			expected: []expectation{
+				{"zeta", 3},
+				{"alpha", 1},
+				{"mid", 2},
+			},
+		},
 		{
 			input:    `{}`,
 			expected: []expectation{},
@@ -420,7 +444,15 @@ prop_two: 2.0,
 			t.Fatalf("exp not *ast.PropertiesExpression. got=%T", stmt.Expression)
 		}
 
-		for _, expected := range tt.expected {
+		if len(propertiesExp.Keys) != len(tt.expected) {
+			t.Fatalf("wrong number of keys. got=%d, want=%d", len(propertiesExp.Keys), len(tt.expected))
+		}
+
+		for i, expected := range tt.expected {
+			if propertiesExp.Keys[i].Value != expected.key {
+				t.Errorf("key %d wrong. got=%q, want=%q", i, propertiesExp.Keys[i].Value, expected.key)
+			}
+
 			prop, ok := propertiesExp.Properties[expected.key]
 			if !ok {
 				t.Errorf("propertiesExp.Properties does not contain key '%s'", expected.key)
@@ -433,3 +465,276 @@ prop_two: 2.0,
 		}
 	}
 }
+
+func TestDuplicatePropertyErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"SPHERE s = {radius: 1, radius: 2}",
+			`1:24: duplicate property "radius", first defined at 1:13`,
+		},
+		{
+			"MATERIAL m = {\n  color: red,\n  texture: {scale: 1},\n  color: blue,\n}",
+			`4:3: duplicate property "color", first defined at 2:3`,
+		},
+		{
+			"SPHERE s = {material: {a: 1, b: 2, a: 3}}",
+			`1:36: duplicate property "a", first defined at 1:24`,
+		},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseFile()
+
+		errors := p.Errors()
+		if len(errors) != 1 || errors[0] != tt.expected {
+			t.Errorf("wrong errors for %q. got=%q, want=%q", tt.input, errors, tt.expected)
+		}
+	}
+}
+
+func TestExtendsStatement(t *testing.T) {
+	input := `MATERIAL shinyRed EXTENDS shiny = {color: red}`
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseFile()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain 1 statements. got=%d", len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.AssignStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.AssignStatement. got=%T", program.Statements[0])
+	}
+
+	if !testIdentifier(t, stmt.Name, "shinyRed") {
+		return
+	}
+
+	if stmt.Parent == nil {
+		t.Fatalf("stmt.Parent is nil")
+	}
+
+	if !testIdentifier(t, stmt.Parent, "shiny") {
+		return
+	}
+
+	expected := "MATERIAL shinyRed EXTENDS shiny = {\ncolor: red,\n}"
+	if stmt.String() != expected {
+		t.Errorf("stmt.String() wrong. expected=%q, got=%q", expected, stmt.String())
+	}
+}
+
+func TestExtendsStatementErrors(t *testing.T) {
+	tests := []string{
+		`MATERIAL shinyRed EXTENDS = {}`,
+		`MATERIAL shinyRed EXTENDS shiny {}`,
+	}
+
+	for _, input := range tests {
+		l := lexer.New(input)
+		p := New(l)
+		p.ParseFile()
+		if len(p.Errors()) == 0 {
+			t.Errorf("expected parser errors for %q", input)
+		}
+	}
+}
+
+func TestMemberExpressionErrors(t *testing.T) {
+	tests := []string{
+		"a.",
+		"a.1",
+		"a[1",
+		"a[]",
+	}
+
+	for _, input := range tests {
+		l := lexer.New(input)
+		p := New(l)
+		p.ParseFile()
+		if len(p.Errors()) == 0 {
+			t.Errorf("expected parser errors for %q", input)
+		}
+	}
+}
+
+func TestCommentsAreIgnored(t *testing.T) {
+	input := `// materials
+NUMBER a = 1 + // one
+	2
+SPHERE s = { // a sphere
+	radius: a, // radius
+	// nothing else
+}`
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseFile()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 2 {
+		t.Fatalf("program.Statements does not contain 2 statements. got=%d", len(program.Statements))
+	}
+	if program.Statements[0].String() != "NUMBER a = (1 + 2)" {
+		t.Errorf("wrong statement. got=%q", program.Statements[0].String())
+	}
+}
+
+func TestSyntaxErrorPositions(t *testing.T) {
+	input := `NUMBER a = 1
+SPHERE = 2`
+	l := lexer.New(input)
+	p := New(l)
+	p.ParseFile()
+
+	errors := p.SyntaxErrors()
+	if len(errors) == 0 {
+		t.Fatalf("expected parser errors")
+	}
+	if errors[0].Pos.Line != 2 || errors[0].Pos.Column != 8 {
+		t.Errorf("wrong position. got=%s", errors[0].Pos)
+	}
+	if p.Errors()[0] != "2:8: expected next token to be IDENT, got = instead" {
+		t.Errorf("wrong message. got=%q", p.Errors()[0])
+	}
+}
+
+func TestFailedStatementsAreLeftOut(t *testing.T) {
+	for _, input := range []string{"SPHERE = {", "MODIFY {radius: 1}", "INCLUDE", "NUMBER a = 1\nSPHERE\nNUMBER c = a + "} {
+		p := New(lexer.New(input))
+		program := p.ParseFile()
+		if len(p.Errors()) == 0 {
+			t.Errorf("expected parser errors for %q", input)
+		}
+		for i, stmt := range program.Statements {
+			if reflect.ValueOf(stmt).IsNil() {
+				t.Errorf("statement %d of %q is a nil %T", i, input, stmt)
+			}
+		}
+	}
+}
+
+func TestIncludeStatement(t *testing.T) {
+	input := `INCLUDE "materials.sdl"
+NUMBER a = 1`
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseFile()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 2 {
+		t.Fatalf("program.Statements does not contain 2 statements. got=%d", len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.IncludeStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.IncludeStatement. got=%T", program.Statements[0])
+	}
+
+	if stmt.Path.Value != "materials.sdl" {
+		t.Errorf("stmt.Path.Value not %q. got=%q", "materials.sdl", stmt.Path.Value)
+	}
+
+	if stmt.String() != `INCLUDE "materials.sdl"` {
+		t.Errorf("stmt.String() wrong. got=%q", stmt.String())
+	}
+
+	l = lexer.New(`INCLUDE materials`)
+	p = New(l)
+	p.ParseFile()
+	if len(p.Errors()) == 0 {
+		t.Errorf("expected parser errors for INCLUDE without a path")
+	}
+}
+
+func TestModifyCameraStatement(t *testing.T) {
+	input := `MODIFY CAMERA {position: [0, 1.5, -10]}
+MODIFY sphere1 {radius: CAMERA.position.y}`
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseFile()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 2 {
+		t.Fatalf("program.Statements does not contain 2 statements. got=%d", len(program.Statements))
+	}
+
+	expectedNames := []string{"CAMERA", "sphere1"}
+	for i, name := range expectedNames {
+		stmt, ok := program.Statements[i].(*ast.ModifyStatement)
+		if !ok {
+			t.Fatalf("program.Statements[%d] is not ast.ModifyStatement. got=%T", i, program.Statements[i])
+		}
+
+		if !testIdentifier(t, stmt.Name, name) {
+			return
+		}
+	}
+
+	expected := "MODIFY sphere1 {\nradius: ((CAMERA.position).y),\n}"
+	if program.Statements[1].String() != expected {
+		t.Errorf("wrong statement. expected=%q, got=%q", expected, program.Statements[1].String())
+	}
+}
+
+func TestAtStatement(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`LIGHT light1 AT [0, 1.5, 0] = {}`, "LIGHT light1 AT [0, 1.5, 0] = {\n}"},
+		{`LIGHT light2 EXTENDS light1 AT origin.xyz = {}`, "LIGHT light2 EXTENDS light1 AT (origin.xyz) = {\n}"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseFile()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statements. got=%d", len(program.Statements))
+		}
+
+		stmt, ok := program.Statements[0].(*ast.AssignStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not ast.AssignStatement. got=%T", program.Statements[0])
+		}
+
+		if stmt.Position == nil {
+			t.Fatalf("stmt.Position is nil")
+		}
+
+		if stmt.String() != tt.expected {
+			t.Errorf("wrong statement. expected=%q, got=%q", tt.expected, stmt.String())
+		}
+	}
+}
+
+func TestStringLiteralExpression(t *testing.T) {
+	l := lexer.New(`{rotationOrder: "ZYX"}`)
+	p := New(l)
+	program := p.ParseFile()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	properties, ok := stmt.Expression.(*ast.PropertiesExpression)
+	if !ok {
+		t.Fatalf("exp not *ast.PropertiesExpression. got=%T", stmt.Expression)
+	}
+
+	str, ok := properties.Properties["rotationOrder"].(*ast.StringLiteral)
+	if !ok {
+		t.Fatalf("rotationOrder not *ast.StringLiteral. got=%T", properties.Properties["rotationOrder"])
+	}
+
+	if str.Value != "ZYX" {
+		t.Errorf("str.Value not %q. got=%q", "ZYX", str.Value)
+	}
+}
@@ -0,0 +1,91 @@
+package render
+
+import (
+	"math"
+
+	"github.com/kacperkrolak/scene-description-language/scene"
+)
+
+// film collects the samples of an image, weighted by the filter. Every row
+// keeps what its own samples add to it and to the rows nearby, so rows can
+// be rendered in parallel, and the parts are added up in the same order
+// however they were scheduled.
+type film struct {
+	width, height int
+	filter        Filter
+	radius        float64
+	reach         int               // Rows away from its own which a sample can count towards.
+	rows          [][]weightedColor // (2*reach+1) rows of the image for every row rendered.
+}
+
+type weightedColor struct {
+	color  scene.Color // Sum of the weighted colors of the samples.
+	weight float64     // Sum of the weights.
+}
+
+func newFilm(width, height int, filter Filter, radius float64) *film {
+	return &film{
+		width:  width,
+		height: height,
+		filter: filter,
+		radius: radius,
+		// Filters are zero at their radius, which a sample in the row
+		// reaches only for rows closer than the radius plus half a pixel.
+		reach: int(math.Ceil(radius+0.5)) - 1,
+		rows:  make([][]weightedColor, height),
+	}
+}
+
+// add records a sample of the given row at the point (x, y) of the image,
+// measured in pixels from its top-left corner. Samples of one row must be
+// added by one goroutine.
+func (f *film) add(row int, x, y float64, c scene.Color) {
+	if f.rows[row] == nil {
+		f.rows[row] = make([]weightedColor, (2*f.reach+1)*f.width)
+	}
+
+	minY, maxY := int(math.Ceil(y-0.5-f.radius)), int(math.Floor(y-0.5+f.radius))
+	minX, maxX := int(math.Ceil(x-0.5-f.radius)), int(math.Floor(x-0.5+f.radius))
+	for py := minY; py <= maxY; py++ {
+		if py < 0 || py >= f.height || py < row-f.reach || py > row+f.reach {
+			continue
+		}
+		for px := minX; px <= maxX; px++ {
+			if px < 0 || px >= f.width {
+				continue
+			}
+			weight := f.filter.weight(x-float64(px)-0.5, y-float64(py)-0.5, f.radius)
+			if weight == 0 {
+				continue
+			}
+			w := &f.rows[row][(py-row+f.reach)*f.width+px]
+			w.color = w.color.Add(c.Scale(weight))
+			w.weight += weight
+		}
+	}
+}
+
+// image returns the weighted average of the samples counting towards every pixel.
+func (f *film) image() *Image {
+	sums := make([]weightedColor, f.width*f.height)
+	for row, part := range f.rows {
+		for i, w := range part {
+			py := row - f.reach + i/f.width
+			if py < 0 || py >= f.height {
+				continue
+			}
+			sum := &sums[py*f.width+i%f.width]
+			sum.color = sum.color.Add(w.color)
+			sum.weight += w.weight
+		}
+	}
+
+	img := NewImage(f.width, f.height)
+	for i, sum := range sums {
+		// Filters with negative lobes could leave too little weight.
+		if sum.weight > 0 {
+			img.Pixels[i] = sum.color.Scale(1 / sum.weight)
+		}
+	}
+	return img
+}
@@ -0,0 +1,77 @@
+package render
+
+import (
+	"fmt"
+	"math"
+)
+
+// Filter selects how samples are weighted by their distance from the
+// center of a pixel when they are combined into its color. Samples count
+// towards every pixel whose center is closer than the radius of the filter.
+type Filter string
+
+const (
+	Box      Filter = "box"      // Equal weights, averaging the samples of each pixel with the default radius.
+	Tent     Filter = "tent"     // Weights falling linearly to zero at the radius.
+	Gaussian Filter = "gaussian" // Weights falling smoothly, softer than the tent.
+	Mitchell Filter = "mitchell" // The Mitchell-Netravali filter with B = C = 1/3, which keeps edges sharp.
+)
+
+// ParseFilter returns the filter with the given name.
+func ParseFilter(s string) (Filter, error) {
+	switch Filter(s) {
+	case Box, Tent, Gaussian, Mitchell:
+		return Filter(s), nil
+	}
+	return "", fmt.Errorf("unknown filter %q, expected box, tent, gaussian or mitchell", s)
+}
+
+// DefaultRadius returns the radius in pixels used when none is given.
+func (f Filter) DefaultRadius() float64 {
+	switch f {
+	case Tent:
+		return 1
+	case Gaussian:
+		return 1.5
+	case Mitchell:
+		return 2
+	default:
+		return 0.5
+	}
+}
+
+// weight returns the weight of a sample at the offset (dx, dy) in pixels
+// from the center of a pixel. The filters are separable, so the weight is
+// the product of those along both axes.
+func (f Filter) weight(dx, dy, radius float64) float64 {
+	return f.weight1D(dx, radius) * f.weight1D(dy, radius)
+}
+
+func (f Filter) weight1D(d, radius float64) float64 {
+	switch f {
+	case Tent:
+		return math.Max(0, 1-math.Abs(d)/radius)
+	case Gaussian:
+		// The curve is lowered to reach zero at the radius, three standard deviations away.
+		sigma := radius / 3
+		gaussian := func(x float64) float64 { return math.Exp(-x * x / (2 * sigma * sigma)) }
+		return math.Max(0, gaussian(d)-gaussian(radius))
+	case Mitchell:
+		const b, c = 1.0 / 3, 1.0 / 3
+		x := math.Abs(2 * d / radius)
+		switch {
+		case x < 1:
+			return ((12-9*b-6*c)*x*x*x + (-18+12*b+6*c)*x*x + (6 - 2*b)) / 6
+		case x < 2:
+			return ((-b-6*c)*x*x*x + (6*b+30*c)*x*x + (-12*b-48*c)*x + (8*b + 24*c)) / 6
+		default:
+			return 0
+		}
+	default:
+		// Samples on the edge between two pixels count towards the one after it.
+		if d < -radius || d >= radius {
+			return 0
+		}
+		return 1
+	}
+}
@@ -0,0 +1,95 @@
+package render
+
+import (
+	"math"
+	"testing"
+
+	"github.com/kacperkrolak/scene-description-language/scene"
+	"github.com/kacperkrolak/scene-description-language/transform"
+)
+
+func TestParseFilter(t *testing.T) {
+	for _, name := range []string{"box", "tent", "gaussian", "mitchell"} {
+		if f, err := ParseFilter(name); err != nil || string(f) != name {
+			t.Errorf("ParseFilter(%q) = %q, %v", name, f, err)
+		}
+	}
+	if _, err := ParseFilter("lanczos"); err == nil || err.Error() != `unknown filter "lanczos", expected box, tent, gaussian or mitchell` {
+		t.Errorf("wrong error for an unknown filter. got=%v", err)
+	}
+}
+
+func TestFilterWeights(t *testing.T) {
+	tests := []struct {
+		filter   Filter
+		d        float64
+		expected float64
+	}{
+		{Box, 0, 1},
+		{Box, -0.5, 1},
+		{Box, 0.5, 0},
+		{Tent, 0, 1},
+		{Tent, 0.5, 0.5},
+		{Tent, 1, 0},
+		{Gaussian, 0, 1 - math.Exp(-4.5)},
+		{Gaussian, 1.5, 0},
+		{Mitchell, 0, 8.0 / 9},
+		{Mitchell, 1, 1.0 / 18},
+		{Mitchell, 2, 0},
+	}
+	for _, tt := range tests {
+		if got := tt.filter.weight1D(tt.d, tt.filter.DefaultRadius()); math.Abs(got-tt.expected) > 1e-9 {
+			t.Errorf("%s filter has wrong weight at %g. got=%g, want=%g", tt.filter, tt.d, got, tt.expected)
+		}
+	}
+
+	// The Mitchell filter has a negative lobe, which sharpens edges.
+	if w := Mitchell.weight1D(1.5, 2); w >= 0 {
+		t.Errorf("Mitchell filter should be negative at 3/4 of its radius. got=%g", w)
+	}
+}
+
+func TestRenderFilters(t *testing.T) {
+	// The camera is inside a glowing sphere, so every sample has the same color.
+	glow := &scene.Scene{
+		Camera: scene.DefaultCamera,
+		Spheres: []scene.Sphere{{
+			Name:      "glow",
+			Transform: transform.Identity(),
+			Radius:    10,
+			Material:  scene.Material{Emission: scene.Color{R: 1, G: 0.5, B: 0.25}, IOR: 1},
+		}},
+	}
+	for _, filter := range []Filter{Box, Tent, Gaussian, Mitchell} {
+		img, err := Render(glow, Options{Width: 5, Height: 4, Samples: 9, Filter: filter})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		for i, c := range img.Pixels {
+			if math.Abs(c.R-1) > 1e-9 || math.Abs(c.G-0.5) > 1e-9 || math.Abs(c.B-0.25) > 1e-9 {
+				t.Errorf("%s filter does not keep a flat color at pixel %d. got=%v", filter, i, c)
+				break
+			}
+		}
+	}
+
+	// Wider filters blur the edge of the ball over the pixel just outside of it.
+	s := testScene()
+	for _, tt := range []struct {
+		filter  Filter
+		radius  float64
+		blurred bool
+	}{{Box, 0, false}, {Gaussian, 2, true}} {
+		img, err := Render(s, Options{Width: 21, Height: 21, Samples: 16, Filter: tt.filter, FilterRadius: tt.radius})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if c := img.At(10, 5); (c.R > 0) != tt.blurred {
+			t.Errorf("%s filter blurs wrongly. got=%v", tt.filter, c)
+		}
+	}
+
+	if _, err := Render(s, Options{Width: 1, Height: 1, FilterRadius: -1}); err == nil || err.Error() != "filter radius must not be negative, got -1" {
+		t.Errorf("wrong error for a negative radius. got=%v", err)
+	}
+}
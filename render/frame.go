@@ -0,0 +1,243 @@
+package render
+
+import (
+	"math"
+
+	"github.com/kacperkrolak/scene-description-language/scene"
+	"github.com/kacperkrolak/scene-description-language/transform"
+)
+
+// Frame is a rendered image which can be brought up to date after the scene
+// changes. Every row of the image is rendered with random numbers of its
+// own, so a row traced again comes out the same as in a full render, and
+// the rows which no ray could take to a changed sphere are kept as they are.
+type Frame struct {
+	Image  *Image
+	Traced int // Rows traced to render the image, all of them unless it was updated.
+
+	scene   *scene.Scene
+	options Options // Options with the defaults filled in.
+	film    *film
+	extents []rowExtent
+}
+
+// RenderFrame renders the scene like Render, keeping what an update needs.
+func RenderFrame(s *scene.Scene, options Options) (*Frame, error) {
+	return render(s, options, nil)
+}
+
+// Update renders the scene after it changed from the scene of the frame,
+// tracing only the rows whose rays can meet a sphere which was added,
+// removed or changed. Spheres are matched by name. Changes to the camera,
+// the lights or the options trace every row, as they can affect every pixel.
+func (f *Frame) Update(s *scene.Scene, options Options) (*Frame, error) {
+	return render(s, options, f)
+}
+
+// rowsToTrace reports which rows of the frame must be traced again to render
+// the scene with the options, all of them for a nil frame.
+func (f *Frame) rowsToTrace(s *scene.Scene, options Options, lens bool) []bool {
+	rows := make([]bool, options.Height)
+	if f == nil || f.options != options || f.scene.Camera != s.Camera || !sameLights(f.scene.Lights, s.Lights) {
+		for y := range rows {
+			rows[y] = true
+		}
+		return rows
+	}
+
+	changed := changedSpheres(f.scene.Spheres, s.Spheres)
+	for y := range rows {
+		frustum := newRowFrustum(s.Camera, options, y)
+		for _, b := range changed {
+			// Rays leaving a lens do not share one origin, so the
+			// frustum does not bound them.
+			if lens || frustum.meets(b) || f.extents[y].meets(b, s.Lights) {
+				rows[y] = true
+				break
+			}
+		}
+	}
+	return rows
+}
+
+// ball is a sphere bounding a part of the scene.
+type ball struct {
+	center transform.Vec3
+	radius float64
+}
+
+// changedSpheres returns balls around the spheres which are in only one of
+// the lists or differ between them. Spheres sharing a name are all changed
+// when any of them is.
+func changedSpheres(before, after []scene.Sphere) []ball {
+	byName := func(spheres []scene.Sphere) map[string][]scene.Sphere {
+		m := make(map[string][]scene.Sphere)
+		for _, sphere := range spheres {
+			m[sphere.Name] = append(m[sphere.Name], sphere)
+		}
+		return m
+	}
+	old, current := byName(before), byName(after)
+
+	var changed []ball
+	add := func(spheres, others []scene.Sphere) {
+		if sameSpheres(spheres, others) {
+			return
+		}
+		for _, sphere := range spheres {
+			changed = append(changed, ball{sphere.Center(), sphere.Radius * stretch(sphere.Transform)})
+		}
+	}
+	for name, spheres := range old {
+		add(spheres, current[name])
+	}
+	for name, spheres := range current {
+		add(spheres, old[name])
+	}
+	return changed
+}
+
+func sameSpheres(a, b []scene.Sphere) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func sameLights(a, b []scene.Light) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// stretch returns a bound of how much m lengthens directions, the
+// Frobenius norm of the part of m which is not a translation.
+func stretch(m transform.Mat4) float64 {
+	var sum float64
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			sum += m[i][j] * m[i][j]
+		}
+	}
+	return math.Sqrt(sum)
+}
+
+// lightBall returns a ball around every point of the light which
+// shadow rays are cast towards.
+func lightBall(light scene.Light) ball {
+	var extent float64
+	switch light.Shape {
+	case "sphere", "disk":
+		extent = light.Radius
+	case "rectangle":
+		extent = math.Hypot(light.Width, light.Height) / 2
+	}
+	return ball{light.Position, extent * stretch(light.Transform)}
+}
+
+// rowFrustum bounds the camera rays of a row of a pinhole camera by
+// the planes through the camera and the edges of the row.
+type rowFrustum struct {
+	origin  transform.Vec3
+	normals [4]transform.Vec3 // Unit normals facing into the frustum.
+}
+
+func newRowFrustum(camera scene.Camera, options Options, y int) rowFrustum {
+	width, top, bottom := float64(options.Width), float64(y), float64(y+1)
+	corners := [4]transform.Vec3{
+		cameraRay(camera, options, 0, top, 0, 0).Direction,
+		cameraRay(camera, options, width, top, 0, 0).Direction,
+		cameraRay(camera, options, width, bottom, 0, 0).Direction,
+		cameraRay(camera, options, 0, bottom, 0, 0).Direction,
+	}
+	center := cameraRay(camera, options, width/2, top+0.5, 0, 0)
+
+	f := rowFrustum{origin: center.Origin}
+	for i, corner := range corners {
+		normal := corner.Cross(corners[(i+1)%len(corners)]).Normalize()
+		if normal.Dot(center.Direction) < 0 {
+			normal = normal.Scale(-1)
+		}
+		f.normals[i] = normal
+	}
+	return f
+}
+
+// meets reports whether the ball is not entirely outside the frustum.
+func (f rowFrustum) meets(b ball) bool {
+	for _, normal := range f.normals {
+		if normal.Dot(b.center.Sub(f.origin)) < -b.radius {
+			return false
+		}
+	}
+	return true
+}
+
+// rowExtent bounds the rays traced for a row of the image after they
+// first hit a surface.
+type rowExtent struct {
+	min, max  transform.Vec3 // Corners of a box around the points hit, where shadow rays start.
+	hit       bool           // Whether any ray hit a surface.
+	scattered bool           // Whether any ray went on from a surface other than towards a light.
+}
+
+func (e *rowExtent) add(point transform.Vec3) {
+	if !e.hit {
+		e.min, e.max, e.hit = point, point, true
+		return
+	}
+	e.min = transform.Vec3{X: math.Min(e.min.X, point.X), Y: math.Min(e.min.Y, point.Y), Z: math.Min(e.min.Z, point.Z)}
+	e.max = transform.Vec3{X: math.Max(e.max.X, point.X), Y: math.Max(e.max.Y, point.Y), Z: math.Max(e.max.Z, point.Z)}
+}
+
+// meets reports whether the ball can be in the way of a ray of the row
+// other than a camera ray. Shadow rays go from the box of the points hit
+// to a light, so they stay within a capsule around the segment from the
+// middle of the box to the light, or a half-line for directional lights.
+func (e rowExtent) meets(b ball, lights []scene.Light) bool {
+	if !e.hit {
+		return false
+	}
+	if e.scattered {
+		return true
+	}
+
+	middle := e.min.Add(e.max).Scale(0.5)
+	size := e.max.Sub(e.min).Length()/2 + epsilon
+	for _, light := range lights {
+		if light.Kind == "directional" {
+			if distanceToSegment(b.center, middle, light.Direction.Scale(-1), math.Inf(1)) <= b.radius+size {
+				return true
+			}
+			continue
+		}
+		l := lightBall(light)
+		toLight := l.center.Sub(middle)
+		length := toLight.Length()
+		if length > 0 {
+			toLight = toLight.Scale(1 / length)
+		}
+		if distanceToSegment(b.center, middle, toLight, length) <= b.radius+math.Max(size, l.radius) {
+			return true
+		}
+	}
+	return false
+}
+
+// distanceToSegment returns the distance from the point to the segment
+// starting at origin and going the given length along the unit direction.
+func distanceToSegment(point, origin, direction transform.Vec3, length float64) float64 {
+	t := math.Max(0, math.Min(length, point.Sub(origin).Dot(direction)))
+	return point.Sub(origin.Add(direction.Scale(t))).Length()
+}
@@ -0,0 +1,138 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/kacperkrolak/scene-description-language/scene"
+	"github.com/kacperkrolak/scene-description-language/transform"
+)
+
+// frameScene has a ball on a floor, a moon beside it and a lamp above them,
+// which casts the shadows of the ball and the moon onto the floor.
+func frameScene() *scene.Scene {
+	s := testScene()
+	s.Spheres = append(s.Spheres,
+		scene.Sphere{
+			Name:      "floor",
+			Transform: transform.Translation(transform.Vec3{Y: -101}),
+			Radius:    100,
+			Material:  scene.DefaultMaterial,
+		},
+		scene.Sphere{
+			Name:      "moon",
+			Transform: transform.Translation(transform.Vec3{X: 1.6, Y: 1.2, Z: 1}),
+			Radius:    0.3,
+			Material:  scene.DefaultMaterial,
+		},
+	)
+	s.Lights[0].Position = transform.Vec3{Y: 10, Z: -2}
+	return s
+}
+
+func TestFrameUpdate(t *testing.T) {
+	options := Options{Width: 32, Height: 24, MaxDepth: 3}
+	moveMoon := func(s *scene.Scene) {
+		s.Spheres[2].Transform = transform.Translation(transform.Vec3{X: 1.4, Y: 1.3, Z: 1})
+	}
+	tests := []struct {
+		name    string
+		options Options                // Options of both renders, those above if empty.
+		setup   func(s *scene.Scene)   // Applied to the scene before and after the change.
+		change  func(s *scene.Scene)   // Applied to the scene after the change.
+		updated func(options *Options) // Applied to the options of the update.
+		all     bool                   // Whether every row must be traced again.
+	}{
+		{name: "moved moon", change: moveMoon},
+		{name: "recolored moon", change: func(s *scene.Scene) { s.Spheres[2].Material.Color = scene.Color{R: 1} }},
+		{name: "removed moon", change: func(s *scene.Scene) { s.Spheres = s.Spheres[:2] }},
+		{name: "added sphere", change: func(s *scene.Scene) {
+			s.Spheres = append(s.Spheres, scene.Sphere{Name: "pebble", Transform: transform.Translation(transform.Vec3{X: -1.5, Y: -0.8}), Radius: 0.2, Material: scene.DefaultMaterial})
+		}},
+		{name: "shadow of a sphere out of view", change: func(s *scene.Scene) {
+			s.Spheres = append(s.Spheres, scene.Sphere{Name: "cloud", Transform: transform.Translation(transform.Vec3{Y: 8, Z: -1}), Radius: 0.5, Material: scene.DefaultMaterial})
+		}},
+		{name: "directional light", setup: func(s *scene.Scene) {
+			s.Lights[0].Kind, s.Lights[0].Direction = "directional", transform.Vec3{X: 0.3, Y: -1}.Normalize()
+		}, change: moveMoon},
+		{name: "area light", setup: func(s *scene.Scene) {
+			s.Lights[0].Shape, s.Lights[0].Radius, s.Lights[0].Samples = "sphere", 1, 4
+			s.Lights[0].Transform = transform.Translation(s.Lights[0].Position)
+		}, change: moveMoon},
+		{name: "mirror", setup: func(s *scene.Scene) { s.Spheres[0].Material.Reflectivity = 0.5 }, change: moveMoon},
+		{name: "tent filter", options: Options{Width: 32, Height: 24, MaxDepth: 3, Filter: Tent, Samples: 4}, change: moveMoon},
+		{name: "path tracing", options: Options{Width: 32, Height: 24, MaxDepth: 3, Integrator: PathTracing, Samples: 2}, change: moveMoon},
+		{name: "depth of field", setup: func(s *scene.Scene) { s.Camera.Aperture, s.Camera.FocalDistance = 0.2, 5 }, change: moveMoon, all: true},
+		{name: "moved lamp", change: func(s *scene.Scene) { s.Lights[0].Position.X = 1 }, all: true},
+		{name: "moved camera", change: func(s *scene.Scene) { s.Camera.Transform = transform.Translation(transform.Vec3{Z: -6}) }, all: true},
+		{name: "more samples", updated: func(options *Options) { options.Samples = 4 }, all: true},
+	}
+
+	for _, tt := range tests {
+		before, after := frameScene(), frameScene()
+		if tt.setup != nil {
+			tt.setup(before)
+			tt.setup(after)
+		}
+		if tt.change != nil {
+			tt.change(after)
+		}
+		first := options
+		if tt.options != (Options{}) {
+			first = tt.options
+		}
+		updated := first
+		if tt.updated != nil {
+			tt.updated(&updated)
+		}
+
+		frame, err := RenderFrame(before, first)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", tt.name, err)
+		}
+		frame, err = frame.Update(after, updated)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", tt.name, err)
+		}
+		full, err := Render(after, updated)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", tt.name, err)
+		}
+
+		for i := range full.Pixels {
+			if frame.Image.Pixels[i] != full.Pixels[i] {
+				t.Errorf("%s: pixel %d,%d differs from a full render. got=%v, want=%v", tt.name, i%full.Width, i/full.Width, frame.Image.Pixels[i], full.Pixels[i])
+				break
+			}
+		}
+		if tt.all && frame.Traced != updated.Height {
+			t.Errorf("%s: every row should be traced again. got=%d", tt.name, frame.Traced)
+		}
+		if !tt.all && (frame.Traced == 0 || frame.Traced == updated.Height) {
+			t.Errorf("%s: only the rows affected by the change should be traced again. got=%d", tt.name, frame.Traced)
+		}
+	}
+}
+
+func TestFrameUpdateUnchanged(t *testing.T) {
+	options := Options{Width: 32, Height: 24}
+	frame, err := RenderFrame(frameScene(), options)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if frame.Traced != options.Height {
+		t.Errorf("the first render should trace every row. got=%d", frame.Traced)
+	}
+
+	updated, err := frame.Update(frameScene(), options)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if updated.Traced != 0 {
+		t.Errorf("an unchanged scene should not be traced again. got=%d", updated.Traced)
+	}
+	for i := range frame.Image.Pixels {
+		if updated.Image.Pixels[i] != frame.Image.Pixels[i] {
+			t.Fatalf("pixel %d changed. got=%v, want=%v", i, updated.Image.Pixels[i], frame.Image.Pixels[i])
+		}
+	}
+}
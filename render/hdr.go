@@ -0,0 +1,105 @@
+package render
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// WriteHDR encodes the image in the Radiance RGBE format, which keeps the
+// linear colors unclamped: every pixel is stored as three 8-bit mantissas
+// sharing an exponent. Scanlines are run-length encoded, unless they are
+// too short or too long for it. Negative components are written as zero.
+func (img *Image) WriteHDR(w io.Writer) error {
+	out := bufio.NewWriter(w)
+	fmt.Fprintf(out, "#?RADIANCE\nFORMAT=32-bit_rle_rgbe\n\n-Y %d +X %d\n", img.Height, img.Width)
+
+	scanline := make([][4]byte, img.Width)
+	for y := 0; y < img.Height; y++ {
+		for x := range scanline {
+			scanline[x] = rgbe(img.At(x, y).R, img.At(x, y).G, img.At(x, y).B)
+		}
+		if img.Width < 8 || img.Width > 0x7fff {
+			for _, pixel := range scanline {
+				out.Write(pixel[:])
+			}
+			continue
+		}
+
+		// The scanline starts with a marker and its width, followed by
+		// each of the four components of all pixels in turn.
+		out.Write([]byte{2, 2, byte(img.Width >> 8), byte(img.Width)})
+		component := make([]byte, img.Width)
+		for i := 0; i < 4; i++ {
+			for x, pixel := range scanline {
+				component[x] = pixel[i]
+			}
+			writeRuns(out, component)
+		}
+	}
+	return out.Flush()
+}
+
+// rgbe returns the shared exponent form of a color.
+func rgbe(r, g, b float64) [4]byte {
+	r, g, b = math.Max(0, r), math.Max(0, g), math.Max(0, b)
+	v := math.Max(r, math.Max(g, b))
+	if v < 1e-32 {
+		return [4]byte{}
+	}
+	mantissa, exponent := math.Frexp(v)
+	scale := mantissa * 256 / v
+	return [4]byte{byte(r * scale), byte(g * scale), byte(b * scale), byte(exponent + 128)}
+}
+
+// writeRuns run-length encodes the bytes: runs of at least 3 equal bytes
+// are written as 128 plus their length followed by the byte, and other
+// bytes as their count followed by themselves, at most 127 at a time.
+func writeRuns(out *bufio.Writer, data []byte) {
+	const minRun, maxRun = 3, 127
+	for i := 0; i < len(data); {
+		run := 1
+		for i+run < len(data) && run < maxRun && data[i+run] == data[i] {
+			run++
+		}
+		if run >= minRun {
+			out.Write([]byte{byte(128 + run), data[i]})
+			i += run
+			continue
+		}
+
+		// Copy the bytes up to the next run, or the longest literal allowed.
+		end := i
+		for end < len(data) && end-i < maxRun {
+			if end+minRun <= len(data) && data[end] == data[end+1] && data[end] == data[end+2] {
+				break
+			}
+			end++
+		}
+		out.WriteByte(byte(end - i))
+		out.Write(data[i:end])
+		i = end
+	}
+}
+
+// WritePFM encodes the image in the Portable Float Map format, with every
+// component as a 32-bit float. As the format requires, rows are written
+// from the bottom up, in little-endian byte order.
+func (img *Image) WritePFM(w io.Writer) error {
+	out := bufio.NewWriter(w)
+	fmt.Fprintf(out, "PF\n%d %d\n-1.0\n", img.Width, img.Height)
+
+	var buf [4]byte
+	for y := img.Height - 1; y >= 0; y-- {
+		for x := 0; x < img.Width; x++ {
+			c := img.At(x, y)
+			for _, v := range [3]float64{c.R, c.G, c.B} {
+				binary.LittleEndian.PutUint32(buf[:], math.Float32bits(float32(v)))
+				out.Write(buf[:])
+			}
+		}
+	}
+	return out.Flush()
+}
@@ -0,0 +1,150 @@
+package render
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/kacperkrolak/scene-description-language/scene"
+)
+
+// readHDR decodes a Radiance file with flat or run-length encoded scanlines.
+func readHDR(t *testing.T, data []byte) *Image {
+	t.Helper()
+	in := bufio.NewReader(bytes.NewReader(data))
+	var header []string
+	for {
+		line, err := in.ReadString('\n')
+		if err != nil {
+			t.Fatalf("header is not terminated: %q", header)
+		}
+		if line == "\n" {
+			break
+		}
+		header = append(header, strings.TrimSuffix(line, "\n"))
+	}
+	if len(header) < 2 || header[0] != "#?RADIANCE" || header[1] != "FORMAT=32-bit_rle_rgbe" {
+		t.Fatalf("wrong header. got=%q", header)
+	}
+	var width, height int
+	if _, err := fmt.Fscanf(in, "-Y %d +X %d\n", &height, &width); err != nil {
+		t.Fatalf("wrong resolution: %s", err)
+	}
+
+	img := NewImage(width, height)
+	read := func(n int) []byte {
+		b := make([]byte, n)
+		if _, err := io.ReadFull(in, b); err != nil {
+			t.Fatalf("file is too short: %s", err)
+		}
+		return b
+	}
+	for y := 0; y < height; y++ {
+		pixels := make([][4]byte, width)
+		if width < 8 || width > 0x7fff {
+			for x := range pixels {
+				copy(pixels[x][:], read(4))
+			}
+		} else {
+			if marker := read(4); marker[0] != 2 || marker[1] != 2 || int(marker[2])<<8|int(marker[3]) != width {
+				t.Fatalf("wrong scanline marker. got=%v", marker)
+			}
+			for i := 0; i < 4; i++ {
+				for x := 0; x < width; {
+					count := int(read(1)[0])
+					if count > 128 {
+						value := read(1)[0]
+						for j := 0; j < count-128; j++ {
+							pixels[x+j][i] = value
+						}
+						x += count - 128
+					} else {
+						for j, value := range read(count) {
+							pixels[x+j][i] = value
+						}
+						x += count
+					}
+				}
+			}
+		}
+		for x, p := range pixels {
+			if p[3] == 0 {
+				continue
+			}
+			scale := math.Ldexp(1, int(p[3])-136)
+			img.Set(x, y, scene.Color{R: (float64(p[0]) + 0.5) * scale, G: (float64(p[1]) + 0.5) * scale, B: (float64(p[2]) + 0.5) * scale})
+		}
+	}
+	return img
+}
+
+func testImage(width, height int) *Image {
+	img := NewImage(width, height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			// Runs of equal pixels and changing ones, some brighter than white.
+			img.Set(x, y, scene.Color{R: float64(x/4) * 3, G: 0.25, B: float64(x*y) / 10})
+		}
+	}
+	img.Set(0, 0, scene.Color{R: -1})
+	return img
+}
+
+func TestWriteHDR(t *testing.T) {
+	for _, width := range []int{5, 300} {
+		img := testImage(width, 3)
+		var buf bytes.Buffer
+		if err := img.WriteHDR(&buf); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if width >= 8 && buf.Len() >= 3*width*4 {
+			t.Errorf("scanlines of width %d are not compressed. got %d bytes", width, buf.Len())
+		}
+
+		decoded := readHDR(t, buf.Bytes())
+		if decoded.Width != width || decoded.Height != 3 {
+			t.Fatalf("wrong size. got=%dx%d", decoded.Width, decoded.Height)
+		}
+		for i, want := range img.Pixels {
+			want = scene.Color{R: math.Max(0, want.R), G: want.G, B: want.B}
+			got := decoded.Pixels[i]
+			// The mantissas keep 8 bits of the brightest component.
+			tolerance := math.Max(want.R, math.Max(want.G, want.B)) / 128
+			if math.Abs(got.R-want.R) > tolerance || math.Abs(got.G-want.G) > tolerance || math.Abs(got.B-want.B) > tolerance {
+				t.Fatalf("wrong pixel %d of width %d. got=%v, want=%v", i, width, got, want)
+			}
+		}
+	}
+}
+
+func TestWritePFM(t *testing.T) {
+	img := testImage(3, 2)
+	var buf bytes.Buffer
+	if err := img.WritePFM(&buf); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	header := "PF\n3 2\n-1.0\n"
+	data := buf.Bytes()
+	if !bytes.HasPrefix(data, []byte(header)) || len(data) != len(header)+3*2*3*4 {
+		t.Fatalf("wrong file. got=%q", data)
+	}
+	data = data[len(header):]
+	for i := 0; i < 3*2; i++ {
+		// Rows go from the bottom up.
+		x, y := i%3, 1-i/3
+		want := img.At(x, y)
+		var got [3]float64
+		for j := range got {
+			got[j] = float64(math.Float32frombits(binary.LittleEndian.Uint32(data[(i*3+j)*4:])))
+		}
+		if got != [3]float64{float64(float32(want.R)), float64(float32(want.G)), float64(float32(want.B))} {
+			t.Errorf("wrong pixel (%d, %d). got=%v, want=%v", x, y, got, want)
+		}
+	}
+}
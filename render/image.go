@@ -0,0 +1,51 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+
+	"github.com/kacperkrolak/scene-description-language/scene"
+)
+
+// Image holds linear colors which can exceed 1 before they are converted for display.
+type Image struct {
+	Width, Height int
+	Pixels        []scene.Color // Rows from top to bottom.
+}
+
+func NewImage(width, height int) *Image {
+	return &Image{Width: width, Height: height, Pixels: make([]scene.Color, width*height)}
+}
+
+func (img *Image) At(x, y int) scene.Color {
+	return img.Pixels[y*img.Width+x]
+}
+
+func (img *Image) Set(x, y int, c scene.Color) {
+	img.Pixels[y*img.Width+x] = c
+}
+
+// ToRGBA converts the image to 8-bit sRGB colors as shown by the display.
+func (img *Image) ToRGBA(d Display) *image.RGBA {
+	rgba := image.NewRGBA(image.Rect(0, 0, img.Width, img.Height))
+	for y := 0; y < img.Height; y++ {
+		for x := 0; x < img.Width; x++ {
+			c := d.Map(img.At(x, y))
+			rgba.SetRGBA(x, y, color.RGBA{R: toByte(c.R), G: toByte(c.G), B: toByte(c.B), A: 255})
+		}
+	}
+	return rgba
+}
+
+// WritePNG encodes the image as PNG, as shown by the display.
+func (img *Image) WritePNG(w io.Writer, d Display) error {
+	return png.Encode(w, img.ToRGBA(d))
+}
+
+// toByte encodes a linear component between 0 and 1 as an 8-bit sRGB value.
+func toByte(v float64) uint8 {
+	return uint8(math.Round(sRGB(v) * 255))
+}
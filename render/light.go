@@ -0,0 +1,40 @@
+package render
+
+import (
+	"math/rand"
+
+	"github.com/kacperkrolak/scene-description-language/scene"
+	"github.com/kacperkrolak/scene-description-language/shading"
+)
+
+// direct returns the light reflected towards the viewer straight from every
+// LIGHT which is not in shadow. Lights with a shape are sampled at points
+// spread over a grid on it, as many as their samples, and the share of them
+// in shadow gives soft shadows.
+func (r *renderer) direct(surface shading.Surface, random *rand.Rand) scene.Color {
+	var color scene.Color
+	for _, light := range r.scene.Lights {
+		points := [][2]float64{{0.5, 0.5}}
+		if light.Shape != "" && light.Shape != "point" && light.Kind != "directional" && light.Samples > 1 {
+			points = make([][2]float64, light.Samples)
+			Stratified.offsets(points, random)
+		}
+
+		var sum scene.Color
+		for _, p := range points {
+			in := shading.Incident(light, surface.Point, p[0], p[1])
+			if in.Strength == 0 || surface.Normal.Dot(in.ToLight) <= 0 || r.occluded(surface, in) {
+				continue
+			}
+			sum = sum.Add(r.shading.Direct(surface, in))
+		}
+		color = color.Add(sum.Scale(1 / float64(len(points))))
+	}
+	return color
+}
+
+// occluded reports whether any surface lies between the point and the light.
+func (r *renderer) occluded(surface shading.Surface, in shading.Incoming) bool {
+	hit, ok := r.closestHit(Ray{Origin: surface.Point, Direction: in.ToLight}, epsilon)
+	return ok && hit.T < in.Distance
+}
@@ -0,0 +1,79 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/kacperkrolak/scene-description-language/scene"
+	"github.com/kacperkrolak/scene-description-language/transform"
+)
+
+// lightScene is the test scene with a blocker between the ball and the
+// light, and the light replaced by the given one.
+func lightScene(light scene.Light, blocker transform.Vec3) *scene.Scene {
+	s := testScene()
+	s.Spheres = append(s.Spheres, scene.Sphere{
+		Name:      "blocker",
+		Transform: transform.Translation(blocker),
+		Radius:    0.5,
+		Material:  scene.DefaultMaterial,
+	})
+	light.Color, light.DiffuseIntensity = scene.White, 1
+	s.Lights = []scene.Light{light}
+	return s
+}
+
+func TestRenderSoftShadows(t *testing.T) {
+	at := transform.Translation(transform.Vec3{Z: -10})
+	point := scene.Light{Kind: "point", Shape: "point", Transform: at, Position: at.Position()}
+	disk := point
+	disk.Shape, disk.Radius, disk.Samples = "disk", 2, 64
+
+	shadowed, lit := scene.Color{R: 0.1, G: 0.05}, scene.Color{R: 0.9, G: 0.45}
+	for _, tt := range []struct {
+		light scene.Light
+		soft  bool
+	}{{point, false}, {disk, true}} {
+		img, err := Render(lightScene(tt.light, transform.Vec3{Z: -7.5}), Options{Width: 21, Height: 21})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		center := img.At(10, 10)
+		if !tt.soft {
+			testColor(t, "hard shadow", center, shadowed)
+			continue
+		}
+		// Most of the disk can be seen around the blocker.
+		if center.R <= shadowed.R+0.5 || center.R >= lit.R-0.01 {
+			t.Errorf("center is not in the penumbra. got=%v", center)
+		}
+	}
+}
+
+func TestRenderDirectionalLight(t *testing.T) {
+	// The sun is infinitely far away, so even a distant blocker casts a shadow.
+	sun := scene.Light{Kind: "directional", Direction: transform.Vec3{Z: 1}, Position: transform.Vec3{Z: 1000}}
+	img, err := Render(lightScene(sun, transform.Vec3{Z: -100}), Options{Width: 21, Height: 21})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	testColor(t, "shadowed center", img.At(10, 10), scene.Color{R: 0.1, G: 0.05})
+
+	img, err = Render(lightScene(sun, transform.Vec3{X: 100}), Options{Width: 21, Height: 21})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	testColor(t, "lit center", img.At(10, 10), scene.Color{R: 0.9, G: 0.45})
+}
+
+func TestRenderSpotLight(t *testing.T) {
+	spot := scene.Light{Kind: "spot", Shape: "point", Position: transform.Vec3{Z: -10}, Direction: transform.Vec3{Z: 1}, ConeAngle: 2}
+	img, err := Render(lightScene(spot, transform.Vec3{X: 100}), Options{Width: 21, Height: 21})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// Only the center of the ball is inside the narrow cone.
+	testColor(t, "center", img.At(10, 10), scene.Color{R: 0.9, G: 0.45})
+	testColor(t, "outside the cone", img.At(13, 10), scene.Color{R: 0.1, G: 0.05})
+}
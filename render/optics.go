@@ -0,0 +1,42 @@
+package render
+
+import (
+	"math"
+
+	"github.com/kacperkrolak/scene-description-language/transform"
+)
+
+// reflect returns the direction mirrored about the normal.
+func reflect(direction, normal transform.Vec3) transform.Vec3 {
+	return direction.Sub(normal.Scale(2 * direction.Dot(normal)))
+}
+
+// refract returns the unit direction of a unit direction bent by Snell's law
+// when passing through a surface, with the normal facing the incoming ray and
+// eta the index of refraction outside divided by the one inside. It returns
+// false if all of the light is reflected.
+func refract(direction, normal transform.Vec3, eta float64) (transform.Vec3, bool) {
+	cosIncident := -direction.Dot(normal)
+	sin2Transmitted := eta * eta * (1 - cosIncident*cosIncident)
+	if sin2Transmitted > 1 {
+		return transform.Vec3{}, false
+	}
+
+	cosTransmitted := math.Sqrt(1 - sin2Transmitted)
+	return direction.Scale(eta).Add(normal.Scale(eta*cosIncident - cosTransmitted)), true
+}
+
+// schlick returns the share of the light reflected by a surface between
+// media with the indices of refraction n1 and n2, with Schlick's
+// approximation of the Fresnel equations. The angle is taken on the side of
+// the thinner medium, so it is the refracted one when light leaves a thicker medium.
+func schlick(direction, normal, refracted transform.Vec3, n1, n2 float64) float64 {
+	r0 := (n1 - n2) / (n1 + n2)
+	r0 *= r0
+
+	cos := -direction.Dot(normal)
+	if n1 > n2 {
+		cos = -refracted.Dot(normal)
+	}
+	return r0 + (1-r0)*math.Pow(1-cos, 5)
+}
@@ -0,0 +1,129 @@
+package render
+
+import (
+	"math"
+	"testing"
+
+	"github.com/kacperkrolak/scene-description-language/scene"
+	"github.com/kacperkrolak/scene-description-language/transform"
+)
+
+func testVector(t *testing.T, name string, got, expected transform.Vec3) {
+	t.Helper()
+	if got.Sub(expected).Length() > 1e-9 {
+		t.Errorf("%s is wrong. got=%v, want=%v", name, got, expected)
+	}
+}
+
+func TestReflect(t *testing.T) {
+	direction := transform.Vec3{X: 1, Y: -1}.Normalize()
+	testVector(t, "reflection", reflect(direction, transform.Vec3{Y: 1}), transform.Vec3{X: 1, Y: 1}.Normalize())
+}
+
+func TestRefract(t *testing.T) {
+	up := transform.Vec3{Y: 1}
+	direction := transform.Vec3{X: 1, Y: -1}.Normalize()
+
+	// Entering glass, the sine of the angle shrinks by the index of refraction.
+	refracted, ok := refract(direction, up, 1/1.5)
+	sin := math.Sqrt(0.5) / 1.5
+	if !ok {
+		t.Fatalf("light entering glass should be refracted")
+	}
+	testVector(t, "refraction", refracted, transform.Vec3{X: sin, Y: -math.Sqrt(1 - sin*sin)})
+
+	testVector(t, "straight", mustRefract(t, transform.Vec3{Y: -1}, up, 1/1.5), transform.Vec3{Y: -1})
+
+	// Leaving glass at 45° is beyond the critical angle of about 41.8°.
+	if _, ok := refract(direction, up, 1.5); ok {
+		t.Errorf("expected total internal reflection")
+	}
+}
+
+func mustRefract(t *testing.T, direction, normal transform.Vec3, eta float64) transform.Vec3 {
+	t.Helper()
+	refracted, ok := refract(direction, normal, eta)
+	if !ok {
+		t.Fatalf("expected %v to be refracted", direction)
+	}
+	return refracted
+}
+
+func TestSchlick(t *testing.T) {
+	up := transform.Vec3{Y: 1}
+	down := transform.Vec3{Y: -1}
+
+	// Glass reflects 4% of the light coming straight at it, from either side.
+	if got := schlick(down, up, down, 1, 1.5); math.Abs(got-0.04) > 1e-9 {
+		t.Errorf("wrong reflectance entering glass. got=%v", got)
+	}
+	if got := schlick(down, up, down, 1.5, 1); math.Abs(got-0.04) > 1e-9 {
+		t.Errorf("wrong reflectance leaving glass. got=%v", got)
+	}
+
+	// At grazing angles nearly all of it.
+	grazing := transform.Vec3{X: 1, Y: -0.001}.Normalize()
+	if got := schlick(grazing, up, mustRefract(t, grazing, up, 1/1.5), 1, 1.5); got < 0.99 {
+		t.Errorf("wrong reflectance at a grazing angle. got=%v", got)
+	}
+}
+
+// opticsScene has a sphere in front of the camera with the given material,
+// and a sphere of a plain color both behind the camera and behind the sphere.
+func opticsScene(material scene.Material) *scene.Scene {
+	camera := scene.DefaultCamera
+	camera.Transform = transform.Translation(transform.Vec3{Z: -5})
+	plain := func(name string, z float64, color scene.Color) scene.Sphere {
+		return scene.Sphere{
+			Name:      name,
+			Transform: transform.Translation(transform.Vec3{Z: z}),
+			Radius:    1,
+			Material:  scene.Material{Color: color, AmbientIntensity: 1, IOR: 1},
+		}
+	}
+
+	return &scene.Scene{
+		Camera: camera,
+		Spheres: []scene.Sphere{
+			{Name: "ball", Transform: transform.Identity(), Radius: 1, Material: material},
+			plain("front", -10, scene.Color{R: 1}),
+			plain("back", 5, scene.Color{G: 1}),
+		},
+	}
+}
+
+func TestRenderMirror(t *testing.T) {
+	s := opticsScene(scene.Material{Color: scene.White, AmbientIntensity: 1, Reflectivity: 1, IOR: 1})
+
+	// The center of the mirror reflects the sphere behind the camera.
+	img, err := Render(s, Options{Width: 21, Height: 21, MaxDepth: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	testColor(t, "mirror", img.At(10, 10), scene.Color{R: 1})
+
+	img, err = Render(s, Options{Width: 21, Height: 21})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	testColor(t, "mirror without reflections", img.At(10, 10), scene.Color{})
+}
+
+func TestRenderGlass(t *testing.T) {
+	s := opticsScene(scene.Material{Color: scene.White, AmbientIntensity: 1, Transparency: 1, IOR: 1.5})
+
+	// Straight through the center, 4% is reflected at both surfaces.
+	// The light reflected inside the sphere needs more bounces to leave it.
+	img, err := Render(s, Options{Width: 21, Height: 21, MaxDepth: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	testColor(t, "glass", img.At(10, 10), scene.Color{R: 0.04, G: 0.96 * 0.96})
+
+	// Only one surface is passed with a depth of 1.
+	img, err = Render(s, Options{Width: 21, Height: 21, MaxDepth: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	testColor(t, "glass at depth 1", img.At(10, 10), scene.Color{R: 0.04})
+}
@@ -0,0 +1,86 @@
+package render
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/kacperkrolak/scene-description-language/scene"
+	"github.com/kacperkrolak/scene-description-language/shading"
+	"github.com/kacperkrolak/scene-description-language/transform"
+)
+
+// rouletteDepth is the number of bounces after which paths may be ended at random.
+const rouletteDepth = 3
+
+// path returns an estimate of the light coming back along a ray with a unit
+// direction, following a random path of bounces between surfaces.
+//
+// At every surface the path takes one of the ways light leaves it, picked
+// at random in proportion to its share: scattered by the matte surface in a
+// cosine-weighted direction, reflected like in a mirror, or refracted, with
+// the chance of reflecting from the Fresnel equations. Light given off by
+// surfaces is collected wherever the path meets them, and the light coming
+// straight from every LIGHT is added at each matte surface, which is much
+// less noisy than waiting for the path to find it. The ambient light is left
+// out, as the light bounced between surfaces takes its place.
+//
+// After a few bounces, paths carrying little light are ended at random,
+// and the light of those which go on is raised to make up for it.
+func (r *renderer) path(ray Ray, random *rand.Rand) scene.Color {
+	var color scene.Color
+	throughput := scene.White
+	tMin := 0.0
+	for depth := 0; ; depth++ {
+		hit, ok := r.closestHit(ray, tMin)
+		if !ok {
+			return color.Add(throughput.Mul(Background))
+		}
+		// Paths may bounce anywhere, which leaves the rays of the row unbounded.
+		r.extent.add(hit.Point)
+		r.extent.scattered = true
+		material := hit.Material
+		color = color.Add(throughput.Mul(material.Emission))
+
+		normal, n1, n2 := hit.Normal, 1.0, material.IOR
+		if ray.Direction.Dot(normal) > 0 {
+			normal, n1, n2 = normal.Scale(-1), n2, n1
+		}
+
+		diffuse := math.Max(0, 1-material.Reflectivity-material.Transparency)
+		total := diffuse + material.Reflectivity + material.Transparency
+		choice := random.Float64() * total
+		// The share of the chosen way is divided by the chance of choosing it.
+		throughput = throughput.Scale(total)
+
+		var direction transform.Vec3
+		switch {
+		case choice < diffuse:
+			surface := shading.Surface{Point: hit.Point, Normal: normal, ToViewer: ray.Direction.Scale(-1), Material: material}
+			color = color.Add(throughput.Mul(r.direct(surface, random)))
+			direction = cosineHemisphere(normal, random.Float64(), random.Float64())
+			throughput = throughput.Mul(material.Color.Scale(material.DiffuseIntensity))
+		case choice < diffuse+material.Reflectivity:
+			direction = reflect(ray.Direction, normal)
+		default:
+			// Beyond the critical angle, all of the light is reflected.
+			direction = reflect(ray.Direction, normal)
+			if refracted, ok := refract(ray.Direction, normal, n1/n2); ok && random.Float64() >= schlick(ray.Direction, normal, refracted, n1, n2) {
+				direction = refracted
+			}
+		}
+
+		if depth >= r.maxDepth {
+			return color
+		}
+		if depth >= rouletteDepth {
+			survival := math.Min(0.95, math.Max(throughput.R, math.Max(throughput.G, throughput.B)))
+			if random.Float64() >= survival {
+				return color
+			}
+			throughput = throughput.Scale(1 / survival)
+		}
+
+		ray = Ray{Origin: hit.Point, Direction: direction}
+		tMin = epsilon
+	}
+}
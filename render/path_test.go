@@ -0,0 +1,100 @@
+package render
+
+import (
+	"math"
+	"testing"
+
+	"github.com/kacperkrolak/scene-description-language/scene"
+	"github.com/kacperkrolak/scene-description-language/transform"
+)
+
+func pathOptions(samples int) Options {
+	return Options{Width: 21, Height: 21, MaxDepth: 5, Integrator: PathTracing, Samples: samples}
+}
+
+func TestRenderPathDirect(t *testing.T) {
+	s := testScene()
+	s.Camera.AmbientIntensity = 0
+
+	img, err := Render(s, pathOptions(16))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// Nothing else is in the scene, so only the light shines on the center,
+	// as with the Whitted integrator: diffuse 0.8 * 1 * cos(0).
+	center := img.At(10, 10)
+	if math.Abs(center.R-0.8) > 0.01 || math.Abs(center.G-0.4) > 0.01 || center.B != 0 {
+		t.Errorf("center has wrong color. got=%v", center)
+	}
+	testColor(t, "corner", img.At(0, 0), Background)
+}
+
+func TestRenderPathEmission(t *testing.T) {
+	s := testScene()
+	s.Spheres[0].Material = scene.Material{Emission: scene.Color{R: 2, G: 1}, IOR: 1}
+	s.Lights = nil
+
+	img, err := Render(s, pathOptions(4))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	testColor(t, "center", img.At(10, 10), scene.Color{R: 2, G: 1})
+}
+
+func TestRenderPathIndirect(t *testing.T) {
+	// The ball is lit only by a glowing sphere behind the camera,
+	// which the Whitted integrator does not treat as a light.
+	s := testScene()
+	s.Camera.AmbientIntensity = 0
+	s.Lights = nil
+	s.Spheres = append(s.Spheres, scene.Sphere{
+		Name:      "glow",
+		Transform: transform.Translation(transform.Vec3{Z: -20}),
+		Radius:    10,
+		Material:  scene.Material{Emission: scene.White, IOR: 1},
+	})
+
+	whitted, err := Render(s, Options{Width: 21, Height: 21, MaxDepth: 5})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	testColor(t, "center with Whitted", whitted.At(10, 10), scene.Color{})
+
+	path, err := Render(s, pathOptions(64))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if center := path.At(10, 10); center.R < 0.1 || center.G < 0.05 || center.G > center.R {
+		t.Errorf("center should be lit by the glowing sphere. got=%v", center)
+	}
+
+	again, err := Render(s, pathOptions(64))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for i := range path.Pixels {
+		if path.Pixels[i] != again.Pixels[i] {
+			t.Fatalf("rendering the same scene twice gave different images")
+		}
+	}
+}
+
+func TestOptionsWithSettings(t *testing.T) {
+	settings := scene.Settings{Integrator: "path", Samples: 8, MaxDepth: 3, Shading: "blinn-phong", Sampler: "jittered", Filter: "tent", FilterRadius: 1.5}
+	got := DefaultOptions.WithSettings(settings)
+	expected := Options{
+		Width:        640,
+		Height:       480,
+		Shading:      "blinn-phong",
+		MaxDepth:     3,
+		Integrator:   PathTracing,
+		Samples:      8,
+		Sampler:      Jittered,
+		Filter:       Tent,
+		FilterRadius: 1.5,
+	}
+	if got != expected {
+		t.Errorf("wrong options. got=%+v, want=%+v", got, expected)
+	}
+}
@@ -0,0 +1,78 @@
+package render
+
+import (
+	"math"
+
+	"github.com/kacperkrolak/scene-description-language/scene"
+	"github.com/kacperkrolak/scene-description-language/transform"
+)
+
+// epsilon offsets secondary rays from surfaces so they do not hit the surface they start on.
+const epsilon = 1e-6
+
+// Ray is a half-line starting at Origin. Direction does not have to be normalized.
+type Ray struct {
+	Origin    transform.Vec3
+	Direction transform.Vec3
+}
+
+// At returns the point at distance t along the ray, measured in lengths of Direction.
+func (r Ray) At(t float64) transform.Vec3 {
+	return r.Origin.Add(r.Direction.Scale(t))
+}
+
+// Hit describes where a ray meets a surface.
+type Hit struct {
+	T        float64 // Distance along the ray, in lengths of its direction.
+	Point    transform.Vec3
+	Normal   transform.Vec3 // Unit normal facing away from the surface.
+	Material scene.Material
+}
+
+// object is a sphere prepared for intersection tests.
+type object struct {
+	sphere  scene.Sphere
+	inverse transform.Mat4 // Transform from world space to the space of the sphere.
+	normal  transform.Mat4 // Transform of normals from the space of the sphere to world space.
+}
+
+func newObject(sphere scene.Sphere) (object, bool) {
+	inverse, ok := sphere.Transform.Inverse()
+	if !ok {
+		return object{}, false
+	}
+	return object{sphere: sphere, inverse: inverse, normal: inverse.Transpose()}, true
+}
+
+// intersect returns the nearest hit of the ray with the sphere further than tMin.
+// The ray is moved into the space of the sphere, where it is centered at
+// the origin, so scaled and rotated spheres are handled as well.
+func (o object) intersect(ray Ray, tMin float64) (Hit, bool) {
+	origin := o.inverse.MulPoint(ray.Origin)
+	direction := o.inverse.MulDirection(ray.Direction)
+
+	a := direction.Dot(direction)
+	b := 2 * origin.Dot(direction)
+	c := origin.Dot(origin) - o.sphere.Radius*o.sphere.Radius
+	discriminant := b*b - 4*a*c
+	if a == 0 || discriminant < 0 {
+		return Hit{}, false
+	}
+
+	root := math.Sqrt(discriminant)
+	t := (-b - root) / (2 * a)
+	if t <= tMin {
+		t = (-b + root) / (2 * a)
+		if t <= tMin {
+			return Hit{}, false
+		}
+	}
+
+	local := origin.Add(direction.Scale(t))
+	return Hit{
+		T:        t,
+		Point:    ray.At(t),
+		Normal:   o.normal.MulDirection(local).Normalize(),
+		Material: o.sphere.Material,
+	}, true
+}
@@ -0,0 +1,323 @@
+// Package render draws scenes with a ray tracer.
+package render
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"runtime"
+	"sync"
+
+	"github.com/kacperkrolak/scene-description-language/scene"
+	"github.com/kacperkrolak/scene-description-language/shading"
+	"github.com/kacperkrolak/scene-description-language/transform"
+)
+
+// Integrator selects how the light reaching the camera is computed.
+type Integrator string
+
+const (
+	Whitted     Integrator = "whitted" // Direct light, mirror reflections and refractions.
+	PathTracing Integrator = "path"    // Light bounced any number of times between surfaces, by random sampling.
+)
+
+// ParseIntegrator returns the integrator with the given name.
+func ParseIntegrator(s string) (Integrator, error) {
+	switch Integrator(s) {
+	case Whitted, PathTracing:
+		return Integrator(s), nil
+	}
+	return "", fmt.Errorf("unknown integrator %q, expected whitted or path", s)
+}
+
+// Options control the size of the rendered image and how it is shaded.
+type Options struct {
+	Width      int
+	Height     int
+	Shading    shading.Model // Empty uses Phong.
+	MaxDepth   int           // Number of reflections and refractions, or bounces of paths, followed from each camera ray.
+	Integrator Integrator    // Empty uses Whitted.
+	Samples    int           // Samples per pixel, zero for 1 with Whitted and 16 with path tracing.
+
+	Sampler      Sampler // Empty uses Stratified.
+	Filter       Filter  // Empty uses Box.
+	FilterRadius float64 // Radius of the filter in pixels, zero for its default.
+}
+
+// DefaultOptions are used by the command-line tool when no options are given.
+var DefaultOptions = Options{
+	Width:      640,
+	Height:     480,
+	Shading:    shading.Phong,
+	MaxDepth:   5,
+	Integrator: Whitted,
+	Sampler:    Stratified,
+	Filter:     Box,
+}
+
+// WithSettings returns the options with the settings of a scene in place of
+// how the image is shaded, keeping its size.
+func (o Options) WithSettings(settings scene.Settings) Options {
+	o.Integrator = Integrator(settings.Integrator)
+	o.Samples = settings.Samples
+	o.MaxDepth = settings.MaxDepth
+	o.Shading = shading.Model(settings.Shading)
+	o.Sampler = Sampler(settings.Sampler)
+	o.Filter = Filter(settings.Filter)
+	o.FilterRadius = settings.FilterRadius
+	return o
+}
+
+// defaultPathSamples is the number of samples per pixel of the path tracer
+// when none is given.
+const defaultPathSamples = 16
+
+// Background is the color of rays which do not hit anything.
+var Background = scene.Color{}
+
+type renderer struct {
+	scene      *scene.Scene
+	shading    shading.Model
+	maxDepth   int
+	integrator Integrator
+	objects    []object
+	extent     *rowExtent // Bounds of the rays of the row being traced.
+}
+
+// Render computes the color of every pixel. A single sample goes through
+// the center of the pixel, and several are spread over it by the sampler.
+// The color of a pixel is the average of the samples around it weighted by
+// the filter, which with the default box filter are its own samples.
+// A camera with an aperture starts every sample at a random point of its
+// lens, which blurs objects away from its focal distance, and takes as many
+// samples as path tracing by default. Rows are rendered in parallel, each
+// with random numbers of its own, so the same options always give the same
+// image.
+func Render(s *scene.Scene, options Options) (*Image, error) {
+	frame, err := RenderFrame(s, options)
+	if err != nil {
+		return nil, err
+	}
+	return frame.Image, nil
+}
+
+// render renders the scene, tracing again only the rows of the previous
+// frame which the changes to the scene can affect, or every row without one.
+func render(s *scene.Scene, options Options, previous *Frame) (*Frame, error) {
+	if options.Width <= 0 || options.Height <= 0 {
+		return nil, fmt.Errorf("image size must be positive, got %dx%d", options.Width, options.Height)
+	}
+	if options.MaxDepth < 0 {
+		return nil, fmt.Errorf("max depth must not be negative, got %d", options.MaxDepth)
+	}
+	if options.Samples < 0 {
+		return nil, fmt.Errorf("samples must not be negative, got %d", options.Samples)
+	}
+	if options.Integrator == "" {
+		options.Integrator = Whitted
+	}
+	if _, err := ParseIntegrator(string(options.Integrator)); err != nil {
+		return nil, err
+	}
+	if options.Sampler == "" {
+		options.Sampler = Stratified
+	}
+	if _, err := ParseSampler(string(options.Sampler)); err != nil {
+		return nil, err
+	}
+	if options.Filter == "" {
+		options.Filter = Box
+	}
+	if _, err := ParseFilter(string(options.Filter)); err != nil {
+		return nil, err
+	}
+	if options.FilterRadius < 0 {
+		return nil, fmt.Errorf("filter radius must not be negative, got %g", options.FilterRadius)
+	}
+	if options.FilterRadius == 0 {
+		options.FilterRadius = options.Filter.DefaultRadius()
+	}
+	if s.Camera.Aperture < 0 {
+		return nil, fmt.Errorf("camera aperture must not be negative, got %g", s.Camera.Aperture)
+	}
+	lens := s.Camera.Aperture > 0 && s.Camera.FocalDistance > 0
+	samples := options.Samples
+	if samples == 0 {
+		samples = 1
+		if options.Integrator == PathTracing || lens {
+			samples = defaultPathSamples
+		}
+	}
+
+	r := renderer{scene: s, shading: options.Shading, maxDepth: options.MaxDepth, integrator: options.Integrator}
+	if r.shading == "" {
+		r.shading = shading.Phong
+	}
+	for _, sphere := range s.Spheres {
+		o, ok := newObject(sphere)
+		if !ok {
+			return nil, fmt.Errorf("sphere %s has a transform which cannot be inverted", sphere.Name)
+		}
+		r.objects = append(r.objects, o)
+	}
+
+	f := newFilm(options.Width, options.Height, options.Filter, options.FilterRadius)
+	extents := make([]rowExtent, options.Height)
+	trace := previous.rowsToTrace(s, options, lens)
+	traced := 0
+	for y, again := range trace {
+		if again {
+			traced++
+		} else {
+			f.rows[y], extents[y] = previous.film.rows[y], previous.extents[y]
+		}
+	}
+
+	rows := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < runtime.NumCPU(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			points := make([][2]float64, samples)
+			for y := range rows {
+				random := rand.New(rand.NewSource(int64(y)))
+				row := r
+				row.extent = &extents[y]
+				for x := 0; x < options.Width; x++ {
+					options.Sampler.offsets(points, random)
+					for _, p := range points {
+						var u, v float64
+						if lens {
+							u, v = concentricDisk(random.Float64(), random.Float64())
+						}
+						px, py := float64(x)+p[0], float64(y)+p[1]
+						f.add(y, px, py, row.radiance(cameraRay(s.Camera, options, px, py, u, v), random))
+					}
+				}
+			}
+		}()
+	}
+
+	for y, again := range trace {
+		if again {
+			rows <- y
+		}
+	}
+	close(rows)
+	wg.Wait()
+
+	return &Frame{Image: f.image(), Traced: traced, scene: s, options: options, film: f, extents: extents}, nil
+}
+
+// cameraRay returns the ray going through the point (x, y) of the image,
+// measured in pixels from its top-left corner, and leaving the lens at the
+// point (u, v) of the unit disk.
+//
+// A thin lens bends every ray going through a point of the image to the
+// same point of the plane in focus, where the ray through the center of the
+// lens meets it. A camera without an aperture or a focal distance is a
+// pinhole, with all rays leaving the center.
+func cameraRay(camera scene.Camera, options Options, x, y, u, v float64) Ray {
+	height := math.Tan(transform.Radians(camera.FieldOfView) / 2)
+	width := height * float64(options.Width) / float64(options.Height)
+
+	direction := transform.Vec3{
+		X: (2*x/float64(options.Width) - 1) * width,
+		Y: (1 - 2*y/float64(options.Height)) * height,
+		Z: 1,
+	}
+	var origin transform.Vec3
+	if camera.Aperture > 0 && camera.FocalDistance > 0 {
+		origin = transform.Vec3{X: u * camera.Aperture / 2, Y: v * camera.Aperture / 2}
+		direction = direction.Scale(camera.FocalDistance).Sub(origin)
+	}
+
+	return Ray{
+		Origin:    camera.Transform.MulPoint(origin),
+		Direction: camera.Transform.MulDirection(direction).Normalize(),
+	}
+}
+
+// radiance returns the light coming back along a camera ray with the integrator of the renderer.
+func (r *renderer) radiance(ray Ray, random *rand.Rand) scene.Color {
+	if r.integrator == PathTracing {
+		return r.path(ray, random)
+	}
+	return r.trace(ray, 0, 0, random)
+}
+
+// closestHit returns the nearest surface hit by the ray.
+func (r *renderer) closestHit(ray Ray, tMin float64) (Hit, bool) {
+	var closest Hit
+	found := false
+	for _, o := range r.objects {
+		hit, ok := o.intersect(ray, tMin)
+		if ok && (!found || hit.T < closest.T) {
+			closest = hit
+			found = true
+		}
+	}
+	return closest, found
+}
+
+// trace returns the light coming back along a ray with a unit direction,
+// at the given depth of reflections and refractions.
+//
+// The color of a surface is the light it gives off, plus its shaded color
+// weakened by the share of the light it reflects and lets through, plus the
+// light coming from the mirror direction and through the surface. Transparent surfaces reflect more light
+// at grazing angles, following the Fresnel equations in the approximation of
+// Schlick, and all of it beyond the critical angle. Shadows of transparent
+// surfaces are as dark as those of opaque ones.
+func (r *renderer) trace(ray Ray, tMin float64, depth int, random *rand.Rand) scene.Color {
+	hit, ok := r.closestHit(ray, tMin)
+	if !ok {
+		return Background
+	}
+
+	r.extent.add(hit.Point)
+
+	material := hit.Material
+	surface := shading.Surface{
+		Point:    hit.Point,
+		Normal:   hit.Normal,
+		ToViewer: ray.Direction.Scale(-1),
+		Material: material,
+	}
+	color := shading.Ambient(material, r.scene.Camera.AmbientIntensity).Add(r.direct(surface, random))
+
+	if material.Reflectivity == 0 && material.Transparency == 0 {
+		return material.Emission.Add(color)
+	}
+	color = material.Emission.Add(color.Scale(math.Max(0, 1-material.Reflectivity-material.Transparency)))
+	if depth >= r.maxDepth {
+		return color
+	}
+	r.extent.scattered = true
+
+	// Rays leaving the sphere see the surface from the inside,
+	// going from the material to the air.
+	normal, n1, n2 := hit.Normal, 1.0, material.IOR
+	if ray.Direction.Dot(normal) > 0 {
+		normal, n1, n2 = normal.Scale(-1), n2, n1
+	}
+
+	reflectance := material.Reflectivity
+	if material.Transparency > 0 {
+		// Beyond the critical angle, all of the light is reflected.
+		fresnel := 1.0
+		if refracted, ok := refract(ray.Direction, normal, n1/n2); ok {
+			fresnel = schlick(ray.Direction, normal, refracted, n1, n2)
+			through := r.trace(Ray{Origin: hit.Point, Direction: refracted}, epsilon, depth+1, random)
+			color = color.Add(through.Scale(material.Transparency * (1 - fresnel)))
+		}
+		reflectance += material.Transparency * fresnel
+	}
+
+	if reflectance > 0 {
+		mirrored := r.trace(Ray{Origin: hit.Point, Direction: reflect(ray.Direction, normal)}, epsilon, depth+1, random)
+		color = color.Add(mirrored.Scale(reflectance))
+	}
+	return color
+}
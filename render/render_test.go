@@ -0,0 +1,207 @@
+package render
+
+import (
+	"bytes"
+	"image/png"
+	"math"
+	"testing"
+
+	"github.com/kacperkrolak/scene-description-language/scene"
+	"github.com/kacperkrolak/scene-description-language/shading"
+	"github.com/kacperkrolak/scene-description-language/transform"
+)
+
+func testScene() *scene.Scene {
+	camera := scene.DefaultCamera
+	camera.Transform = transform.Translation(transform.Vec3{Z: -5})
+	camera.AmbientIntensity = 0.5
+
+	return &scene.Scene{
+		Camera: camera,
+		Spheres: []scene.Sphere{{
+			Name:      "ball",
+			Transform: transform.Identity(),
+			Radius:    1,
+			Material:  scene.Material{Color: scene.Color{R: 1, G: 0.5, B: 0}, AmbientIntensity: 0.2, DiffuseIntensity: 0.8},
+		}},
+		Lights: []scene.Light{{
+			Name:             "lamp",
+			Position:         transform.Vec3{Z: -10},
+			Color:            scene.White,
+			DiffuseIntensity: 1,
+		}},
+	}
+}
+
+func testColor(t *testing.T, name string, got, expected scene.Color) {
+	t.Helper()
+	const tolerance = 1e-3
+	if math.Abs(got.R-expected.R) > tolerance || math.Abs(got.G-expected.G) > tolerance || math.Abs(got.B-expected.B) > tolerance {
+		t.Errorf("%s has wrong color. got=%v, want=%v", name, got, expected)
+	}
+}
+
+func TestRender(t *testing.T) {
+	img, err := Render(testScene(), Options{Width: 21, Height: 21})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// The center of the sphere faces both the camera and the light:
+	// ambient 0.2 * 0.5 plus diffuse 0.8 * 1 * cos(0).
+	testColor(t, "center", img.At(10, 10), scene.Color{R: 0.9, G: 0.45})
+	testColor(t, "corner", img.At(0, 0), Background)
+}
+
+func TestRenderShadows(t *testing.T) {
+	s := testScene()
+	// The blocker is behind the camera, between the ball and the light.
+	s.Spheres = append(s.Spheres, scene.Sphere{
+		Name:      "blocker",
+		Transform: transform.Translation(transform.Vec3{Z: -7.5}),
+		Radius:    0.1,
+		Material:  scene.DefaultMaterial,
+	})
+
+	img, err := Render(s, Options{Width: 21, Height: 21})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	testColor(t, "shadowed center", img.At(10, 10), scene.Color{R: 0.1, G: 0.05})
+}
+
+func TestRenderHighlights(t *testing.T) {
+	s := testScene()
+	s.Spheres[0].Material.SpecularIntensity = 0.5
+	s.Spheres[0].Material.Shininess = 8
+	s.Lights[0].SpecularIntensity = 1
+
+	for _, model := range []shading.Model{shading.Phong, shading.BlinnPhong} {
+		img, err := Render(s, Options{Width: 21, Height: 21, Shading: model})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		// The light is behind the camera, so the highlight is in the center,
+		// where it adds the full specular intensity in white.
+		testColor(t, string(model)+" center", img.At(10, 10), scene.Color{R: 1.4, G: 0.95, B: 0.5})
+	}
+}
+
+func TestIntersectTransformedSphere(t *testing.T) {
+	sphere := scene.Sphere{
+		Transform: transform.Compose(transform.Vec3{X: 3}, transform.Identity(), transform.Vec3{X: 2, Y: 1, Z: 1}),
+		Radius:    1,
+	}
+	o, ok := newObject(sphere)
+	if !ok {
+		t.Fatalf("sphere should be invertible")
+	}
+
+	hit, ok := o.intersect(Ray{Origin: transform.Vec3{}, Direction: transform.Vec3{X: 1}}, 0)
+	if !ok {
+		t.Fatalf("ray should hit the sphere")
+	}
+	if math.Abs(hit.T-1) > 1e-9 {
+		t.Errorf("wrong distance. got=%v, want=1", hit.T)
+	}
+	if math.Abs(hit.Normal.X+1) > 1e-9 {
+		t.Errorf("wrong normal. got=%v", hit.Normal)
+	}
+
+	if _, ok := o.intersect(Ray{Origin: transform.Vec3{}, Direction: transform.Vec3{X: -1}}, 0); ok {
+		t.Errorf("ray pointing away should miss")
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	if _, err := Render(testScene(), Options{}); err == nil {
+		t.Errorf("expected an error for an empty image")
+	}
+	if _, err := Render(testScene(), Options{Width: 1, Height: 1, MaxDepth: -1}); err == nil || err.Error() != "max depth must not be negative, got -1" {
+		t.Errorf("wrong error for a negative depth. got=%v", err)
+	}
+	if _, err := Render(testScene(), Options{Width: 1, Height: 1, Samples: -1}); err == nil || err.Error() != "samples must not be negative, got -1" {
+		t.Errorf("wrong error for negative samples. got=%v", err)
+	}
+	if _, err := Render(testScene(), Options{Width: 1, Height: 1, Integrator: "radiosity"}); err == nil || err.Error() != `unknown integrator "radiosity", expected whitted or path` {
+		t.Errorf("wrong error for an unknown integrator. got=%v", err)
+	}
+
+	s := testScene()
+	s.Spheres[0].Transform = transform.Scaling(transform.Vec3{})
+	if _, err := Render(s, DefaultOptions); err == nil {
+		t.Errorf("expected an error for a flattened sphere")
+	}
+}
+
+func TestWritePNG(t *testing.T) {
+	img := NewImage(2, 1)
+	img.Set(0, 0, scene.Color{R: 2, G: 0.5, B: -1})
+
+	var buf bytes.Buffer
+	if err := img.WritePNG(&buf, Display{}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	decoded, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("cannot decode: %s", err)
+	}
+	// Half of the light is encoded as 188 in sRGB.
+	r, g, b, _ := decoded.At(0, 0).RGBA()
+	if r>>8 != 255 || g>>8 != 188 || b>>8 != 0 {
+		t.Errorf("wrong pixel. got=(%d, %d, %d)", r>>8, g>>8, b>>8)
+	}
+}
+
+func TestCameraRayThinLens(t *testing.T) {
+	camera := scene.DefaultCamera
+	camera.Transform = transform.Translation(transform.Vec3{Z: -5})
+	camera.FocalDistance = 10
+	camera.Aperture = 2
+	options := Options{Width: 10, Height: 10}
+
+	center := cameraRay(camera, options, 3, 7, 0, 0)
+	focus := center.At(10 / center.Direction.Z)
+	for _, lens := range [][2]float64{{1, 0}, {0, -1}, {0.6, 0.8}} {
+		ray := cameraRay(camera, options, 3, 7, lens[0], lens[1])
+		testVector(t, "origin on the lens", ray.Origin, transform.Vec3{X: lens[0], Y: lens[1], Z: -5})
+		// Every ray through the same pixel meets the others on the plane in focus.
+		testVector(t, "point in focus", ray.At((focus.Z-ray.Origin.Z)/ray.Direction.Z), focus)
+	}
+
+	camera.Aperture = 0
+	if ray := cameraRay(camera, options, 3, 7, 1, 0); ray.Origin != center.Origin {
+		t.Errorf("pinhole camera should not move rays to the lens. got=%v", ray.Origin)
+	}
+}
+
+func TestRenderDepthOfField(t *testing.T) {
+	s := testScene()
+	options := Options{Width: 21, Height: 21, Samples: 256}
+
+	// Just outside of the ball, which fills the middle third of the image.
+	const x, y = 10, 5
+	sharp, err := Render(s, options)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	testColor(t, "pixel in focus", sharp.At(x, y), Background)
+
+	s.Camera.FocalDistance = 2
+	s.Camera.Aperture = 1
+	blurred, err := Render(s, options)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if c := blurred.At(x, y); c.R <= 0 {
+		t.Errorf("ball out of focus should blur over the pixel. got=%v", c)
+	}
+
+	s.Camera.Aperture = -1
+	if _, err := Render(s, options); err == nil || err.Error() != "camera aperture must not be negative, got -1" {
+		t.Errorf("wrong error for a negative aperture. got=%v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package render
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/kacperkrolak/scene-description-language/transform"
+)
+
+// basis returns two unit vectors perpendicular to the unit vector n and to each other.
+func basis(n transform.Vec3) (u, v transform.Vec3) {
+	// The axis least aligned with n is never parallel to it.
+	axis := transform.Vec3{X: 1}
+	if math.Abs(n.X) > 0.5 {
+		axis = transform.Vec3{Y: 1}
+	}
+	u = n.Cross(axis).Normalize()
+	return u, n.Cross(u)
+}
+
+// cosineHemisphere maps two numbers in [0, 1) to a unit direction on the side
+// of the unit normal, with a density proportional to the cosine of its angle
+// with the normal. Directions are spread the way a matte surface scatters light.
+func cosineHemisphere(normal transform.Vec3, u1, u2 float64) transform.Vec3 {
+	// Points spread evenly on the unit disk, lifted onto the hemisphere.
+	r := math.Sqrt(u1)
+	phi := 2 * math.Pi * u2
+	x, y := r*math.Cos(phi), r*math.Sin(phi)
+	z := math.Sqrt(math.Max(0, 1-u1))
+
+	u, v := basis(normal)
+	return u.Scale(x).Add(v.Scale(y)).Add(normal.Scale(z))
+}
+
+// concentricDisk maps two numbers in [0, 1) to a point spread evenly on the
+// unit disk. Squares around the center of [0, 1)² become rings, which keeps
+// samples which are spread well over the square spread well over the disk.
+func concentricDisk(u1, u2 float64) (x, y float64) {
+	a, b := 2*u1-1, 2*u2-1
+	if a == 0 && b == 0 {
+		return 0, 0
+	}
+
+	var r, phi float64
+	if math.Abs(a) > math.Abs(b) {
+		r, phi = a, math.Pi/4*(b/a)
+	} else {
+		r, phi = b, math.Pi/2-math.Pi/4*(a/b)
+	}
+	return r * math.Cos(phi), r * math.Sin(phi)
+}
+
+// Sampler selects how the samples of a pixel are spread over it.
+type Sampler string
+
+const (
+	Stratified Sampler = "stratified" // One sample at a random point of every cell of a grid over the pixel.
+	Jittered   Sampler = "jittered"   // Every sample at a random point of the pixel.
+)
+
+// ParseSampler returns the sampler with the given name.
+func ParseSampler(s string) (Sampler, error) {
+	switch Sampler(s) {
+	case Stratified, Jittered:
+		return Sampler(s), nil
+	}
+	return "", fmt.Errorf("unknown sampler %q, expected stratified or jittered", s)
+}
+
+// offsets fills points with the positions of the samples of a pixel,
+// measured from its top-left corner. A single sample is at the center.
+// The stratified sampler uses the largest square grid with no more cells
+// than samples, and spreads the samples left over at random.
+func (s Sampler) offsets(points [][2]float64, random *rand.Rand) {
+	if len(points) == 1 {
+		points[0] = [2]float64{0.5, 0.5}
+		return
+	}
+
+	cells := 0
+	if s == Stratified {
+		cells = int(math.Sqrt(float64(len(points))))
+	}
+	for i := range points {
+		if i < cells*cells {
+			x, y := i%cells, i/cells
+			points[i] = [2]float64{(float64(x) + random.Float64()) / float64(cells), (float64(y) + random.Float64()) / float64(cells)}
+		} else {
+			points[i] = [2]float64{random.Float64(), random.Float64()}
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package render
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/kacperkrolak/scene-description-language/transform"
+)
+
+func TestBasis(t *testing.T) {
+	for _, n := range []transform.Vec3{{X: 1}, {Y: 1}, {Z: -1}, transform.Vec3{X: 1, Y: 2, Z: 3}.Normalize()} {
+		u, v := basis(n)
+		if math.Abs(u.Length()-1) > 1e-9 || math.Abs(v.Length()-1) > 1e-9 {
+			t.Errorf("basis of %v is not made of unit vectors. got=%v, %v", n, u, v)
+		}
+		if math.Abs(u.Dot(n)) > 1e-9 || math.Abs(v.Dot(n)) > 1e-9 || math.Abs(u.Dot(v)) > 1e-9 {
+			t.Errorf("basis of %v is not perpendicular. got=%v, %v", n, u, v)
+		}
+	}
+}
+
+func TestCosineHemisphere(t *testing.T) {
+	normal := transform.Vec3{X: 1, Y: 1}.Normalize()
+	random := rand.New(rand.NewSource(1))
+
+	const n = 100000
+	var sum float64
+	for i := 0; i < n; i++ {
+		d := cosineHemisphere(normal, random.Float64(), random.Float64())
+		if math.Abs(d.Length()-1) > 1e-9 {
+			t.Fatalf("direction is not a unit vector. got=%v", d)
+		}
+		cos := d.Dot(normal)
+		if cos < 0 {
+			t.Fatalf("direction is below the surface. got=%v", d)
+		}
+		sum += cos
+	}
+
+	// The mean cosine of directions with a density of cos/pi is 2/3.
+	if mean := sum / n; math.Abs(mean-2.0/3) > 0.01 {
+		t.Errorf("directions are not cosine-weighted. mean cosine=%f", mean)
+	}
+}
+
+func TestConcentricDisk(t *testing.T) {
+	if x, y := concentricDisk(0.5, 0.5); x != 0 || y != 0 {
+		t.Errorf("center of the square should map to the center of the disk. got=%f, %f", x, y)
+	}
+	if x, y := concentricDisk(1, 0.5); math.Abs(x-1) > 1e-9 || math.Abs(y) > 1e-9 {
+		t.Errorf("edge of the square should map to the edge of the disk. got=%f, %f", x, y)
+	}
+
+	random := rand.New(rand.NewSource(1))
+	const n = 100000
+	var sum float64
+	for i := 0; i < n; i++ {
+		x, y := concentricDisk(random.Float64(), random.Float64())
+		r2 := x*x + y*y
+		if r2 > 1+1e-9 {
+			t.Fatalf("point is outside the disk. got=%f, %f", x, y)
+		}
+		sum += r2
+	}
+
+	// Points spread evenly on the unit disk have a mean squared radius of 1/2.
+	if mean := sum / n; math.Abs(mean-0.5) > 0.01 {
+		t.Errorf("points are not spread evenly. mean squared radius=%f", mean)
+	}
+}
+
+func TestSamplerOffsets(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+
+	single := make([][2]float64, 1)
+	Jittered.offsets(single, random)
+	if single[0] != [2]float64{0.5, 0.5} {
+		t.Errorf("a single sample should be at the center. got=%v", single[0])
+	}
+
+	// 10 samples fill a 3x3 grid, one in each cell, and one more anywhere.
+	points := make([][2]float64, 10)
+	Stratified.offsets(points, random)
+	cells := make(map[[2]int]bool)
+	for _, p := range points[:9] {
+		cells[[2]int{int(p[0] * 3), int(p[1] * 3)}] = true
+	}
+	if len(cells) != 9 {
+		t.Errorf("samples are not stratified. got=%v", points)
+	}
+	for _, p := range points {
+		if p[0] < 0 || p[0] >= 1 || p[1] < 0 || p[1] >= 1 {
+			t.Errorf("sample is outside of the pixel. got=%v", p)
+		}
+	}
+
+	if _, err := ParseSampler("halton"); err == nil || err.Error() != `unknown sampler "halton", expected stratified or jittered` {
+		t.Errorf("wrong error for an unknown sampler. got=%v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package render
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/kacperkrolak/scene-description-language/scene"
+)
+
+// ToneMap selects how colors brighter than white are brought into the
+// range of a screen.
+type ToneMap string
+
+const (
+	Clamp    ToneMap = "none"     // Components above 1 are cut off.
+	Reinhard ToneMap = "reinhard" // The luminance L becomes L / (1 + L), keeping the hue.
+	ACES     ToneMap = "aces"     // The filmic curve of ACES, in the fit of Krzysztof Narkowicz.
+)
+
+// ParseToneMap returns the tone mapping operator with the given name.
+func ParseToneMap(s string) (ToneMap, error) {
+	switch ToneMap(s) {
+	case Clamp, Reinhard, ACES:
+		return ToneMap(s), nil
+	}
+	return "", fmt.Errorf("unknown tone mapping %q, expected none, reinhard or aces", s)
+}
+
+// Display controls how the linear colors of an image become the 8-bit
+// colors of a screen: they are brightened by the exposure, tone mapped,
+// and encoded with the sRGB transfer curve.
+type Display struct {
+	Exposure float64 // Stops by which colors are brightened, negative to darken them.
+	ToneMap  ToneMap // Empty uses Clamp.
+}
+
+// WithSettings returns the display with the settings of a scene in its place.
+func (d Display) WithSettings(settings scene.Settings) Display {
+	return Display{Exposure: settings.Exposure, ToneMap: ToneMap(settings.ToneMap)}
+}
+
+// Map returns the linear color shown for c, with components between 0 and 1.
+func (d Display) Map(c scene.Color) scene.Color {
+	c = c.Scale(math.Exp2(d.Exposure))
+	switch d.ToneMap {
+	case Reinhard:
+		luminance := 0.2126*c.R + 0.7152*c.G + 0.0722*c.B
+		if luminance > 0 {
+			c = c.Scale(1 / (1 + luminance))
+		}
+	case ACES:
+		curve := func(x float64) float64 { return x * (2.51*x + 0.03) / (x*(2.43*x+0.59) + 0.14) }
+		c = scene.Color{R: curve(math.Max(0, c.R)), G: curve(math.Max(0, c.G)), B: curve(math.Max(0, c.B))}
+	}
+	return scene.Color{R: unit(c.R), G: unit(c.G), B: unit(c.B)}
+}
+
+// unit clamps x to [0, 1].
+func unit(x float64) float64 {
+	return math.Max(0, math.Min(1, x))
+}
+
+// sRGB encodes a linear component between 0 and 1 with the sRGB transfer
+// curve, which gives more of the 8-bit values to dark shades.
+func sRGB(x float64) float64 {
+	if x <= 0.0031308 {
+		return 12.92 * x
+	}
+	return 1.055*math.Pow(x, 1/2.4) - 0.055
+}
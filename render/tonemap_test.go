@@ -0,0 +1,52 @@
+package render
+
+import (
+	"math"
+	"testing"
+
+	"github.com/kacperkrolak/scene-description-language/scene"
+)
+
+func TestDisplayMap(t *testing.T) {
+	tests := []struct {
+		display  Display
+		color    scene.Color
+		expected scene.Color
+	}{
+		{Display{}, scene.Color{R: 2, G: 0.5, B: -1}, scene.Color{R: 1, G: 0.5}},
+		{Display{Exposure: 1}, scene.Color{R: 0.25, G: 1}, scene.Color{R: 0.5, G: 1}},
+		{Display{Exposure: -2}, scene.Color{R: 2}, scene.Color{R: 0.5}},
+		{Display{ToneMap: Reinhard}, scene.White, scene.Color{R: 0.5, G: 0.5, B: 0.5}},
+		{Display{ToneMap: Reinhard}, scene.Color{R: 3, G: 3, B: 3}, scene.Color{R: 0.75, G: 0.75, B: 0.75}},
+		{Display{ToneMap: ACES}, scene.Color{}, scene.Color{}},
+		{Display{ToneMap: ACES}, scene.Color{R: 1000}, scene.Color{R: 1}},
+	}
+	for _, tt := range tests {
+		got := tt.display.Map(tt.color)
+		if math.Abs(got.R-tt.expected.R) > 1e-3 || math.Abs(got.G-tt.expected.G) > 1e-3 || math.Abs(got.B-tt.expected.B) > 1e-3 {
+			t.Errorf("%+v maps %v wrongly. got=%v, want=%v", tt.display, tt.color, got, tt.expected)
+		}
+	}
+
+	// The filmic curve keeps the order of brightness and leaves room for highlights.
+	previous := 0.0
+	for x := 0.1; x < 5; x *= 2 {
+		y := Display{ToneMap: ACES}.Map(scene.Color{R: x}).R
+		if y <= previous || y >= 1 {
+			t.Errorf("ACES curve is wrong at %g. got=%g after %g", x, y, previous)
+		}
+		previous = y
+	}
+
+	if _, err := ParseToneMap("filmic"); err == nil || err.Error() != `unknown tone mapping "filmic", expected none, reinhard or aces` {
+		t.Errorf("wrong error for an unknown tone mapping. got=%v", err)
+	}
+}
+
+func TestSRGB(t *testing.T) {
+	for _, tt := range []struct{ linear, encoded float64 }{{0, 0}, {0.001, 0.01292}, {0.5, 0.735357}, {1, 1}} {
+		if got := sRGB(tt.linear); math.Abs(got-tt.encoded) > 1e-6 {
+			t.Errorf("sRGB(%g) = %g, want %g", tt.linear, got, tt.encoded)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+// Package repl implements an interactive session evaluating SDL statements
+// one after another, with the objects of earlier lines kept in scope.
+package repl
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/kacperkrolak/scene-description-language/ast"
+	"github.com/kacperkrolak/scene-description-language/evaluator"
+	"github.com/kacperkrolak/scene-description-language/lexer"
+	"github.com/kacperkrolak/scene-description-language/parser"
+	"github.com/kacperkrolak/scene-description-language/token"
+)
+
+const (
+	Prompt             = ">> "
+	ContinuationPrompt = ".. "
+)
+
+const help = `Enter statements or expressions to evaluate them.
+Input continues on the next line until every bracket is closed.
+
+Commands:
+  :load FILE   evaluate a file, adding its objects to the session
+  :env         list the defined objects
+  :type EXPR   print the type of an expression without defining anything
+  :reset       forget every object
+  :help        print this message
+  :quit        end the session
+`
+
+// REPL holds the objects defined during a session.
+type REPL struct {
+	evaluator *evaluator.Evaluator
+	out       io.Writer
+}
+
+// New returns a session writing its results to out.
+func New(out io.Writer) *REPL {
+	return &REPL{evaluator: evaluator.NewEvaluator(), out: out}
+}
+
+// Start runs a new session reading input from in and writing to out.
+func Start(in io.Reader, out io.Writer) {
+	New(out).Run(in)
+}
+
+// Run reads input from in until it ends or :quit is entered,
+// writing prompts and results to the output of the session.
+func (r *REPL) Run(in io.Reader) {
+	out := r.out
+	scanner := bufio.NewScanner(in)
+
+	var input strings.Builder
+	for {
+		if input.Len() == 0 {
+			fmt.Fprint(out, Prompt)
+		} else {
+			fmt.Fprint(out, ContinuationPrompt)
+		}
+
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return
+		}
+
+		input.WriteString(scanner.Text())
+		input.WriteString("\n")
+		if depth(input.String()) > 0 {
+			continue
+		}
+
+		line := input.String()
+		input.Reset()
+		if !r.Execute(line) {
+			return
+		}
+	}
+}
+
+// Execute runs a command or evaluates the given source and prints the result.
+// It returns false when the session should end.
+func (r *REPL) Execute(input string) bool {
+	trimmed := strings.TrimSpace(input)
+	if trimmed == "" {
+		return true
+	}
+
+	if !strings.HasPrefix(trimmed, ":") {
+		r.evaluate(input)
+		return true
+	}
+
+	name, arg, _ := strings.Cut(trimmed, " ")
+	arg = strings.TrimSpace(arg)
+	switch name {
+	case ":load":
+		r.load(arg)
+	case ":env":
+		r.env()
+	case ":type":
+		r.typeOf(arg)
+	case ":reset":
+		r.evaluator = evaluator.NewEvaluator()
+	case ":help":
+		fmt.Fprint(r.out, help)
+	case ":quit", ":q":
+		return false
+	default:
+		fmt.Fprintf(r.out, "unknown command %s, enter :help for the list of commands\n", name)
+	}
+
+	return true
+}
+
+// evaluate evaluates the statements of the input. The objects defined or modified
+// are printed as statements, a trailing expression is printed as its value.
+func (r *REPL) evaluate(input string) {
+	file, ok := r.parse(input)
+	if !ok {
+		return
+	}
+
+	result := r.evaluator.Eval(file)
+	if result != nil && result.Type() == evaluator.ERROR_OBJ {
+		fmt.Fprintln(r.out, result.Inspect())
+		return
+	}
+
+	for i, statement := range file.Statements {
+		switch statement := statement.(type) {
+		case *ast.AssignStatement:
+			r.printEntity(statement.Name.Value)
+		case *ast.ModifyStatement:
+			r.printEntity(statement.Name.Value)
+		case *ast.ExpressionStatement:
+			if i == len(file.Statements)-1 && result != nil {
+				fmt.Fprintln(r.out, result.Inspect())
+			}
+		}
+	}
+}
+
+func (r *REPL) printEntity(name string) {
+	if entity, ok := r.evaluator.Lookup(name); ok {
+		fmt.Fprintln(r.out, entity.Inspect())
+	}
+}
+
+func (r *REPL) parse(input string) (*ast.File, bool) {
+	p := parser.New(lexer.New(input))
+	file := p.ParseFile()
+	for _, err := range p.SyntaxErrors() {
+		fmt.Fprintf(r.out, "syntax error: %s\n", err)
+	}
+
+	return file, len(p.SyntaxErrors()) == 0
+}
+
+func (r *REPL) load(path string) {
+	if path == "" {
+		fmt.Fprintln(r.out, "usage: :load FILE")
+		return
+	}
+
+	before := len(r.evaluator.Names())
+	if err := r.evaluator.EvaluatePath(path); err != nil {
+		fmt.Fprintln(r.out, err)
+		return
+	}
+
+	fmt.Fprintf(r.out, "loaded %d objects from %s\n", len(r.evaluator.Names())-before, path)
+}
+
+// env lists the objects in the order they were defined.
+func (r *REPL) env() {
+	for _, name := range r.evaluator.Names() {
+		entity, _ := r.evaluator.Lookup(name)
+		fmt.Fprintf(r.out, "%s %s\n", entity.Class, entity.Name)
+	}
+}
+
+// typeOf prints the class of an object, or the type of any other value.
+// The expression is evaluated, but nothing is defined.
+func (r *REPL) typeOf(input string) {
+	file, ok := r.parse(input)
+	if !ok {
+		return
+	}
+
+	if len(file.Statements) != 1 {
+		fmt.Fprintln(r.out, "usage: :type EXPR")
+		return
+	}
+	statement, ok := file.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		fmt.Fprintln(r.out, ":type expects an expression, not a statement")
+		return
+	}
+
+	if identifier, ok := statement.Expression.(*ast.Identifier); ok {
+		if entity, ok := r.evaluator.Lookup(identifier.Value); ok {
+			fmt.Fprintln(r.out, entity.Class)
+			return
+		}
+	}
+
+	result := r.evaluator.Eval(statement.Expression)
+	if result.Type() == evaluator.ERROR_OBJ {
+		fmt.Fprintln(r.out, result.Inspect())
+		return
+	}
+	fmt.Fprintln(r.out, result.Type())
+}
+
+// depth returns how many brackets are left open at the end of the input.
+func depth(input string) int {
+	open := 0
+	l := lexer.New(input)
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		switch tok.Type {
+		case token.LBRACE, token.LBRACKET, token.LPAREN:
+			open++
+		case token.RBRACE, token.RBRACKET, token.RPAREN:
+			open--
+		}
+	}
+	return open
+}
@@ -0,0 +1,116 @@
+package repl
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func runSession(input string) string {
+	var out strings.Builder
+	Start(strings.NewReader(input), &out)
+	return out.String()
+}
+
+// results returns the output of a session without the prompts.
+func results(output string) string {
+	output = strings.ReplaceAll(output, ContinuationPrompt, "")
+	return strings.ReplaceAll(output, Prompt, "")
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"expression", "1 + 2 * 3\n", "7\n\n"},
+		{"definition", "NUMBER r = 2\n", "NUMBER r = 2\n\n"},
+		{"objects stay defined", "NUMBER r = 2\nr * 3\n", "NUMBER r = 2\n6\n\n"},
+		{
+			"multi-line block",
+			"SPHERE s = {\n    radius: 1,\n    position: [0, 0, 5],\n}\ns.position.z\n",
+			"SPHERE s = {\n    radius: 1,\n    position: [0, 0, 5],\n}\n5\n\n",
+		},
+		{"modify", "SPHERE s = {radius: 1}\nMODIFY s {radius: 2}\n", "SPHERE s = {\n    radius: 1,\n}\nSPHERE s = {\n    radius: 2,\n}\n\n"},
+		{"redefinition", "NUMBER r = 2\nNUMBER r = 3\n", "NUMBER r = 2\nerror: 1:1: redefining objects is not allowed: r\n\n"},
+		{"undefined", "r\n", "error: 1:1: undefined identifier: r\n\n"},
+		{"syntax error", "NUMBER = 2\n", "syntax error: 1:8: expected next token to be IDENT, got = instead\nsyntax error: 1:8: no prefix parse function for = found\n\n"},
+		{"blank lines", "\n\n", "\n"},
+		{"quit", "1\n:quit\n2\n", "1\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := results(runSession(tt.input)); got != tt.expected {
+				t.Errorf("wrong output.\ngot=%q\nwant=%q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPrompts(t *testing.T) {
+	got := runSession("[1,\n2]\n")
+	expected := ">> .. [1, 2]\n>> \n"
+	if got != expected {
+		t.Errorf("wrong prompts.\ngot=%q\nwant=%q", got, expected)
+	}
+}
+
+func TestCommands(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "scene.sdl")
+	if err := os.WriteFile(path, []byte("MATERIAL red = {color: [1, 0, 0]}\nSPHERE ball = {radius: 1, material: red}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	broken := filepath.Join(dir, "broken.sdl")
+	if err := os.WriteFile(broken, []byte("NUMBER a = b\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"load", ":load " + path + "\n:env\n", "loaded 2 objects from " + path + "\nMATERIAL red\nSPHERE ball\n"},
+		{"load error", ":load " + broken + "\n", broken + ":1:12: undefined identifier: b\n"},
+		{"load usage", ":load\n", "usage: :load FILE\n"},
+		{"type of object", "SPHERE s = {radius: 1}\n:type s\n", "SPHERE s = {\n    radius: 1,\n}\nSPHERE\n"},
+		{"type of value", "SPHERE s = {radius: 1}\n:type s.radius\n:type [1, 2]\n", "SPHERE s = {\n    radius: 1,\n}\nNUMBER\nARRAY\n"},
+		{"type defines nothing", ":type NUMBER a = 1\n:env\n", ":type expects an expression, not a statement\n"},
+		{"type error", ":type a\n", "error: 1:1: undefined identifier: a\n"},
+		{"reset", "NUMBER a = 1\n:reset\n:env\na\n", "NUMBER a = 1\nerror: 1:1: undefined identifier: a\n"},
+		{"unknown", ":what\n", "unknown command :what, enter :help for the list of commands\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := strings.TrimSuffix(results(runSession(tt.input)), "\n")
+			if got != tt.expected {
+				t.Errorf("wrong output.\ngot=%q\nwant=%q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDepth(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{"SPHERE s = {", 1},
+		{"SPHERE s = {radius: [1, (2", 3},
+		{"SPHERE s = {}", 0},
+		{`STRING s = "{"`, 0},
+		{"NUMBER a = 1 // {", 0},
+		{"}", -1},
+	}
+
+	for _, tt := range tests {
+		if got := depth(tt.input); got != tt.expected {
+			t.Errorf("wrong depth of %q. got=%d, want=%d", tt.input, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+// Package scene turns evaluated SDL values into a typed scene which can be rendered or exported.
+package scene
+
+import (
+	"math"
+
+	"github.com/kacperkrolak/scene-description-language/evaluator"
+	"github.com/kacperkrolak/scene-description-language/token"
+	"github.com/kacperkrolak/scene-description-language/transform"
+)
+
+// Color is a linear RGB color with components usually between 0 and 1.
+type Color struct {
+	R, G, B float64
+}
+
+// Scale multiplies every component by s.
+func (c Color) Scale(s float64) Color {
+	return Color{c.R * s, c.G * s, c.B * s}
+}
+
+// Mul multiplies the colors component-wise.
+func (c Color) Mul(d Color) Color {
+	return Color{c.R * d.R, c.G * d.G, c.B * d.B}
+}
+
+// Add adds the colors component-wise.
+func (c Color) Add(d Color) Color {
+	return Color{c.R + d.R, c.G + d.G, c.B + d.B}
+}
+
+var White = Color{1, 1, 1}
+
+// Camera describes the point of view. The camera looks along the Z axis
+// of its transform, with the Y axis pointing up.
+type Camera struct {
+	Transform        transform.Mat4
+	FieldOfView      float64 // Vertical field of view in degrees.
+	FocalDistance    float64 // Distance to the plane in focus, zero if not given.
+	Aperture         float64 // Diameter of the lens, zero for a pinhole camera with everything in focus.
+	AmbientIntensity float64 // Strength of the light reaching every surface.
+}
+
+// Material describes how a surface reflects light.
+type Material struct {
+	Name              string // Name of the MATERIAL, empty for materials given inline.
+	Color             Color
+	AmbientIntensity  float64
+	DiffuseIntensity  float64
+	SpecularIntensity float64
+	Shininess         float64 // Exponent of the highlights, larger for smaller and sharper ones.
+	Reflectivity      float64 // Share of the light reflected like in a mirror.
+	Transparency      float64 // Share of the light passing through the surface.
+	IOR               float64 // Index of refraction of the inside of the surface.
+	Emission          Color   // Light given off by the surface.
+}
+
+// DefaultMaterial is used by spheres without a material.
+var DefaultMaterial = Material{Color: White, AmbientIntensity: 0.1, DiffuseIntensity: 0.9, Shininess: 32, IOR: 1}
+
+type Sphere struct {
+	Name      string
+	Transform transform.Mat4 // World transform of the sphere.
+	Radius    float64        // Radius before the transform is applied.
+	Material  Material
+}
+
+// Center returns the center of the sphere in world space.
+func (s Sphere) Center() transform.Vec3 {
+	return s.Transform.Position()
+}
+
+// Light is a source of light. Point and spot lights shine from their
+// position, or from every point of their shape, and spot and directional
+// lights shine along the Z axis of their transform. Directional lights are
+// infinitely far away, so their position and shape do not matter.
+type Light struct {
+	Name              string
+	Kind              string         // "point", "directional" or "spot".
+	Transform         transform.Mat4 // World transform of the light.
+	Position          transform.Vec3 // Position in world space.
+	Direction         transform.Vec3 // Unit direction in world space in which the light shines.
+	Color             Color
+	DiffuseIntensity  float64
+	SpecularIntensity float64
+
+	Shape   string  // "point", "sphere", "rectangle" or "disk".
+	Radius  float64 // Radius of a sphere or disk before the transform is applied.
+	Width   float64 // Size of a rectangle along the X axis before the transform is applied.
+	Height  float64 // Size of a rectangle along the Y axis before the transform is applied.
+	Samples int     // Shadow rays towards a light with a shape from every shaded point.
+
+	ConeAngle float64 // Angle in degrees between the axis of a spot light and the edge of its cone.
+	Falloff   float64 // Angle in degrees inside the edge of the cone over which a spot light fades out.
+}
+
+// DefaultLight holds the values of properties which a LIGHT does not set.
+var DefaultLight = Light{
+	Kind:              "point",
+	Color:             White,
+	DiffuseIntensity:  1,
+	SpecularIntensity: 1,
+	Shape:             "point",
+	Radius:            0.5,
+	Width:             1,
+	Height:            1,
+	Samples:           16,
+	ConeAngle:         30,
+}
+
+// Settings are the options of the renderer given by the RENDER object.
+type Settings struct {
+	Integrator string // "whitted" or "path".
+	Samples    int    // Samples per pixel, zero for the default of the integrator.
+	MaxDepth   int
+	Shading    string // "phong" or "blinn-phong".
+
+	Sampler      string  // "stratified" or "jittered".
+	Filter       string  // "box", "tent", "gaussian" or "mitchell".
+	FilterRadius float64 // Radius of the filter in pixels, zero for the default of the filter.
+
+	Exposure float64 // Stops by which 8-bit images are brightened.
+	ToneMap  string  // "none", "reinhard" or "aces".
+}
+
+// DefaultSettings are used when the file has no RENDER object.
+var DefaultSettings = Settings{Integrator: "whitted", MaxDepth: 5, Shading: "phong", Sampler: "stratified", Filter: "box", ToneMap: "none"}
+
+// Scene holds the objects of an evaluated SDL file with defaults applied.
+type Scene struct {
+	Camera   Camera
+	Spheres  []Sphere
+	Lights   []Light
+	Settings Settings
+}
+
+// DefaultCamera is used when the file does not modify the camera.
+var DefaultCamera = Camera{Transform: transform.Identity(), FieldOfView: 60, AmbientIntensity: 1}
+
+// DefaultSensorHeight is the height in millimetres of the sensor of a camera
+// with a focal length, that of a 35 mm film frame.
+const DefaultSensorHeight = 24
+
+// New validates the evaluated values and builds the scene from them.
+// If the values do not match the schema, a ValidationError is returned.
+func New(values evaluator.EvaluatedValues) (*Scene, error) {
+	if problems := Validate(values); len(problems) > 0 {
+		return nil, problems
+	}
+
+	materials := make(map[*evaluator.Dictionary]string)
+	for _, entity := range values.Entities[token.MATERIAL] {
+		materials[entity.Value.(*evaluator.Dictionary)] = entity.Name
+	}
+
+	scene := &Scene{Camera: DefaultCamera, Settings: DefaultSettings}
+	for _, entity := range values.Entities[token.RENDER] {
+		properties := entity.Value.(*evaluator.Dictionary).Properties
+		scene.Settings = Settings{
+			Integrator: text(properties, "integrator", DefaultSettings.Integrator),
+			Samples:    int(number(properties, "samples", float64(DefaultSettings.Samples))),
+			MaxDepth:   int(number(properties, "maxDepth", float64(DefaultSettings.MaxDepth))),
+			Shading:    text(properties, "shading", DefaultSettings.Shading),
+
+			Sampler:      text(properties, "sampler", DefaultSettings.Sampler),
+			Filter:       text(properties, "filter", DefaultSettings.Filter),
+			FilterRadius: number(properties, "filterRadius", DefaultSettings.FilterRadius),
+
+			Exposure: number(properties, "exposure", DefaultSettings.Exposure),
+			ToneMap:  text(properties, "toneMap", DefaultSettings.ToneMap),
+		}
+	}
+
+	for _, node := range values.Nodes {
+		properties := node.Entity.Value.(*evaluator.Dictionary).Properties
+		switch node.Entity.Class {
+		case token.CAMERA:
+			scene.Camera = Camera{
+				Transform:        node.World,
+				FieldOfView:      number(properties, "fov", DefaultCamera.FieldOfView),
+				FocalDistance:    number(properties, "focalDistance", 0),
+				Aperture:         number(properties, "aperture", 0),
+				AmbientIntensity: number(properties, "ambientIntensity", DefaultCamera.AmbientIntensity),
+			}
+			// A lens sees the angle covered by the sensor at its focal length.
+			if focalLength := number(properties, "focalLength", 0); focalLength > 0 {
+				sensor := number(properties, "sensorHeight", DefaultSensorHeight)
+				scene.Camera.FieldOfView = transform.Degrees(2 * math.Atan(sensor/2/focalLength))
+			}
+		case token.SPHERE:
+			material := DefaultMaterial
+			if value, ok := properties["material"].(*evaluator.Dictionary); ok {
+				material = newMaterial(materials[value], value.Properties)
+			}
+
+			scene.Spheres = append(scene.Spheres, Sphere{
+				Name:      node.Entity.Name,
+				Transform: node.World,
+				Radius:    number(properties, "radius", 0),
+				Material:  material,
+			})
+		case token.LIGHT:
+			scene.Lights = append(scene.Lights, Light{
+				Name:              node.Entity.Name,
+				Kind:              text(properties, "kind", DefaultLight.Kind),
+				Transform:         node.World,
+				Position:          node.World.Position(),
+				Direction:         node.World.MulDirection(transform.Vec3{Z: 1}).Normalize(),
+				Color:             color(properties, "color", DefaultLight.Color),
+				DiffuseIntensity:  number(properties, "diffuseIntensity", DefaultLight.DiffuseIntensity),
+				SpecularIntensity: number(properties, "specularIntensity", DefaultLight.SpecularIntensity),
+
+				Shape:   text(properties, "shape", DefaultLight.Shape),
+				Radius:  number(properties, "radius", DefaultLight.Radius),
+				Width:   number(properties, "width", DefaultLight.Width),
+				Height:  number(properties, "height", DefaultLight.Height),
+				Samples: int(number(properties, "samples", float64(DefaultLight.Samples))),
+
+				ConeAngle: number(properties, "coneAngle", DefaultLight.ConeAngle),
+				Falloff:   number(properties, "falloff", DefaultLight.Falloff),
+			})
+		}
+	}
+
+	return scene, nil
+}
+
+func newMaterial(name string, properties map[string]evaluator.Object) Material {
+	return Material{
+		Name:              name,
+		Color:             color(properties, "color", DefaultMaterial.Color),
+		AmbientIntensity:  number(properties, "ambientIntensity", DefaultMaterial.AmbientIntensity),
+		DiffuseIntensity:  number(properties, "diffuseIntensity", DefaultMaterial.DiffuseIntensity),
+		SpecularIntensity: number(properties, "specularIntensity", DefaultMaterial.SpecularIntensity),
+		Shininess:         number(properties, "shininess", DefaultMaterial.Shininess),
+		Reflectivity:      number(properties, "reflectivity", DefaultMaterial.Reflectivity),
+		Transparency:      number(properties, "transparency", DefaultMaterial.Transparency),
+		IOR:               number(properties, "ior", DefaultMaterial.IOR),
+		Emission:          color(properties, "emission", DefaultMaterial.Emission),
+	}
+}
+
+// number returns the value of a validated NUMBER property, or def if it is not set.
+func number(properties map[string]evaluator.Object, name string, def float64) float64 {
+	if value, ok := properties[name].(*evaluator.Number); ok {
+		return value.Value
+	}
+	return def
+}
+
+// text returns the value of a validated STRING property, or def if it is not set.
+func text(properties map[string]evaluator.Object, name string, def string) string {
+	if value, ok := properties[name].(*evaluator.String); ok {
+		return value.Value
+	}
+	return def
+}
+
+// color returns the value of a validated COLOR property, or def if it is not set.
+func color(properties map[string]evaluator.Object, name string, def Color) Color {
+	array, ok := properties[name].(*evaluator.Array)
+	if !ok {
+		return def
+	}
+
+	components := make([]float64, 3)
+	for i, element := range array.Elements {
+		components[i] = element.(*evaluator.Number).Value
+	}
+	return Color{components[0], components[1], components[2]}
+}
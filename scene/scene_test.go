@@ -0,0 +1,212 @@
+package scene
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/kacperkrolak/scene-description-language/evaluator"
+	"github.com/kacperkrolak/scene-description-language/transform"
+)
+
+func evaluate(t *testing.T, input string) evaluator.EvaluatedValues {
+	t.Helper()
+	e := evaluator.NewEvaluator()
+	if err := e.EvaluateFile(strings.NewReader(input)); err != nil {
+		t.Fatalf("evaluation failed: %s", err)
+	}
+	return e.ExportValues()
+}
+
+func TestNew(t *testing.T) {
+	input := `
+MODIFY CAMERA {position: [0, 1, -10], fov: 45, ambientIntensity: 0.3}
+MATERIAL red = {color: [1, 0, 0], diffuseIntensity: 0.5}
+GROUP g = {children: [ball], position: [1, 0, 0]}
+SPHERE ball = {radius: 2, material: red, position: [0, 2, 0]}
+SPHERE plain = {radius: 1}
+LIGHT lamp AT [0, 5, 0] = {color: [1, 1, 0.5]}
+`
+	s, err := New(evaluate(t, input))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if s.Camera.FieldOfView != 45 || s.Camera.AmbientIntensity != 0.3 {
+		t.Errorf("wrong camera. got=%+v", s.Camera)
+	}
+	if position := s.Camera.Transform.Position(); position.Z != -10 {
+		t.Errorf("wrong camera position. got=%v", position)
+	}
+
+	if len(s.Spheres) != 2 {
+		t.Fatalf("wrong number of spheres. got=%d", len(s.Spheres))
+	}
+
+	ball := s.Spheres[0]
+	if ball.Name != "ball" || ball.Radius != 2 {
+		t.Errorf("wrong sphere. got=%+v", ball)
+	}
+	if center := ball.Center(); center.X != 1 || center.Y != 2 {
+		t.Errorf("sphere is not placed in its group. got=%v", center)
+	}
+	expectedMaterial := Material{Name: "red", Color: Color{1, 0, 0}, AmbientIntensity: 0.1, DiffuseIntensity: 0.5, Shininess: 32, IOR: 1}
+	if ball.Material != expectedMaterial {
+		t.Errorf("wrong material. got=%+v, want=%+v", ball.Material, expectedMaterial)
+	}
+
+	if s.Spheres[1].Material != DefaultMaterial {
+		t.Errorf("sphere without a material should use the default. got=%+v", s.Spheres[1].Material)
+	}
+
+	if len(s.Lights) != 1 {
+		t.Fatalf("wrong number of lights. got=%d", len(s.Lights))
+	}
+	light := s.Lights[0]
+	if light.Position.Y != 5 || light.Color != (Color{1, 1, 0.5}) || light.DiffuseIntensity != 1 {
+		t.Errorf("wrong light. got=%+v", light)
+	}
+}
+
+func TestNewWithoutCamera(t *testing.T) {
+	s, err := New(evaluate(t, `SPHERE s = {radius: 1}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if s.Camera != DefaultCamera {
+		t.Errorf("expected the default camera. got=%+v", s.Camera)
+	}
+	if s.Settings != DefaultSettings {
+		t.Errorf("expected the default settings. got=%+v", s.Settings)
+	}
+}
+
+func TestNewCameraLens(t *testing.T) {
+	s, err := New(evaluate(t, `MODIFY CAMERA {focalLength: 50, sensorHeight: 24, aperture: 0.1, focalDistance: 3}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// The sensor covers 2 * atan(12 / 50) at a focal length of 50 mm.
+	if fov := s.Camera.FieldOfView; math.Abs(fov-26.9915) > 1e-4 {
+		t.Errorf("wrong field of view. got=%f", fov)
+	}
+	if s.Camera.Aperture != 0.1 || s.Camera.FocalDistance != 3 {
+		t.Errorf("wrong lens. got=%+v", s.Camera)
+	}
+}
+
+func TestNewSettings(t *testing.T) {
+	s, err := New(evaluate(t, `RENDER settings = {integrator: "path", samples: 64}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := Settings{Integrator: "path", Samples: 64, MaxDepth: 5, Shading: "phong", Sampler: "stratified", Filter: "box", ToneMap: "none"}
+	if s.Settings != expected {
+		t.Errorf("wrong settings. got=%+v, want=%+v", s.Settings, expected)
+	}
+}
+
+func TestNewLights(t *testing.T) {
+	input := `LIGHT panel = {shape: "rectangle", width: 2, height: 3, samples: 4, position: [0, 5, 0], rotation: [90, 0, 0]}
+LIGHT torch = {kind: "spot", coneAngle: 20, falloff: 5}`
+	s, err := New(evaluate(t, input))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	panel, torch := s.Lights[0], s.Lights[1]
+	if panel.Kind != "point" || panel.Shape != "rectangle" || panel.Width != 2 || panel.Height != 3 || panel.Samples != 4 || panel.Radius != DefaultLight.Radius {
+		t.Errorf("wrong panel. got=%+v", panel)
+	}
+	// The light shines along its Z axis, turned to point down.
+	if down := (transform.Vec3{Y: -1}); panel.Direction.Sub(down).Length() > 1e-9 || panel.Position != (transform.Vec3{Y: 5}) {
+		t.Errorf("wrong placement of the panel. got position %v, direction %v", panel.Position, panel.Direction)
+	}
+	if torch.Kind != "spot" || torch.Shape != "point" || torch.ConeAngle != 20 || torch.Falloff != 5 || torch.Direction != (transform.Vec3{Z: 1}) {
+		t.Errorf("wrong spot light. got=%+v", torch)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		input    string
+		problems []string
+	}{
+		{`SPHERE s = {}`, []string{"SPHERE s: radius: missing required property"}},
+		{`SPHERE s = {radius: 1, size: 2}`, []string{"SPHERE s: size: unknown property of SPHERE"}},
+		{`SPHERE s = {radius: [1]}`, []string{"SPHERE s: radius: expected a NUMBER, got ARRAY"}},
+		{`SPHERE s = 1`, []string{"SPHERE s: expected properties, got NUMBER"}},
+		{`COLOR c = [1, 2]`, []string{"COLOR c: expected an array of 3 numbers, got an ARRAY of 2 elements"}},
+		{`NUMBER n = [1]`, []string{"NUMBER n: expected a NUMBER, got ARRAY"}},
+		{
+			`SPHERE s = {radius: 1, material: {color: 1, shine: 2}}`,
+			[]string{
+				"SPHERE s: material.color: expected an array of 3 numbers, got NUMBER",
+				"SPHERE s: material.shine: unknown property of MATERIAL",
+			},
+		},
+		{`RENDER r = {integrator: "radiosity"}`, []string{`RENDER r: integrator: expected "whitted" or "path", got "radiosity"`}},
+		{`LIGHT l = {shape: "cube"}`, []string{`LIGHT l: shape: expected "point", "sphere", "rectangle" or "disk", got "cube"`}},
+		{`RENDER r = {samples: 2.5}`, []string{"RENDER r: samples: expected a whole NUMBER, got 2.5"}},
+		{`RENDER r = {samples: 0}`, []string{"RENDER r: samples: must be at least 1, got 0"}},
+		{`RENDER r = {maxDepth: -1}`, []string{"RENDER r: maxDepth: must be at least 0, got -1"}},
+		{`RENDER r = {samples: "many"}`, []string{"RENDER r: samples: expected a whole NUMBER, got STRING"}},
+		{`LIGHT l = {shape: "disk", samples: -4}`, []string{"LIGHT l: samples: must be at least 1, got -4"}},
+		{`RENDER r = {shading: 1}`, []string{"RENDER r: shading: expected a STRING, got NUMBER"}},
+		{`RENDER a = {} RENDER b = {}`, []string{"RENDER b: only one RENDER object is allowed"}},
+		{`MODIFY CAMERA {fov: "wide"}`, []string{"CAMERA CAMERA: fov: expected a NUMBER, got STRING"}},
+		{
+			`MATERIAL m = {specular: 1} LIGHT l = {color: 1}`,
+			[]string{
+				"MATERIAL m: specular: unknown property of MATERIAL",
+				"LIGHT l: color: expected an array of 3 numbers, got NUMBER",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		problems := Validate(evaluate(t, tt.input))
+		var got []string
+		for _, problem := range problems {
+			got = append(got, problem.String())
+		}
+
+		if strings.Join(got, "\n") != strings.Join(tt.problems, "\n") {
+			t.Errorf("wrong problems for %q.\ngot=%q\nwant=%q", tt.input, got, tt.problems)
+		}
+	}
+}
+
+func TestNewReturnsValidationError(t *testing.T) {
+	_, err := New(evaluate(t, `SPHERE s = {}`))
+
+	var validationError ValidationError
+	if !errors.As(err, &validationError) {
+		t.Fatalf("expected a ValidationError. got=%T (%v)", err, err)
+	}
+	if len(validationError) != 1 || validationError[0].Entity != "s" || validationError[0].Property != "radius" {
+		t.Errorf("wrong problems. got=%+v", validationError)
+	}
+}
+
+func TestSchemaDescribesEveryClass(t *testing.T) {
+	for _, name := range []string{"NUMBER", "COLOR", "MATERIAL", "SPHERE", "LIGHT", "GROUP", "CAMERA", "RENDER"} {
+		class, ok := LookupClass(name)
+		if !ok {
+			t.Errorf("class %s is not described", name)
+			continue
+		}
+		if class.Description == "" {
+			t.Errorf("class %s has no description", name)
+		}
+	}
+
+	sphere, _ := LookupClass("SPHERE")
+	if property, ok := sphere.Property("position"); !ok || property.Type != VectorValue {
+		t.Errorf("SPHERE should accept a position. got=%+v", property)
+	}
+}
@@ -0,0 +1,363 @@
+package scene
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/kacperkrolak/scene-description-language/evaluator"
+	"github.com/kacperkrolak/scene-description-language/token"
+)
+
+// ValueType describes what kind of value a property or an object holds.
+type ValueType string
+
+const (
+	NumberValue     ValueType = "NUMBER"     // a number
+	IntegerValue    ValueType = "INTEGER"    // a whole number
+	StringValue     ValueType = "STRING"     // a string
+	ColorValue      ValueType = "COLOR"      // [r, g, b] with components usually between 0 and 1
+	VectorValue     ValueType = "VECTOR"     // [x, y, z]
+	RotationValue   ValueType = "ROTATION"   // Euler angles [x, y, z] in degrees or a quaternion [x, y, z, w]
+	ScaleValue      ValueType = "SCALE"      // a number or [x, y, z]
+	MatrixValue     ValueType = "MATRIX"     // 4 rows of 4 numbers or 16 numbers
+	ReferencesValue ValueType = "REFERENCES" // [name, ...] of other objects
+	MaterialValue   ValueType = "MATERIAL"   // properties of a MATERIAL
+	PropertiesValue ValueType = "PROPERTIES" // properties described by the class
+)
+
+// Property describes a property of an object class.
+type Property struct {
+	Name        string
+	Type        ValueType
+	Required    bool
+	Description string
+	Values      []string // allowed values of a STRING, any if empty
+	Minimum     *float64 // smallest allowed NUMBER or INTEGER, any if nil
+}
+
+// atLeast returns the minimum of a property.
+func atLeast(minimum float64) *float64 {
+	return &minimum
+}
+
+// Class describes the value of objects of one class, e.g. SPHERE.
+type Class struct {
+	Name        string
+	Description string
+	Value       ValueType  // PropertiesValue for classes with properties
+	Properties  []Property // known properties, if Value is PropertiesValue
+	Unique      bool       // at most one object of the class is allowed
+}
+
+// Property returns the description of the property with the given name.
+func (c Class) Property(name string) (Property, bool) {
+	for _, property := range c.Properties {
+		if property.Name == name {
+			return property, true
+		}
+	}
+	return Property{}, false
+}
+
+// transformProperties are accepted by every object placed in the scene.
+var transformProperties = []Property{
+	{Name: "position", Type: VectorValue, Description: "Position relative to the parent group."},
+	{Name: "rotation", Type: RotationValue, Description: "Euler angles in degrees or a quaternion [x, y, z, w]."},
+	{Name: "rotationOrder", Type: StringValue, Description: `Order of the Euler angles, "XYZ" by default.`},
+	{Name: "scale", Type: ScaleValue, Description: "A number or a scale for each axis."},
+	{Name: "matrix", Type: MatrixValue, Description: "A 4x4 transform used instead of position, rotation and scale."},
+}
+
+func withTransform(properties ...Property) []Property {
+	return append(properties, transformProperties...)
+}
+
+// Classes describes every object class of the language.
+var Classes = []Class{
+	{
+		Name:        token.NUMBER,
+		Description: "A number constant.",
+		Value:       NumberValue,
+	},
+	{
+		Name:        token.COLOR,
+		Description: "A color constant [r, g, b].",
+		Value:       ColorValue,
+	},
+	{
+		Name:        token.MATERIAL,
+		Description: "Describes how a surface reflects light.",
+		Value:       PropertiesValue,
+		Properties: []Property{
+			{Name: "color", Type: ColorValue, Description: "Color of the surface, white by default."},
+			{Name: "ambientIntensity", Type: NumberValue, Description: "Share of the ambient light reflected, 0.1 by default."},
+			{Name: "diffuseIntensity", Type: NumberValue, Description: "Share of the light scattered by the surface, 0.9 by default."},
+			{Name: "specularIntensity", Type: NumberValue, Description: "Strength of highlights, 0 by default."},
+			{Name: "shininess", Type: NumberValue, Description: "Exponent of the highlights, larger for smaller and sharper ones, 32 by default."},
+			{Name: "reflectivity", Type: NumberValue, Description: "Share of the light reflected like in a mirror, 0 by default."},
+			{Name: "transparency", Type: NumberValue, Description: "Share of the light passing through the surface, 0 by default."},
+			{Name: "ior", Type: NumberValue, Description: "Index of refraction of the inside, e.g. 1.5 for glass, 1 by default."},
+			{Name: "emission", Type: ColorValue, Description: "Light given off by the surface, black by default."},
+		},
+	},
+	{
+		Name:        token.SPHERE,
+		Description: "A sphere centered at its position.",
+		Value:       PropertiesValue,
+		Properties: withTransform(
+			Property{Name: "radius", Type: NumberValue, Required: true, Description: "Radius of the sphere."},
+			Property{Name: "material", Type: MaterialValue, Description: "Material of the surface."},
+		),
+	},
+	{
+		Name:        token.LIGHT,
+		Description: "A light shining from its position, or along its Z axis for directional and spot lights.",
+		Value:       PropertiesValue,
+		Properties: withTransform(
+			Property{Name: "kind", Type: StringValue, Values: []string{"point", "directional", "spot"}, Description: `Kind of light, "point" by default to shine in every direction, "directional" for a distant light like the sun, or "spot" to shine in a cone.`},
+			Property{Name: "color", Type: ColorValue, Description: "Color of the light, white by default."},
+			Property{Name: "diffuseIntensity", Type: NumberValue, Description: "Strength of the light scattered by surfaces, 1 by default."},
+			Property{Name: "specularIntensity", Type: NumberValue, Description: "Strength of the highlights, 1 by default."},
+			Property{Name: "shape", Type: StringValue, Values: []string{"point", "sphere", "rectangle", "disk"}, Description: `Shape of a point or spot light, "point" by default for hard shadows, or "sphere", "rectangle" or "disk" for soft shadows. Rectangles and disks lie in the XY plane.`},
+			Property{Name: "radius", Type: NumberValue, Description: "Radius of a sphere or disk, 0.5 by default."},
+			Property{Name: "width", Type: NumberValue, Description: "Size of a rectangle along the X axis, 1 by default."},
+			Property{Name: "height", Type: NumberValue, Description: "Size of a rectangle along the Y axis, 1 by default."},
+			Property{Name: "samples", Type: IntegerValue, Minimum: atLeast(1), Description: "Shadow rays towards a light with a shape from every shaded point, 16 by default."},
+			Property{Name: "coneAngle", Type: NumberValue, Description: "Angle in degrees between the axis of a spot light and the edge of its cone, 30 by default."},
+			Property{Name: "falloff", Type: NumberValue, Description: "Angle in degrees inside the edge of the cone over which a spot light fades out, 0 by default for a sharp edge."},
+		),
+	},
+	{
+		Name:        token.GROUP,
+		Description: "Places its children relative to its own transform.",
+		Value:       PropertiesValue,
+		Properties: withTransform(
+			Property{Name: "children", Type: ReferencesValue, Description: "Names of the objects in the group."},
+		),
+	},
+	{
+		Name:        token.CAMERA,
+		Description: "The camera, looking along its Z axis with Y pointing up. Change it with MODIFY CAMERA.",
+		Value:       PropertiesValue,
+		Properties: withTransform(
+			Property{Name: "fov", Type: NumberValue, Description: "Vertical field of view in degrees, 60 by default."},
+			Property{Name: "focalLength", Type: NumberValue, Description: "Focal length of the lens in millimetres, which gives the field of view with the sensorHeight in place of fov."},
+			Property{Name: "sensorHeight", Type: NumberValue, Description: "Height of the sensor in millimetres, used with focalLength, 24 by default."},
+			Property{Name: "focalDistance", Type: NumberValue, Description: "Distance to the plane in focus."},
+			Property{Name: "aperture", Type: NumberValue, Description: "Diameter of the lens, 0 by default for a pinhole camera. With a focalDistance, objects away from it are blurred."},
+			Property{Name: "ambientIntensity", Type: NumberValue, Description: "Strength of the light reaching every surface, 1 by default."},
+		),
+	},
+	{
+		Name:        token.RENDER,
+		Description: "Settings of the renderer. Options given to sdl render take precedence.",
+		Value:       PropertiesValue,
+		Unique:      true,
+		Properties: []Property{
+			{Name: "integrator", Type: StringValue, Values: []string{"whitted", "path"}, Description: `How light is traced, "whitted" by default or "path" for path tracing with global illumination.`},
+			{Name: "samples", Type: IntegerValue, Minimum: atLeast(1), Description: "Samples per pixel, 1 by default, or 16 with path tracing."},
+			{Name: "maxDepth", Type: IntegerValue, Minimum: atLeast(0), Description: "Number of reflections and refractions, or bounces with path tracing, followed from each camera ray, 5 by default."},
+			{Name: "shading", Type: StringValue, Values: []string{"phong", "blinn-phong"}, Description: `Shading model, "phong" by default or "blinn-phong".`},
+			{Name: "sampler", Type: StringValue, Values: []string{"stratified", "jittered"}, Description: `How samples are spread over a pixel, "stratified" by default for one in every cell of a grid, or "jittered" for all at random.`},
+			{Name: "filter", Type: StringValue, Values: []string{"box", "tent", "gaussian", "mitchell"}, Description: `How samples are weighted by their distance from the center of a pixel, "box" by default, "tent", "gaussian" or "mitchell".`},
+			{Name: "filterRadius", Type: NumberValue, Description: "Radius of the filter in pixels, by default 0.5 for box, 1 for tent, 1.5 for gaussian and 2 for mitchell."},
+			{Name: "exposure", Type: NumberValue, Description: "Stops by which 8-bit images are brightened before tone mapping, negative to darken them, 0 by default."},
+			{Name: "toneMap", Type: StringValue, Values: []string{"none", "reinhard", "aces"}, Description: `How colors brighter than white are shown in 8-bit images, "none" by default to clip them, "reinhard" or "aces".`},
+		},
+	},
+}
+
+// LookupClass returns the description of the class with the given name.
+func LookupClass(name string) (Class, bool) {
+	for _, class := range Classes {
+		if class.Name == name {
+			return class, true
+		}
+	}
+	return Class{}, false
+}
+
+// Problem is a value of an entity which does not match the schema.
+type Problem struct {
+	Entity   string // name of the entity
+	Class    string
+	Property string // path to the property, e.g. material.color, empty for the whole value
+	Message  string
+}
+
+func (p Problem) String() string {
+	if p.Property == "" {
+		return fmt.Sprintf("%s %s: %s", p.Class, p.Entity, p.Message)
+	}
+	return fmt.Sprintf("%s %s: %s: %s", p.Class, p.Entity, p.Property, p.Message)
+}
+
+// ValidationError lists the problems found in the evaluated values.
+type ValidationError []Problem
+
+func (e ValidationError) Error() string {
+	lines := make([]string, len(e))
+	for i, problem := range e {
+		lines[i] = problem.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Validate checks every entity against the schema of its class.
+// Entities are checked class by class, in the order of Classes.
+func Validate(values evaluator.EvaluatedValues) ValidationError {
+	var problems ValidationError
+	for _, class := range Classes {
+		for i, entity := range values.Entities[class.Name] {
+			if class.Unique && i > 0 {
+				problems = append(problems, Problem{Entity: entity.Name, Class: entity.Class, Message: fmt.Sprintf("only one %s object is allowed", class.Name)})
+				continue
+			}
+			for _, problem := range checkValue(class.Value, class, entity.Value, "") {
+				problem.Entity = entity.Name
+				problem.Class = entity.Class
+				problems = append(problems, problem)
+			}
+		}
+	}
+	return problems
+}
+
+func checkValue(valueType ValueType, class Class, value evaluator.Object, path string) []Problem {
+	problem := func(format string, a ...interface{}) []Problem {
+		return []Problem{{Property: path, Message: fmt.Sprintf(format, a...)}}
+	}
+
+	switch valueType {
+	case NumberValue:
+		if _, ok := value.(*evaluator.Number); !ok {
+			return problem("expected a NUMBER, got %s", value.Type())
+		}
+	case IntegerValue:
+		number, ok := value.(*evaluator.Number)
+		if !ok {
+			return problem("expected a whole NUMBER, got %s", value.Type())
+		}
+		if number.Value != math.Trunc(number.Value) {
+			return problem("expected a whole NUMBER, got %s", number.Inspect())
+		}
+	case StringValue:
+		if _, ok := value.(*evaluator.String); !ok {
+			return problem("expected a STRING, got %s", value.Type())
+		}
+	case ColorValue, VectorValue:
+		if !isNumberArray(value, 3) {
+			return problem("expected an array of 3 numbers, got %s", describe(value))
+		}
+	case RotationValue:
+		if !isNumberArray(value, 3) && !isNumberArray(value, 4) {
+			return problem("expected an array of 3 or 4 numbers, got %s", describe(value))
+		}
+	case ScaleValue:
+		if _, ok := value.(*evaluator.Number); !ok && !isNumberArray(value, 3) {
+			return problem("expected a NUMBER or an array of 3 numbers, got %s", describe(value))
+		}
+	case MatrixValue:
+		// The evaluator checks the shape of matrices when it computes transforms.
+		if _, ok := value.(*evaluator.Array); !ok {
+			return problem("expected an ARRAY, got %s", value.Type())
+		}
+	case ReferencesValue:
+		if _, ok := value.(*evaluator.Array); !ok {
+			return problem("expected an array of object names, got %s", value.Type())
+		}
+	case MaterialValue:
+		material, _ := LookupClass(token.MATERIAL)
+		return checkValue(PropertiesValue, material, value, path)
+	case PropertiesValue:
+		return checkProperties(class, value, path)
+	}
+
+	return nil
+}
+
+func checkProperties(class Class, value evaluator.Object, path string) []Problem {
+	dictionary, ok := value.(*evaluator.Dictionary)
+	if !ok {
+		return []Problem{{Property: path, Message: fmt.Sprintf("expected properties, got %s", value.Type())}}
+	}
+
+	prefix := ""
+	if path != "" {
+		prefix = path + "."
+	}
+
+	var problems []Problem
+	for _, property := range class.Properties {
+		if _, ok := dictionary.Properties[property.Name]; !ok && property.Required {
+			problems = append(problems, Problem{Property: prefix + property.Name, Message: "missing required property"})
+		}
+	}
+
+	for _, key := range dictionary.Keys {
+		property, ok := class.Property(key)
+		if !ok {
+			problems = append(problems, Problem{Property: prefix + key, Message: fmt.Sprintf("unknown property of %s", class.Name)})
+			continue
+		}
+
+		value := dictionary.Properties[key]
+		if found := checkValue(property.Type, class, value, prefix+key); len(found) > 0 {
+			problems = append(problems, found...)
+			continue
+		}
+		if s, ok := value.(*evaluator.String); ok && len(property.Values) > 0 && !contains(property.Values, s.Value) {
+			problems = append(problems, Problem{Property: prefix + key, Message: fmt.Sprintf("expected %s, got %q", quote(property.Values), s.Value)})
+		}
+		if n, ok := value.(*evaluator.Number); ok && property.Minimum != nil && n.Value < *property.Minimum {
+			problems = append(problems, Problem{Property: prefix + key, Message: fmt.Sprintf("must be at least %s, got %s", evaluator.Number{Value: *property.Minimum}.Inspect(), n.Inspect())})
+		}
+	}
+
+	return problems
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// quote lists the values as Go strings, e.g. "a", "b" or "c".
+func quote(values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = fmt.Sprintf("%q", value)
+	}
+	if len(quoted) == 1 {
+		return quoted[0]
+	}
+	return strings.Join(quoted[:len(quoted)-1], ", ") + " or " + quoted[len(quoted)-1]
+}
+
+func isNumberArray(value evaluator.Object, length int) bool {
+	array, ok := value.(*evaluator.Array)
+	if !ok || len(array.Elements) != length {
+		return false
+	}
+
+	for _, element := range array.Elements {
+		if _, ok := element.(*evaluator.Number); !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// describe names the type of a value, including the length of arrays.
+func describe(value evaluator.Object) string {
+	if array, ok := value.(*evaluator.Array); ok {
+		return fmt.Sprintf("an ARRAY of %d elements", len(array.Elements))
+	}
+	return string(value.Type())
+}
@@ -0,0 +1,60 @@
+package shading
+
+import (
+	"math"
+
+	"github.com/kacperkrolak/scene-description-language/scene"
+	"github.com/kacperkrolak/scene-description-language/transform"
+)
+
+// Incoming is the light reaching a point from one point of a light.
+type Incoming struct {
+	Light    scene.Light
+	ToLight  transform.Vec3 // Unit direction from the point to the light.
+	Distance float64        // Distance to the light, infinite for directional lights.
+	Strength float64        // Share of the light reaching the point, less than 1 at the edge of a spot light.
+}
+
+// Incident returns the light reaching a point from the point of a light
+// chosen by u1 and u2 in [0, 1). Points are spread evenly over the shape of
+// the light, whose every point shines like a point light.
+func Incident(light scene.Light, point transform.Vec3, u1, u2 float64) Incoming {
+	if light.Kind == "directional" {
+		return Incoming{Light: light, ToLight: light.Direction.Scale(-1), Distance: math.Inf(1), Strength: 1}
+	}
+
+	position := light.Position
+	switch light.Shape {
+	case "sphere":
+		z := 1 - 2*u1
+		r, phi := math.Sqrt(math.Max(0, 1-z*z)), 2*math.Pi*u2
+		position = light.Transform.MulPoint(transform.Vec3{X: r * math.Cos(phi), Y: r * math.Sin(phi), Z: z}.Scale(light.Radius))
+	case "disk":
+		r, phi := light.Radius*math.Sqrt(2*math.Abs(u1-0.5)), 2*math.Pi*u2
+		position = light.Transform.MulPoint(transform.Vec3{X: r * math.Cos(phi), Y: r * math.Sin(phi)})
+	case "rectangle":
+		position = light.Transform.MulPoint(transform.Vec3{X: (u1 - 0.5) * light.Width, Y: (u2 - 0.5) * light.Height})
+	}
+
+	toLight := position.Sub(point)
+	in := Incoming{Light: light, ToLight: toLight.Normalize(), Distance: toLight.Length(), Strength: 1}
+	if light.Kind == "spot" {
+		in.Strength = spot(light, point.Sub(light.Position).Normalize())
+	}
+	return in
+}
+
+// spot returns the share of a spot light shining in the unit direction,
+// fading smoothly from full strength to nothing over the falloff angle
+// inside the edge of the cone.
+func spot(light scene.Light, direction transform.Vec3) float64 {
+	angle := transform.Degrees(math.Acos(math.Max(-1, math.Min(1, light.Direction.Dot(direction)))))
+	if angle >= light.ConeAngle {
+		return 0
+	}
+	if light.Falloff <= 0 || angle <= light.ConeAngle-light.Falloff {
+		return 1
+	}
+	t := (light.ConeAngle - angle) / light.Falloff
+	return t * t * (3 - 2*t)
+}
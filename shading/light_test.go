@@ -0,0 +1,76 @@
+package shading
+
+import (
+	"math"
+	"testing"
+
+	"github.com/kacperkrolak/scene-description-language/scene"
+	"github.com/kacperkrolak/scene-description-language/transform"
+)
+
+func TestIncidentDirectional(t *testing.T) {
+	sun := scene.Light{Kind: "directional", Direction: transform.Vec3{Y: -1}}
+	in := Incident(sun, transform.Vec3{X: 5, Z: 3}, 0.3, 0.7)
+	if in.ToLight != (transform.Vec3{Y: 1}) || !math.IsInf(in.Distance, 1) || in.Strength != 1 {
+		t.Errorf("wrong light from the sun. got=%+v", in)
+	}
+}
+
+func TestIncidentShapes(t *testing.T) {
+	// The light hangs 4 units above the origin, facing down.
+	world := transform.Translation(transform.Vec3{Y: 4}).Mul(transform.RotationX(math.Pi / 2))
+	tests := []struct {
+		shape  string
+		inside func(p transform.Vec3) bool
+	}{
+		{"point", func(p transform.Vec3) bool { return p == transform.Vec3{Y: 4} }},
+		{"sphere", func(p transform.Vec3) bool { return math.Abs(p.Sub(transform.Vec3{Y: 4}).Length()-0.5) < 1e-9 }},
+		{"disk", func(p transform.Vec3) bool { return math.Abs(p.Y-4) < 1e-9 && math.Hypot(p.X, p.Z) <= 0.5+1e-9 }},
+		{"rectangle", func(p transform.Vec3) bool {
+			return math.Abs(p.Y-4) < 1e-9 && math.Abs(p.X) <= 1+1e-9 && math.Abs(p.Z) <= 0.5+1e-9
+		}},
+	}
+	for _, tt := range tests {
+		light := scene.Light{Shape: tt.shape, Transform: world, Position: world.Position(), Radius: 0.5, Width: 2, Height: 1}
+		spread := 0.0
+		for i := 0; i < 16; i++ {
+			in := Incident(light, transform.Vec3{}, float64(i%4)/4, float64(i/4)/4)
+			p := in.ToLight.Scale(in.Distance)
+			if !tt.inside(p) {
+				t.Errorf("%s: point %v is not on the light", tt.shape, p)
+			}
+			spread = math.Max(spread, p.Sub(transform.Vec3{Y: 4}).Length())
+		}
+		if tt.shape != "point" && spread < 0.25 {
+			t.Errorf("%s: points are not spread over the light, farthest %g from its center", tt.shape, spread)
+		}
+	}
+}
+
+func TestIncidentSpot(t *testing.T) {
+	light := scene.Light{Kind: "spot", Position: transform.Vec3{Y: 1}, Direction: transform.Vec3{Y: -1}, ConeAngle: 30, Falloff: 10}
+	tests := []struct {
+		angle    float64
+		strength float64
+	}{
+		{0, 1},
+		{20, 1},
+		{25, 0.5},
+		{30, 0},
+		{60, 0},
+	}
+	for _, tt := range tests {
+		a := transform.Radians(tt.angle)
+		point := transform.Vec3{X: math.Sin(a), Y: 1 - math.Cos(a)}
+		got := Incident(light, point, 0.5, 0.5).Strength
+		if math.Abs(got-tt.strength) > 1e-9 {
+			t.Errorf("wrong strength at %g°. got=%g, want=%g", tt.angle, got, tt.strength)
+		}
+	}
+
+	// Without falloff the edge is sharp.
+	light.Falloff = 0
+	if in := Incident(light, transform.Vec3{X: math.Sin(transform.Radians(29)), Y: 1 - math.Cos(transform.Radians(29))}, 0.5, 0.5); in.Strength != 1 {
+		t.Errorf("wrong strength inside a sharp cone. got=%g", in.Strength)
+	}
+}
@@ -0,0 +1,91 @@
+// Package shading computes the light reflected by surfaces towards the viewer,
+// with the Phong or Blinn-Phong reflection model.
+//
+// The color of a point is the sum of the ambient light it reflects,
+//
+//	C * ka * A
+//
+// and, for every light reaching it, the diffuse and specular reflections
+//
+//	C * Cl * kd * Id * max(0, N·L)
+//	Cl * ks * Is * max(0, R·V)^n    (Phong)
+//	Cl * ks * Is * max(0, N·H)^n    (Blinn-Phong)
+//
+// where C, ka, kd, ks and n are the color, intensities and shininess of the
+// MATERIAL, A is the ambientIntensity of the CAMERA, Cl, Id and Is are the
+// color and intensities of the LIGHT, N is the normal of the surface, L the
+// direction to the light, V the direction to the viewer, R the reflection of
+// L about N and H the direction halfway between L and V. Highlights take the
+// color of the light, and only surfaces facing a light have them.
+// Light does not weaken with distance. Lights with a shape are sampled at
+// many points, each shining with an equal share of the light, which gives
+// soft shadows, and spot lights fade out at the edge of their cone.
+package shading
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/kacperkrolak/scene-description-language/scene"
+	"github.com/kacperkrolak/scene-description-language/transform"
+)
+
+// Model selects how highlights are computed.
+type Model string
+
+const (
+	Phong      Model = "phong"       // Highlights follow the reflection of the light.
+	BlinnPhong Model = "blinn-phong" // Highlights follow the halfway vector, and are wider for the same shininess.
+)
+
+// ParseModel returns the model with the given name.
+func ParseModel(s string) (Model, error) {
+	switch Model(s) {
+	case Phong, BlinnPhong:
+		return Model(s), nil
+	}
+	return "", fmt.Errorf("unknown shading model %q, expected phong or blinn-phong", s)
+}
+
+// Surface is a point seen by the viewer.
+type Surface struct {
+	Point    transform.Vec3
+	Normal   transform.Vec3 // Unit normal facing away from the surface.
+	ToViewer transform.Vec3 // Unit direction from the point to the viewer.
+	Material scene.Material
+}
+
+// Ambient returns the ambient light reflected by a material.
+func Ambient(material scene.Material, ambientIntensity float64) scene.Color {
+	return material.Color.Scale(material.AmbientIntensity * ambientIntensity)
+}
+
+// Direct returns the light reflected towards the viewer from the incoming
+// light, including the highlight.
+func (m Model) Direct(surface Surface, in Incoming) scene.Color {
+	toLight, light := in.ToLight, in.Light
+	lambert := surface.Normal.Dot(toLight)
+	if lambert <= 0 {
+		return scene.Color{}
+	}
+
+	material, lightColor := surface.Material, light.Color.Scale(in.Strength)
+	color := material.Color.Mul(lightColor).Scale(material.DiffuseIntensity * light.DiffuseIntensity * lambert)
+	if material.SpecularIntensity == 0 || light.SpecularIntensity == 0 {
+		return color
+	}
+
+	var alignment float64
+	if m == BlinnPhong {
+		alignment = surface.Normal.Dot(toLight.Add(surface.ToViewer).Normalize())
+	} else {
+		reflected := surface.Normal.Scale(2 * lambert).Sub(toLight)
+		alignment = reflected.Dot(surface.ToViewer)
+	}
+	if alignment <= 0 {
+		return color
+	}
+
+	highlight := material.SpecularIntensity * light.SpecularIntensity * math.Pow(alignment, material.Shininess)
+	return color.Add(lightColor.Scale(highlight))
+}
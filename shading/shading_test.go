@@ -0,0 +1,77 @@
+package shading
+
+import (
+	"math"
+	"testing"
+
+	"github.com/kacperkrolak/scene-description-language/scene"
+	"github.com/kacperkrolak/scene-description-language/transform"
+)
+
+func testColor(t *testing.T, name string, got, expected scene.Color) {
+	t.Helper()
+	const tolerance = 1e-9
+	if math.Abs(got.R-expected.R) > tolerance || math.Abs(got.G-expected.G) > tolerance || math.Abs(got.B-expected.B) > tolerance {
+		t.Errorf("%s has wrong color. got=%v, want=%v", name, got, expected)
+	}
+}
+
+var material = scene.Material{
+	Color:             scene.Color{R: 1, G: 0.5},
+	AmbientIntensity:  0.2,
+	DiffuseIntensity:  0.5,
+	SpecularIntensity: 0.25,
+	Shininess:         4,
+}
+
+var light = scene.Light{Color: scene.Color{R: 1, G: 1, B: 0.5}, DiffuseIntensity: 2, SpecularIntensity: 0.5}
+
+func TestAmbient(t *testing.T) {
+	testColor(t, "ambient", Ambient(material, 0.5), scene.Color{R: 0.1, G: 0.05})
+}
+
+func TestDirect(t *testing.T) {
+	up := transform.Vec3{Y: 1}
+	surface := Surface{Normal: up, ToViewer: up, Material: material}
+	toLight := transform.Vec3{X: 1, Y: 1}.Normalize()
+	in := Incoming{Light: light, ToLight: toLight, Distance: 1, Strength: 1}
+	cos45 := math.Sqrt(0.5)
+
+	// The diffuse part is the same in both models: C * Cl * kd * Id * cos 45°.
+	diffuse := scene.Color{R: cos45, G: 0.5 * cos45}
+	// Phong: the reflection of the light is 45° away from the viewer.
+	phong := light.Color.Scale(0.25 * 0.5 * math.Pow(cos45, 4))
+	// Blinn-Phong: the halfway vector is 22.5° away from the normal.
+	blinn := light.Color.Scale(0.25 * 0.5 * math.Pow(math.Cos(math.Pi/8), 4))
+
+	testColor(t, "phong", Phong.Direct(surface, in), diffuse.Add(phong))
+	testColor(t, "blinn-phong", BlinnPhong.Direct(surface, in), diffuse.Add(blinn))
+
+	// The highlight is strongest where the viewer sees the mirror image of the light.
+	mirror := surface
+	mirror.ToViewer = transform.Vec3{X: -1, Y: 1}.Normalize()
+	for _, model := range []Model{Phong, BlinnPhong} {
+		testColor(t, string(model)+" mirror", model.Direct(mirror, in), diffuse.Add(light.Color.Scale(0.25*0.5)))
+	}
+
+	// Nothing is reflected from lights behind the surface.
+	behind := in
+	behind.ToLight = transform.Vec3{Y: -1}
+	testColor(t, "behind", Phong.Direct(surface, behind), scene.Color{})
+
+	// The edge of a spot light lets through part of the light.
+	dimmed := in
+	dimmed.Strength = 0.5
+	testColor(t, "dimmed", Phong.Direct(surface, dimmed), diffuse.Add(phong).Scale(0.5))
+}
+
+func TestParseModel(t *testing.T) {
+	for _, name := range []string{"phong", "blinn-phong"} {
+		if model, err := ParseModel(name); err != nil || string(model) != name {
+			t.Errorf("wrong model for %q. got=%q, %v", name, model, err)
+		}
+	}
+	if _, err := ParseModel("gouraud"); err == nil || err.Error() != `unknown shading model "gouraud", expected phong or blinn-phong` {
+		t.Errorf("wrong error. got=%v", err)
+	}
+}
@@ -1,10 +1,28 @@
 package token
 
+import "fmt"
+
 type TokenType string
 
 type Token struct {
 	Type    TokenType
 	Literal string
+	Pos     Position // position of the first character of the token
+}
+
+// Position is a place in the source, both line and column start at 1.
+// The column counts bytes. The zero value means the position is unknown.
+type Position struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
 }
 
 func NewToken(tokenType TokenType, ch byte) Token {
@@ -16,11 +34,15 @@ var keywords = map[string]TokenType{
 	"CAMERA":   CAMERA,
 	"PLACE":    PLACE,
 	"AT":       AT,
+	"EXTENDS":  EXTENDS,
+	"INCLUDE":  INCLUDE,
 	"NUMBER":   NUMBER,
 	"COLOR":    COLOR,
 	"MATERIAL": MATERIAL,
 	"SPHERE":   SPHERE,
 	"LIGHT":    LIGHT,
+	"GROUP":    GROUP,
+	"RENDER":   RENDER,
 }
 
 func LookupIdent(ident string) TokenType {
@@ -37,6 +59,8 @@ const (
 	IDENT      = "IDENT"      // x, y, sphere1, light_blue ...
 	PROPERTIES = "PROPERTIES" // {x: 1, y: 2, z: 3}
 	FLOAT      = "FLOAT"
+	STRING     = "STRING"  // "materials.sdl"
+	COMMENT    = "COMMENT" // // up to the end of the line
 
 	// Operators
 	ASSIGN   = "="
@@ -48,6 +72,7 @@ const (
 	// Delimiters
 	COMMA    = ","
 	COLON    = ":"
+	DOT      = "."
 	LPAREN   = "("
 	RPAREN   = ")"
 	LBRACE   = "{"
@@ -56,10 +81,12 @@ const (
 	RBRACKET = "]"
 
 	// Keywords
-	MODIFY = "MODIFY"
-	CAMERA = "CAMERA"
-	PLACE  = "PLACE"
-	AT     = "AT"
+	MODIFY  = "MODIFY"
+	CAMERA  = "CAMERA"
+	PLACE   = "PLACE"
+	AT      = "AT"
+	EXTENDS = "EXTENDS"
+	INCLUDE = "INCLUDE"
 
 	// Object types
 	NUMBER   = "NUMBER"
@@ -67,6 +94,8 @@ const (
 	MATERIAL = "MATERIAL"
 	SPHERE   = "SPHERE"
 	LIGHT    = "LIGHT"
+	GROUP    = "GROUP"
+	RENDER   = "RENDER"
 
 	// Special token for statements that don't need a token
 	NONE = "NONE"
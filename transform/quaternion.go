@@ -0,0 +1,133 @@
+package transform
+
+import (
+	"fmt"
+	"math"
+)
+
+// EulerOrder names the order in which rotations around the axes are applied,
+// e.g. XYZ rotates around X first and around Z last.
+type EulerOrder string
+
+const (
+	XYZ EulerOrder = "XYZ"
+	XZY EulerOrder = "XZY"
+	YXZ EulerOrder = "YXZ"
+	YZX EulerOrder = "YZX"
+	ZXY EulerOrder = "ZXY"
+	ZYX EulerOrder = "ZYX"
+)
+
+// ParseEulerOrder checks that s names one of the six orders of the axes.
+func ParseEulerOrder(s string) (EulerOrder, error) {
+	switch order := EulerOrder(s); order {
+	case XYZ, XZY, YXZ, YZX, ZXY, ZYX:
+		return order, nil
+	default:
+		return "", fmt.Errorf("unknown rotation order %q, expected one of XYZ, XZY, YXZ, YZX, ZXY, ZYX", s)
+	}
+}
+
+// Quat is a quaternion representing a rotation.
+type Quat struct {
+	X, Y, Z, W float64
+}
+
+// QuatIdentity returns the quaternion of no rotation.
+func QuatIdentity() Quat {
+	return Quat{W: 1}
+}
+
+// QuatFromAxisAngle returns a rotation around the axis by the angle in radians.
+func QuatFromAxisAngle(axis Vec3, angle float64) Quat {
+	axis = axis.Normalize()
+	sin, cos := math.Sincos(angle / 2)
+	return Quat{axis.X * sin, axis.Y * sin, axis.Z * sin, cos}
+}
+
+// QuatFromEuler returns the same rotation as EulerInOrder.
+func QuatFromEuler(degrees Vec3, order EulerOrder) Quat {
+	rotation := QuatIdentity()
+	for _, axis := range order {
+		var step Quat
+		switch axis {
+		case 'X':
+			step = QuatFromAxisAngle(Vec3{X: 1}, Radians(degrees.X))
+		case 'Y':
+			step = QuatFromAxisAngle(Vec3{Y: 1}, Radians(degrees.Y))
+		case 'Z':
+			step = QuatFromAxisAngle(Vec3{Z: 1}, Radians(degrees.Z))
+		}
+		rotation = step.Mul(rotation)
+	}
+	return rotation
+}
+
+// QuatFromMat4 returns the rotation of a matrix which does not scale.
+func QuatFromMat4(m Mat4) Quat {
+	trace := m[0][0] + m[1][1] + m[2][2]
+	var q Quat
+	switch {
+	case trace > 0:
+		s := 0.5 / math.Sqrt(trace+1)
+		q = Quat{(m[2][1] - m[1][2]) * s, (m[0][2] - m[2][0]) * s, (m[1][0] - m[0][1]) * s, 0.25 / s}
+	case m[0][0] > m[1][1] && m[0][0] > m[2][2]:
+		s := 2 * math.Sqrt(1+m[0][0]-m[1][1]-m[2][2])
+		q = Quat{0.25 * s, (m[0][1] + m[1][0]) / s, (m[0][2] + m[2][0]) / s, (m[2][1] - m[1][2]) / s}
+	case m[1][1] > m[2][2]:
+		s := 2 * math.Sqrt(1+m[1][1]-m[0][0]-m[2][2])
+		q = Quat{(m[0][1] + m[1][0]) / s, 0.25 * s, (m[1][2] + m[2][1]) / s, (m[0][2] - m[2][0]) / s}
+	default:
+		s := 2 * math.Sqrt(1+m[2][2]-m[0][0]-m[1][1])
+		q = Quat{(m[0][2] + m[2][0]) / s, (m[1][2] + m[2][1]) / s, 0.25 * s, (m[1][0] - m[0][1]) / s}
+	}
+	return q.Normalize()
+}
+
+// Mul returns the rotation applying r and then q.
+func (q Quat) Mul(r Quat) Quat {
+	return Quat{
+		q.W*r.X + q.X*r.W + q.Y*r.Z - q.Z*r.Y,
+		q.W*r.Y - q.X*r.Z + q.Y*r.W + q.Z*r.X,
+		q.W*r.Z + q.X*r.Y - q.Y*r.X + q.Z*r.W,
+		q.W*r.W - q.X*r.X - q.Y*r.Y - q.Z*r.Z,
+	}
+}
+
+func (q Quat) Length() float64 {
+	return math.Sqrt(q.X*q.X + q.Y*q.Y + q.Z*q.Z + q.W*q.W)
+}
+
+// Normalize scales q to length 1, as only unit quaternions are rotations.
+// The zero quaternion is returned unchanged.
+func (q Quat) Normalize() Quat {
+	length := q.Length()
+	if length == 0 {
+		return q
+	}
+	return Quat{q.X / length, q.Y / length, q.Z / length, q.W / length}
+}
+
+// Conjugate returns the inverse rotation of a unit quaternion.
+func (q Quat) Conjugate() Quat {
+	return Quat{-q.X, -q.Y, -q.Z, q.W}
+}
+
+// Rotate applies the rotation to a vector.
+func (q Quat) Rotate(v Vec3) Vec3 {
+	u := Vec3{q.X, q.Y, q.Z}
+	// v' = v + 2w(u x v) + 2u x (u x v)
+	t := u.Cross(v).Scale(2)
+	return v.Add(t.Scale(q.W)).Add(u.Cross(t))
+}
+
+// Mat4 returns the rotation matrix of a unit quaternion.
+func (q Quat) Mat4() Mat4 {
+	x, y, z, w := q.X, q.Y, q.Z, q.W
+	return Mat4{
+		{1 - 2*(y*y+z*z), 2 * (x*y - z*w), 2 * (x*z + y*w), 0},
+		{2 * (x*y + z*w), 1 - 2*(x*x+z*z), 2 * (y*z - x*w), 0},
+		{2 * (x*z - y*w), 2 * (y*z + x*w), 1 - 2*(x*x+y*y), 0},
+		{0, 0, 0, 1},
+	}
+}
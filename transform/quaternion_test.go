@@ -0,0 +1,50 @@
+package transform
+
+import (
+	"math"
+	"testing"
+)
+
+func TestQuatFromEulerMatchesMatrix(t *testing.T) {
+	angles := Vec3{30, -60, 120}
+	for _, order := range []EulerOrder{XYZ, XZY, YXZ, YZX, ZXY, ZYX} {
+		q := QuatFromEuler(angles, order)
+		if !matAlmostEqual(q.Mat4(), EulerInOrder(angles, order)) {
+			t.Errorf("quaternion and matrix differ for order %s", order)
+		}
+
+		point := Vec3{1, 2, 3}
+		if !vecAlmostEqual(q.Rotate(point), EulerInOrder(angles, order).MulPoint(point)) {
+			t.Errorf("quaternion rotates point differently for order %s", order)
+		}
+	}
+}
+
+func TestQuatFromMat4(t *testing.T) {
+	tests := []Quat{
+		QuatIdentity(),
+		QuatFromAxisAngle(Vec3{X: 1}, math.Pi),
+		QuatFromAxisAngle(Vec3{Y: 1}, math.Pi),
+		QuatFromAxisAngle(Vec3{Z: 1}, math.Pi),
+		QuatFromAxisAngle(Vec3{1, 2, 3}, 2.5),
+	}
+
+	for _, q := range tests {
+		got := QuatFromMat4(q.Mat4())
+		if !matAlmostEqual(got.Mat4(), q.Mat4()) {
+			t.Errorf("wrong quaternion for %v. got=%v", q, got)
+		}
+	}
+}
+
+func TestQuatConjugate(t *testing.T) {
+	q := QuatFromAxisAngle(Vec3{1, 1, 0}, 1)
+	point := Vec3{3, 2, 1}
+	if !vecAlmostEqual(q.Conjugate().Rotate(q.Rotate(point)), point) {
+		t.Errorf("conjugate did not undo the rotation")
+	}
+
+	if !matAlmostEqual(q.Mul(q.Conjugate()).Mat4(), Identity()) {
+		t.Errorf("q * conjugate is not identity. got=%v", q.Mul(q.Conjugate()))
+	}
+}
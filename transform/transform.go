@@ -0,0 +1,292 @@
+// Package transform provides the vector and matrix math used to place objects in a scene.
+//
+// The coordinate system has X pointing right, Y up and Z forward, away from the viewer.
+// Matrices multiply column vectors, so in A.Mul(B) the transform B is applied first.
+package transform
+
+import "math"
+
+// Vec3 is a point or a direction in 3D space.
+type Vec3 struct {
+	X, Y, Z float64
+}
+
+func (v Vec3) Add(u Vec3) Vec3 {
+	return Vec3{v.X + u.X, v.Y + u.Y, v.Z + u.Z}
+}
+
+func (v Vec3) Sub(u Vec3) Vec3 {
+	return Vec3{v.X - u.X, v.Y - u.Y, v.Z - u.Z}
+}
+
+func (v Vec3) Scale(s float64) Vec3 {
+	return Vec3{v.X * s, v.Y * s, v.Z * s}
+}
+
+func (v Vec3) Dot(u Vec3) float64 {
+	return v.X*u.X + v.Y*u.Y + v.Z*u.Z
+}
+
+func (v Vec3) Cross(u Vec3) Vec3 {
+	return Vec3{
+		v.Y*u.Z - v.Z*u.Y,
+		v.Z*u.X - v.X*u.Z,
+		v.X*u.Y - v.Y*u.X,
+	}
+}
+
+func (v Vec3) Length() float64 {
+	return math.Sqrt(v.Dot(v))
+}
+
+// Normalize returns a vector with the same direction and length 1.
+// The zero vector is returned unchanged.
+func (v Vec3) Normalize() Vec3 {
+	length := v.Length()
+	if length == 0 {
+		return v
+	}
+	return v.Scale(1 / length)
+}
+
+// Mat4 is a 4x4 matrix stored in row-major order.
+type Mat4 [4][4]float64
+
+// Identity returns the transform that leaves every point in place.
+func Identity() Mat4 {
+	return Mat4{
+		{1, 0, 0, 0},
+		{0, 1, 0, 0},
+		{0, 0, 1, 0},
+		{0, 0, 0, 1},
+	}
+}
+
+func Translation(v Vec3) Mat4 {
+	return Mat4{
+		{1, 0, 0, v.X},
+		{0, 1, 0, v.Y},
+		{0, 0, 1, v.Z},
+		{0, 0, 0, 1},
+	}
+}
+
+func Scaling(v Vec3) Mat4 {
+	return Mat4{
+		{v.X, 0, 0, 0},
+		{0, v.Y, 0, 0},
+		{0, 0, v.Z, 0},
+		{0, 0, 0, 1},
+	}
+}
+
+// RotationX returns a rotation around the X axis by the angle in radians.
+func RotationX(angle float64) Mat4 {
+	sin, cos := math.Sincos(angle)
+	return Mat4{
+		{1, 0, 0, 0},
+		{0, cos, -sin, 0},
+		{0, sin, cos, 0},
+		{0, 0, 0, 1},
+	}
+}
+
+// RotationY returns a rotation around the Y axis by the angle in radians.
+func RotationY(angle float64) Mat4 {
+	sin, cos := math.Sincos(angle)
+	return Mat4{
+		{cos, 0, sin, 0},
+		{0, 1, 0, 0},
+		{-sin, 0, cos, 0},
+		{0, 0, 0, 1},
+	}
+}
+
+// RotationZ returns a rotation around the Z axis by the angle in radians.
+func RotationZ(angle float64) Mat4 {
+	sin, cos := math.Sincos(angle)
+	return Mat4{
+		{cos, -sin, 0, 0},
+		{sin, cos, 0, 0},
+		{0, 0, 1, 0},
+		{0, 0, 0, 1},
+	}
+}
+
+// Euler returns a rotation by the angles in degrees around the X, Y and Z axes,
+// applied in that order.
+func Euler(degrees Vec3) Mat4 {
+	return EulerInOrder(degrees, XYZ)
+}
+
+// EulerInOrder returns a rotation by the angles in degrees around the X, Y and Z axes,
+// applied in the given order. The axes are fixed, they do not rotate with the object.
+func EulerInOrder(degrees Vec3, order EulerOrder) Mat4 {
+	rotation := Identity()
+	for _, axis := range order {
+		var step Mat4
+		switch axis {
+		case 'X':
+			step = RotationX(Radians(degrees.X))
+		case 'Y':
+			step = RotationY(Radians(degrees.Y))
+		case 'Z':
+			step = RotationZ(Radians(degrees.Z))
+		}
+		rotation = step.Mul(rotation)
+	}
+	return rotation
+}
+
+// EulerFromMat4 returns the angles in degrees which, applied in the XYZ order,
+// give the rotation of m. The matrix must not contain scaling.
+func EulerFromMat4(m Mat4) Vec3 {
+	sinY := math.Max(-1, math.Min(1, -m[2][0]))
+	y := math.Asin(sinY)
+
+	var x, z float64
+	if math.Abs(sinY) < 1-1e-9 {
+		x = math.Atan2(m[2][1], m[2][2])
+		z = math.Atan2(m[1][0], m[0][0])
+	} else {
+		// Gimbal lock: rotations around X and Z are about the same axis, so attribute all of it to X.
+		x = math.Atan2(-m[1][2], m[1][1])
+	}
+
+	return Vec3{Degrees(x), Degrees(y), Degrees(z)}
+}
+
+// Compose returns the transform which scales, then rotates and then translates a point.
+func Compose(position Vec3, rotation Mat4, scale Vec3) Mat4 {
+	return Translation(position).Mul(rotation).Mul(Scaling(scale))
+}
+
+// Mul returns the product m * n, the transform applying n and then m.
+func (m Mat4) Mul(n Mat4) Mat4 {
+	var result Mat4
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			for k := 0; k < 4; k++ {
+				result[i][j] += m[i][k] * n[k][j]
+			}
+		}
+	}
+	return result
+}
+
+// MulPoint transforms a point, applying the translation of m.
+func (m Mat4) MulPoint(p Vec3) Vec3 {
+	return Vec3{
+		m[0][0]*p.X + m[0][1]*p.Y + m[0][2]*p.Z + m[0][3],
+		m[1][0]*p.X + m[1][1]*p.Y + m[1][2]*p.Z + m[1][3],
+		m[2][0]*p.X + m[2][1]*p.Y + m[2][2]*p.Z + m[2][3],
+	}
+}
+
+// MulDirection transforms a direction, ignoring the translation of m.
+func (m Mat4) MulDirection(d Vec3) Vec3 {
+	return Vec3{
+		m[0][0]*d.X + m[0][1]*d.Y + m[0][2]*d.Z,
+		m[1][0]*d.X + m[1][1]*d.Y + m[1][2]*d.Z,
+		m[2][0]*d.X + m[2][1]*d.Y + m[2][2]*d.Z,
+	}
+}
+
+// Transpose returns m with rows and columns swapped.
+func (m Mat4) Transpose() Mat4 {
+	var result Mat4
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			result[i][j] = m[j][i]
+		}
+	}
+	return result
+}
+
+// Inverse returns the transform undoing m.
+// If m cannot be inverted, e.g. because it scales an axis by 0, it returns false.
+func (m Mat4) Inverse() (Mat4, bool) {
+	// Gauss-Jordan elimination with partial pivoting on [m | I].
+	a := m
+	inverse := Identity()
+	for column := 0; column < 4; column++ {
+		pivot := column
+		for row := column + 1; row < 4; row++ {
+			if math.Abs(a[row][column]) > math.Abs(a[pivot][column]) {
+				pivot = row
+			}
+		}
+		if math.Abs(a[pivot][column]) < 1e-12 {
+			return Mat4{}, false
+		}
+		a[column], a[pivot] = a[pivot], a[column]
+		inverse[column], inverse[pivot] = inverse[pivot], inverse[column]
+
+		scale := 1 / a[column][column]
+		for j := 0; j < 4; j++ {
+			a[column][j] *= scale
+			inverse[column][j] *= scale
+		}
+
+		for row := 0; row < 4; row++ {
+			if row == column {
+				continue
+			}
+			factor := a[row][column]
+			for j := 0; j < 4; j++ {
+				a[row][j] -= factor * a[column][j]
+				inverse[row][j] -= factor * inverse[column][j]
+			}
+		}
+	}
+	return inverse, true
+}
+
+// IsAffine reports whether the last row of m is [0, 0, 0, 1],
+// which holds for every combination of translations, rotations and scalings.
+func (m Mat4) IsAffine() bool {
+	return m[3] == [4]float64{0, 0, 0, 1}
+}
+
+// Decompose splits an affine transform without shear into a translation,
+// a rotation and a scale, such that Compose(position, rotation.Mat4(), scale) == m.
+func Decompose(m Mat4) (position Vec3, rotation Quat, scale Vec3) {
+	position = m.Position()
+
+	columns := [3]Vec3{}
+	for j := 0; j < 3; j++ {
+		columns[j] = Vec3{m[0][j], m[1][j], m[2][j]}
+	}
+	scale = Vec3{columns[0].Length(), columns[1].Length(), columns[2].Length()}
+
+	// A mirrored transform has a negative determinant, keep the rotation proper by flipping one axis.
+	if columns[0].Cross(columns[1]).Dot(columns[2]) < 0 {
+		scale.X = -scale.X
+	}
+
+	rotationMatrix := Identity()
+	scales := [3]float64{scale.X, scale.Y, scale.Z}
+	for j := 0; j < 3; j++ {
+		if scales[j] == 0 {
+			continue
+		}
+		for i := 0; i < 3; i++ {
+			rotationMatrix[i][j] = m[i][j] / scales[j]
+		}
+	}
+
+	return position, QuatFromMat4(rotationMatrix), scale
+}
+
+// Position returns the point to which m moves the origin.
+func (m Mat4) Position() Vec3 {
+	return Vec3{m[0][3], m[1][3], m[2][3]}
+}
+
+func Radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+func Degrees(radians float64) float64 {
+	return radians * 180 / math.Pi
+}
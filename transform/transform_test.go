@@ -0,0 +1,182 @@
+package transform
+
+import (
+	"math"
+	"testing"
+)
+
+const epsilon = 1e-9
+
+func vecAlmostEqual(a, b Vec3) bool {
+	return math.Abs(a.X-b.X) < epsilon && math.Abs(a.Y-b.Y) < epsilon && math.Abs(a.Z-b.Z) < epsilon
+}
+
+func TestRotations(t *testing.T) {
+	tests := []struct {
+		rotation Mat4
+		point    Vec3
+		expected Vec3
+	}{
+		{RotationX(math.Pi / 2), Vec3{0, 1, 0}, Vec3{0, 0, 1}},
+		{RotationY(math.Pi / 2), Vec3{0, 0, 1}, Vec3{1, 0, 0}},
+		{RotationZ(math.Pi / 2), Vec3{1, 0, 0}, Vec3{0, 1, 0}},
+		{Euler(Vec3{90, 0, 0}), Vec3{0, 1, 0}, Vec3{0, 0, 1}},
+		// X is applied first: (0, 1, 0) -> (0, 0, 1) -> (1, 0, 0)
+		{Euler(Vec3{90, 90, 0}), Vec3{0, 1, 0}, Vec3{1, 0, 0}},
+		{Euler(Vec3{0, 0, 180}), Vec3{1, 2, 3}, Vec3{-1, -2, 3}},
+	}
+
+	for i, tt := range tests {
+		got := tt.rotation.MulPoint(tt.point)
+		if !vecAlmostEqual(got, tt.expected) {
+			t.Errorf("tests[%d] - wrong point. expected=%v, got=%v", i, tt.expected, got)
+		}
+	}
+}
+
+func TestCompose(t *testing.T) {
+	m := Compose(Vec3{1, 2, 3}, RotationZ(math.Pi/2), Vec3{2, 2, 2})
+
+	got := m.MulPoint(Vec3{1, 0, 0})
+	expected := Vec3{1, 4, 3}
+	if !vecAlmostEqual(got, expected) {
+		t.Errorf("wrong point. expected=%v, got=%v", expected, got)
+	}
+
+	got = m.MulDirection(Vec3{1, 0, 0})
+	expected = Vec3{0, 2, 0}
+	if !vecAlmostEqual(got, expected) {
+		t.Errorf("wrong direction. expected=%v, got=%v", expected, got)
+	}
+
+	if m.Position() != (Vec3{1, 2, 3}) {
+		t.Errorf("wrong position. got=%v", m.Position())
+	}
+}
+
+func TestMulOrder(t *testing.T) {
+	// Moving and then rotating differs from rotating and then moving.
+	move := Translation(Vec3{1, 0, 0})
+	rotate := RotationZ(math.Pi / 2)
+
+	got := rotate.Mul(move).MulPoint(Vec3{})
+	if !vecAlmostEqual(got, Vec3{0, 1, 0}) {
+		t.Errorf("wrong point for rotate after move. got=%v", got)
+	}
+
+	got = move.Mul(rotate).MulPoint(Vec3{})
+	if !vecAlmostEqual(got, Vec3{1, 0, 0}) {
+		t.Errorf("wrong point for move after rotate. got=%v", got)
+	}
+
+	if Identity().Mul(move) != move {
+		t.Errorf("identity changed the matrix")
+	}
+}
+
+func matAlmostEqual(a, b Mat4) bool {
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			if math.Abs(a[i][j]-b[i][j]) > epsilon {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestEulerOrders(t *testing.T) {
+	angles := Vec3{90, 90, 0}
+	point := Vec3{0, 1, 0}
+	tests := []struct {
+		order    EulerOrder
+		expected Vec3
+	}{
+		// X first: (0, 1, 0) -> (0, 0, 1) -> (1, 0, 0)
+		{XYZ, Vec3{1, 0, 0}},
+		// Y first leaves the point in place, then X: (0, 1, 0) -> (0, 0, 1)
+		{YXZ, Vec3{0, 0, 1}},
+	}
+
+	for _, tt := range tests {
+		got := EulerInOrder(angles, tt.order).MulPoint(point)
+		if !vecAlmostEqual(got, tt.expected) {
+			t.Errorf("wrong point for order %s. expected=%v, got=%v", tt.order, tt.expected, got)
+		}
+	}
+
+	if _, err := ParseEulerOrder("XXY"); err == nil {
+		t.Errorf("expected an error for order XXY")
+	}
+	if order, err := ParseEulerOrder("ZYX"); err != nil || order != ZYX {
+		t.Errorf("wrong order. got=%q, err=%v", order, err)
+	}
+}
+
+func TestInverse(t *testing.T) {
+	m := Compose(Vec3{1, 2, 3}, Euler(Vec3{10, 20, 30}), Vec3{2, 3, 4})
+	inverse, ok := m.Inverse()
+	if !ok {
+		t.Fatalf("matrix could not be inverted")
+	}
+
+	if !matAlmostEqual(m.Mul(inverse), Identity()) {
+		t.Errorf("m * inverse is not identity. got=%v", m.Mul(inverse))
+	}
+
+	if _, ok := Scaling(Vec3{1, 0, 1}).Inverse(); ok {
+		t.Errorf("expected a flattening matrix to have no inverse")
+	}
+
+	if !matAlmostEqual(Euler(Vec3{10, 20, 30}).Transpose(), mustInverse(t, Euler(Vec3{10, 20, 30}))) {
+		t.Errorf("transpose of a rotation is not its inverse")
+	}
+}
+
+func mustInverse(t *testing.T, m Mat4) Mat4 {
+	inverse, ok := m.Inverse()
+	if !ok {
+		t.Fatalf("matrix could not be inverted: %v", m)
+	}
+	return inverse
+}
+
+func TestEulerFromMat4(t *testing.T) {
+	tests := []Vec3{
+		{0, 0, 0},
+		{10, 20, 30},
+		{-45, 60, 170},
+		{30, 90, 0},
+	}
+
+	for _, angles := range tests {
+		got := EulerFromMat4(Euler(angles))
+		if !matAlmostEqual(Euler(got), Euler(angles)) {
+			t.Errorf("angles %v converted to %v, which is a different rotation", angles, got)
+		}
+	}
+}
+
+func TestDecompose(t *testing.T) {
+	position := Vec3{1, -2, 3}
+	rotation := QuatFromEuler(Vec3{10, -40, 75}, ZXY)
+	scale := Vec3{2, 0.5, 3}
+	m := Compose(position, rotation.Mat4(), scale)
+
+	gotPosition, gotRotation, gotScale := Decompose(m)
+	if !vecAlmostEqual(gotPosition, position) {
+		t.Errorf("wrong position. expected=%v, got=%v", position, gotPosition)
+	}
+	if !vecAlmostEqual(gotScale, scale) {
+		t.Errorf("wrong scale. expected=%v, got=%v", scale, gotScale)
+	}
+	if !matAlmostEqual(gotRotation.Mat4(), rotation.Mat4()) {
+		t.Errorf("wrong rotation. expected=%v, got=%v", rotation, gotRotation)
+	}
+
+	mirrored := Compose(position, rotation.Mat4(), Vec3{-1, 1, 1})
+	gotPosition, gotRotation, gotScale = Decompose(mirrored)
+	if !matAlmostEqual(Compose(gotPosition, gotRotation.Mat4(), gotScale), mirrored) {
+		t.Errorf("mirrored matrix was not decomposed correctly. got scale=%v, rotation=%v", gotScale, gotRotation)
+	}
+}